@@ -3,26 +3,32 @@ package app
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
 	"github.com/rivo/tview"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 )
 
 // Client App for gRPC-ChatRoom service usage.
 type ClientApp struct {
 	app                 *tview.Application
 	username            *string
+	token               string
 	conn                *grpc.ClientConn
 	stub                gs.ChatRoomClient
 	chatStream          gs.ChatRoom_ChatClient
 	navigator           *tview.Pages
 	publicMessageList   *tview.List
+	publicMessageIndex  map[string]int
+	botSenders          map[string]bool
 	privateMessageList  map[string]*tview.List
+	privateMessageIndex map[string]int
 	connectedClientList *tview.List
 	inputArea           *tview.TextArea
 	selectedIndex       int
@@ -31,7 +37,17 @@ type ClientApp struct {
 	RefreshInterval     time.Duration
 	Port                int
 	Ipaddr              string
-	nRecieveMessage     int
+	// path of a unix socket of the server, used instead of Ipaddr and Port
+	Socket string
+	// connect with TLS, trusting CAFile or the system certificates when it is empty
+	TLS    bool
+	CAFile string
+	// ring the terminal bell when a room message mentions the user
+	Bell            bool
+	bellPending     bool
+	unreadMentions  int
+	mentionButtons  []*tview.Button
+	nRecieveMessage int
 }
 
 // Start and run the client application
@@ -39,9 +55,24 @@ func (ca *ClientApp) Start() error {
 	var err error
 
 	serverAddr := fmt.Sprintf("%s:%d", ca.Ipaddr, ca.Port)
-	ca.conn, err = grpc.Dial(serverAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if ca.Socket != "" {
+		serverAddr = "unix:" + ca.Socket
+	}
+
+	creds := insecure.NewCredentials()
+	if ca.TLS || ca.CAFile != "" {
+		creds = credentials.NewTLS(nil)
+		if ca.CAFile != "" {
+			creds, err = credentials.NewClientTLSFromFile(ca.CAFile, "")
+		}
+	}
+
+	if err == nil {
+		ca.conn, err = grpc.Dial(serverAddr, grpc.WithTransportCredentials(creds))
+	}
 
 	ca.app = tview.NewApplication()
+	ca.app.SetBeforeDrawFunc(ca.ringBell)
 
 	if err != nil {
 		ca.alert("Cannot connect to server", "")
@@ -52,7 +83,10 @@ func (ca *ClientApp) Start() error {
 
 	ca.connectedClientList = tview.NewList()
 	ca.publicMessageList = tview.NewList()
+	ca.publicMessageIndex = make(map[string]int)
+	ca.botSenders = make(map[string]bool)
 	ca.privateMessageList = make(map[string]*tview.List)
+	ca.privateMessageIndex = make(map[string]int)
 	ca.navigator = tview.NewPages()
 
 	ca.inputArea = tview.NewTextArea()
@@ -62,6 +96,8 @@ func (ca *ClientApp) Start() error {
 	})
 
 	ca.nRecieveMessage = 0
+	ca.unreadMentions = 0
+	ca.mentionButtons = nil
 
 	ca.navigateToLogin()
 	ca.app.SetRoot(ca.navigator, true).EnableMouse(true).Run()
@@ -69,13 +105,19 @@ func (ca *ClientApp) Start() error {
 	return err
 }
 
+// context carrying the session token of the logged in user
+func (ca *ClientApp) authContext() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), gs.SessionTokenKey, ca.token)
+}
+
 // Start listening for messages from server
 func (ca *ClientApp) startListening() {
-	stream, err := ca.stub.Chat(context.Background())
+	stream, err := ca.stub.Chat(ca.authContext())
 	if err != nil {
 		ca.alert("Cannot connect to server", "")
 	} else {
 		ca.chatStream = stream
+		ca.loadUnreadMentions()
 
 		ca.chatStream.Send(&gs.ChatMessage{
 			Sender:  *ca.username,
@@ -84,17 +126,52 @@ func (ca *ClientApp) startListening() {
 
 		go func() {
 			for {
-				msg, err := ca.chatStream.Recv()
+				msg, err := stream.Recv()
 				if err != nil {
+					// stream was closed on purpose by logout
+					if ca.chatStream != stream {
+						return
+					}
+
 					ca.Exit()
-					ca.alert("Disconnected from server", "Login")
+					if errorReason(err) != "" {
+						ca.alert(errorMessage(err), "Login")
+					} else {
+						ca.alert("Disconnected from server", "Login")
+					}
 					return
 				} else {
-					if msg.GetPrivate() > 0 {
-						ca.updatePrivateMessageList(msg.GetSender(), msg.GetSender(), msg.GetMessage())
+					// messages sent by this user from another device are echoed back
+					fromOtherDevice := msg.GetSender() == *ca.username
+
+					if msg.GetPrivate() > 0 && fromOtherDevice {
+						index := ca.updatePrivateMessageList("You", msg.GetRecipent(), msg.GetMessage())
+						ca.privateMessageIndex[msg.GetId()] = index
+					} else if msg.GetPrivate() > 0 {
+						index := ca.updatePrivateMessageList(msg.GetSender(), msg.GetSender(), msg.GetMessage())
+						ca.privateMessageIndex[msg.GetId()] = index
 						ca.nRecieveMessage++
-					} else {
+					} else if msg.GetKind() == gs.MessageKind_MESSAGE_WARNING {
+						ca.updateMessageList(msg.GetSender(), "[yellow]"+msg.GetMessage())
+					} else if msg.GetKind() == gs.MessageKind_MESSAGE_MENTION {
+						// the room message itself comes separately
+						ca.notifyMention()
+					} else if msg.GetKind() == gs.MessageKind_MESSAGE_DELETED {
+						ca.markMessageDeleted(msg.GetId())
 						ca.updateMessageList(msg.GetSender(), msg.GetMessage())
+					} else {
+						// remember where the message is, for a later deletion
+						ca.publicMessageIndex[msg.GetId()] = ca.publicMessageList.GetItemCount()
+
+						if fromOtherDevice {
+							ca.updateMessageList("You", msg.GetMessage())
+						} else if msg.GetBot() {
+							// posted by a bot, not by a user, so it cannot be liked
+							ca.botSenders[msg.GetSender()] = true
+							ca.updateMessageList(msg.GetSender(), "[blue](bot)[-] "+ca.highlightMentions(msg.GetMessage()))
+						} else {
+							ca.updateMessageList(msg.GetSender(), ca.highlightMentions(msg.GetMessage()))
+						}
 					}
 				}
 			}
@@ -102,32 +179,36 @@ func (ca *ClientApp) startListening() {
 	}
 }
 
-// Request for login authentication from the server
-func (ca *ClientApp) requestLogin(password string) bool {
+// Request for login authentication from the server, totpCode is empty on the first step
+func (ca *ClientApp) requestLogin(password string, kickOtherSession bool, totpCode string) bool {
 	cred := gs.UserLoginCredentials{
-		Username: *ca.username,
-		Password: password,
+		Username:         *ca.username,
+		Password:         password,
+		KickOtherSession: &kickOtherSession,
+	}
+	if totpCode != "" {
+		cred.TotpCode = &totpCode
 	}
 
 	result, err := ca.stub.Login(context.Background(), &cred)
-	if err != nil {
-		ca.alert("Failed to request authentication from server. Please try again", "Login")
+	if err != nil && errorReason(err) == gs.ReasonEmailNotVerified {
+		ca.navigateToVerifyEmail(*ca.username)
+		ca.alert(errorMessage(err), "")
+		return false
+	} else if err != nil && errorReason(err) == gs.ReasonTOTPRequired {
+		// second step of the login
+		ca.navigateToTwoFactorLogin(password, kickOtherSession)
+		return false
+	} else if err != nil && totpCode != "" {
+		ca.alert(errorMessage(err), "Two-Factor Login")
+		return false
+	} else if err != nil {
+		ca.alert(errorMessage(err), "Login")
 		return false
 	}
 
-	switch result.Status {
-	case int32(codes.Unavailable), int32(codes.Unauthenticated):
-		{
-			return false
-		}
-	case int32(codes.OK):
-		{
-			return true
-		}
-	}
-
-	ca.alert("Unexpected error", "Login")
-	return false
+	ca.token = result.GetToken()
+	return true
 }
 
 // An alert modal
@@ -164,32 +245,24 @@ func (ca *ClientApp) createLoginForm() *tview.Form {
 
 	form.AddInputField("Username", "", 30, nil, nil).
 		AddPasswordField("Password", "", 30, '*', nil).
+		AddCheckbox("Logout other session", false, nil).
 		AddButton("Login", func() {
 			// Retrieve values from the form fields
 			usernameField, usernameFieldFound := form.GetFormItemByLabel("Username").(*tview.InputField)
 			passwordField, passwordFieldFound := form.GetFormItemByLabel("Password").(*tview.InputField)
+			kickField, _ := form.GetFormItemByLabel("Logout other session").(*tview.Checkbox)
 
 			if usernameFieldFound && passwordFieldFound {
 				username := usernameField.GetText()
 				ca.username = &username
 
 				password := passwordField.GetText()
-				isAuthenticated := ca.requestLogin(password)
+				isAuthenticated := ca.requestLogin(password, kickField.IsChecked(), "")
 				if !isAuthenticated {
 					usernameField.SetText("")
 					passwordField.SetText("")
 				} else {
-					ca.stillRunning = true
-					ca.alert("Login successfully!", "")
-					go ca.startListening()
-
-					go func() {
-						ca.refresh()
-						if !ca.stillRunning {
-							ca.refreshFuncs = []func(){}
-						}
-					}()
-					ca.navigateToPublicChatRoom()
+					ca.enterChatRoom()
 				}
 			} else {
 				msg := "Cannot access the input. Please try again later."
@@ -199,6 +272,9 @@ func (ca *ClientApp) createLoginForm() *tview.Form {
 		AddButton("Move to Register", func() {
 			ca.navigateToRegister()
 		}).
+		AddButton("Forgot password", func() {
+			ca.navigateToPasswordReset()
+		}).
 		AddButton("Quit", func() {
 			ca.Exit()
 		})
@@ -208,63 +284,99 @@ func (ca *ClientApp) createLoginForm() *tview.Form {
 	return form
 }
 
+// start chatting after a successful login
+func (ca *ClientApp) enterChatRoom() {
+	ca.stillRunning = true
+	ca.alert("Login successfully!", "")
+	go ca.startListening()
+
+	go func() {
+		ca.refresh()
+		if !ca.stillRunning {
+			ca.refreshFuncs = []func(){}
+		}
+	}()
+	ca.navigateToPublicChatRoom()
+}
+
+// add the profile fields shared by the registration and the edit profile forms
+func (ca *ClientApp) addProfileFields(form *tview.Form, profile *gs.PublicUserInfo) *tview.Form {
+	address := profile.GetAddress()
+
+	return form.AddInputField("Full Name", profile.GetFullName(), 30, nil, nil).
+		AddInputField("Email (optional)", profile.GetEmail(), 30, nil, nil).
+		AddInputField("Birthdate (optional)", profile.GetBirthdate(), 30, nil, nil).
+		AddInputField("Street (optional)", address.GetStreet(), 30, nil, nil).
+		AddInputField("City (optional)", address.GetCity(), 30, nil, nil).
+		AddInputField("Country", address.GetCountry(), 30, nil, nil)
+}
+
+// read the profile fields of a form into a User message
+func (ca *ClientApp) readProfileFields(form *tview.Form, user *gs.User) {
+	// Retrieve values from the form fields
+	fullNameField, _ := form.GetFormItemByLabel("Full Name").(*tview.InputField)
+	emailField, _ := form.GetFormItemByLabel("Email (optional)").(*tview.InputField)
+	birthdateField, _ := form.GetFormItemByLabel("Birthdate (optional)").(*tview.InputField)
+	streetField, _ := form.GetFormItemByLabel("Street (optional)").(*tview.InputField)
+	cityField, _ := form.GetFormItemByLabel("City (optional)").(*tview.InputField)
+	countryField, _ := form.GetFormItemByLabel("Country").(*tview.InputField)
+
+	user.FullName = fullNameField.GetText()
+
+	// Set optional fields if they are not empty
+	if emailField.GetText() != "" {
+		email := emailField.GetText()
+		user.Email = &email
+	}
+	if birthdateField.GetText() != "" {
+		birthdate := birthdateField.GetText()
+		user.Birthdate = &birthdate
+	}
+	if streetField.GetText() != "" || cityField.GetText() != "" || countryField.GetText() != "" {
+		street := streetField.GetText()
+		city := cityField.GetText()
+		user.Address = &gs.Address{
+			Street:  &street,
+			City:    &city,
+			Country: countryField.GetText(),
+		}
+	}
+}
+
 // a standart registration form
 func (ca *ClientApp) createUserRegistrationForm() *tview.Form {
 
 	form := tview.NewForm()
 
 	form.AddInputField("Username", "", 30, nil, nil).
-		AddPasswordField("Password", "", 30, '*', nil).
-		AddInputField("Full Name", "", 30, nil, nil).
-		AddInputField("Email (optional)", "", 30, nil, nil).
-		AddInputField("Birthdate (optional)", "", 30, nil, nil).
-		AddInputField("Street (optional)", "", 30, nil, nil).
-		AddInputField("City (optional)", "", 30, nil, nil).
-		AddInputField("Country", "", 30, nil, nil).
+		AddPasswordField("Password", "", 30, '*', nil)
+
+	ca.addProfileFields(form, nil).
 		AddButton("Register", func() {
+			ca.clearFieldErrors(form)
+
 			// Retrieve values from the form fields
 			usernameField, _ := form.GetFormItemByLabel("Username").(*tview.InputField)
 			passwordField, _ := form.GetFormItemByLabel("Password").(*tview.InputField)
-			fullNameField, _ := form.GetFormItemByLabel("Full Name").(*tview.InputField)
-			emailField, _ := form.GetFormItemByLabel("Email (optional)").(*tview.InputField)
-			birthdateField, _ := form.GetFormItemByLabel("Birthdate (optional)").(*tview.InputField)
-			streetField, _ := form.GetFormItemByLabel("Street (optional)").(*tview.InputField)
-			cityField, _ := form.GetFormItemByLabel("City (optional)").(*tview.InputField)
-			countryField, _ := form.GetFormItemByLabel("Country").(*tview.InputField)
 
 			// Create a User message based on the form values
 			user := &gs.User{
 				Username: usernameField.GetText(),
 				Password: passwordField.GetText(),
-				FullName: fullNameField.GetText(),
-			}
-
-			// Set optional fields if they are not empty
-			if emailField.GetText() != "" {
-				email := emailField.GetText()
-				user.Email = &email
-			}
-			if birthdateField.GetText() != "" {
-				birthdate := birthdateField.GetText()
-				user.Birthdate = &birthdate
-			}
-			if streetField.GetText() != "" || cityField.GetText() != "" || countryField.GetText() != "" {
-				street := streetField.GetText()
-				city := cityField.GetText()
-				user.Address = &gs.Address{
-					Street:  &street,
-					City:    &city,
-					Country: countryField.GetText(),
-				}
 			}
+			ca.readProfileFields(form, user)
 
 			// Handle the user registration logic with the created user message
 			result, err := ca.stub.Register(context.Background(), user)
 			if err != nil {
-				ca.alert(err.Error(), "Register")
+				ca.alertFormError(form, err, "Register")
 			} else {
-				ca.alert(fmt.Sprintf("User %s registered successfully with code %d!", result.GetUsername(), result.GetStatus()), "")
-				ca.navigateToLogin()
+				if user.Email != nil {
+					ca.navigateToVerifyEmail(user.Username)
+				} else {
+					ca.navigateToLogin()
+				}
+				ca.alert(result.GetMessage(), "")
 			}
 		}).
 		AddButton("Move to Login", func() {
@@ -346,6 +458,15 @@ func (ca *ClientApp) Exit() {
 	ca.app.Stop()
 }
 
+// drop the current session and restart the application on the login page
+func (ca *ClientApp) restart() {
+	ca.chatStream = nil
+	ca.token = ""
+
+	ca.Exit()
+	ca.Start()
+}
+
 // left area of chat room layout
 func (ca *ClientApp) createChatRoomLeftFlex() *tview.Flex {
 	leftFlex := tview.NewFlex().SetDirection(tview.FlexRow)
@@ -402,12 +523,46 @@ func (ca *ClientApp) createChatRoomRightFlex() *tview.Flex {
 	logoutBtn := tview.NewButton("Logout")
 	logoutBtn.SetBorder(true)
 	logoutBtn.SetSelectedFunc(func() {
-		ca.Exit()
-		ca.Start()
+		ca.chatStream = nil
+		ca.stub.Logout(ca.authContext(), &gs.UserRequest{
+			Sender: *ca.username,
+		})
+
+		ca.restart()
+	})
+
+	profileBtn := tview.NewButton("Edit profile")
+	profileBtn.SetBorder(true)
+	profileBtn.SetSelectedFunc(func() {
+		ca.navigateToEditProfile()
+	})
+
+	settingsBtn := tview.NewButton("Settings")
+	settingsBtn.SetBorder(true)
+	settingsBtn.SetSelectedFunc(func() {
+		ca.navigateToSettings()
+	})
+
+	searchBtn := tview.NewButton("Search")
+	searchBtn.SetBorder(true)
+	searchBtn.SetSelectedFunc(func() {
+		ca.navigateToSearch()
 	})
 
+	// every page has its own button, all of them show the unread mentions
+	mentionBtn := tview.NewButton(ca.mentionButtonLabel())
+	mentionBtn.SetBorder(true)
+	mentionBtn.SetSelectedFunc(func() {
+		ca.navigateToMentions()
+	})
+	ca.mentionButtons = append(ca.mentionButtons, mentionBtn)
+
 	rightFlex.AddItem(quitBtn, 0, 1, false)
 	rightFlex.AddItem(ca.connectedClientList, 0, 9, false)
+	rightFlex.AddItem(profileBtn, 0, 1, false)
+	rightFlex.AddItem(settingsBtn, 0, 1, false)
+	rightFlex.AddItem(searchBtn, 0, 1, false)
+	rightFlex.AddItem(mentionBtn, 0, 1, false)
 	rightFlex.AddItem(logoutBtn, 0, 1, false)
 	rightFlex.SetBorder(true)
 
@@ -459,15 +614,18 @@ func (ca *ClientApp) updateMessageList(sender, message string) {
 	}
 
 	likeHandler := func() {
-		if sender == "You" || sender == "Server" {
+		if sender == "You" || sender == "Server" || ca.botSenders[sender] {
 			return
 		}
 
-		ca.stub.LikeMessage(context.Background(), &gs.UserRequest{
+		_, err := ca.stub.LikeMessage(ca.authContext(), &gs.UserRequest{
 			Sender: *ca.username,
 			Target: &sender,
 		})
 
+		if err != nil {
+			ca.alert(errorMessage(err), "")
+		}
 	}
 
 	ca.publicMessageList.SetCurrentItem(ca.nRecieveMessage)
@@ -475,8 +633,20 @@ func (ca *ClientApp) updateMessageList(sender, message string) {
 	ca.app.SetFocus(ca.publicMessageList)
 }
 
-// update the message text view with the new incoming message
-func (ca *ClientApp) updatePrivateMessageList(sender, target, message string) {
+// replace the text of a deleted room message
+func (ca *ClientApp) markMessageDeleted(id string) {
+	index, ok := ca.publicMessageIndex[id]
+	if !ok || index >= ca.publicMessageList.GetItemCount() {
+		return
+	}
+
+	sender, _ := ca.publicMessageList.GetItemText(index)
+	ca.publicMessageList.SetItemText(index, sender, "[message deleted]")
+	delete(ca.publicMessageIndex, id)
+}
+
+// update the message text view with the new incoming message, returns its position in the list
+func (ca *ClientApp) updatePrivateMessageList(sender, target, message string) int {
 	var r rune
 	if sender == "You" {
 		r = '>'
@@ -492,6 +662,7 @@ func (ca *ClientApp) updatePrivateMessageList(sender, target, message string) {
 
 	ca.privateMessageList[target].AddItem(sender, message, r, nil)
 	ca.app.SetFocus(ca.privateMessageList[target])
+	return ca.privateMessageList[target].GetItemCount() - 1
 }
 
 // update the connected clients list
@@ -502,9 +673,9 @@ func (ca *ClientApp) updateOnlineClientsList() {
 	}
 
 	// Get the list of connected clients from the server
-	connectedClients, err := ca.stub.GetConnectedPeers(context.Background(), userRequest)
+	connectedClients, err := ca.stub.GetConnectedPeers(ca.authContext(), userRequest)
 	if err != nil {
-		ca.alert(fmt.Sprintf("Failed to get connected clients: %v", err), "")
+		ca.alert("Failed to get connected clients: "+errorMessage(err), "")
 		return
 	}
 
@@ -523,7 +694,11 @@ func (ca *ClientApp) updateOnlineClientsList() {
 	}
 	ca.connectedClientList.SetCurrentItem(ca.selectedIndex)
 
-	if ca.app.GetFocus() != ca.inputArea {
+	// do not steal the focus from pages other than the chat rooms
+	page, _ := ca.navigator.GetFrontPage()
+	onChatRoom := page == "Public Chat Room" || strings.HasPrefix(page, "Private Chat Room")
+
+	if onChatRoom && ca.app.GetFocus() != ca.inputArea {
 		ca.app.SetFocus(ca.connectedClientList)
 	}
 }
@@ -541,13 +716,13 @@ func (ca *ClientApp) createUserProfieView(target string) *tview.TextView {
 	userProfView.SetBorder(true).SetTitle("Profile")
 	userProfView.SetTitleAlign(tview.AlignRight)
 
-	profile, err := ca.stub.GetPeerInfomations(context.Background(), &gs.UserRequest{
+	profile, err := ca.stub.GetPeerInfomations(ca.authContext(), &gs.UserRequest{
 		Target: &target,
 		Sender: *ca.username,
 	})
 
 	if err != nil {
-		ca.alert(fmt.Sprintf("Failed to get user information: %v", err), "")
+		ca.alert("Failed to get user information: "+errorMessage(err), "")
 		return nil
 	}
 	profileString := fmt.Sprintf("Full Name: %s\nUsername: %s\nEmail: %s\nBirthday: %s\nAddress: %v", profile.GetFullName(), profile.GetUsername(), profile.GetEmail(), profile.GetBirthdate(), profile.GetAddress())
@@ -574,14 +749,20 @@ func (ca *ClientApp) createPrivateChatRoomLeftFlex(target string) *tview.Flex {
 		message := ca.inputArea.GetText()
 
 		if message != "" {
-			ca.updatePrivateMessageList("You", target, message)
+			index := ca.updatePrivateMessageList("You", target, message)
 
-			ca.stub.SendPrivateMessage(context.Background(), &gs.PrivateChatMessage{
+			sent, err := ca.stub.SendPrivateMessage(ca.authContext(), &gs.PrivateChatMessage{
 				Sender:   *ca.username,
 				Recipent: target,
 				Message:  message,
 			})
 
+			if err != nil {
+				ca.alert(errorMessage(err), "")
+			} else {
+				ca.privateMessageIndex[sent.GetId()] = index
+			}
+
 			ca.inputArea.SetText("", true)
 		}
 	})
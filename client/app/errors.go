@@ -0,0 +1,83 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// user-facing messages of the error reasons sent by the server
+var reasonMessages = map[string]string{
+	gs.ReasonWrongCredentials:       "Wrong username or password. Please try again.",
+	gs.ReasonUserNotFound:           "This user does not exist.",
+	gs.ReasonUsernameTaken:          "This username is already taken. Please choose another one.",
+	gs.ReasonAccountDisabled:        "Your account has been disabled by an admin.",
+	gs.ReasonNotLoggedIn:            "You are not logged in from this client. Please login again.",
+	gs.ReasonSessionEnded:           "Your session has ended. Please login again.",
+	gs.ReasonSessionNotFound:        "This session does not exist anymore.",
+	gs.ReasonUserOffline:            "This user is not logged in.",
+	gs.ReasonRecipientOffline:       "The recipient is offline. The message was not delivered.",
+	gs.ReasonPrivateMessagesRefused: "This user does not accept private messages from you.",
+	gs.ReasonPermissionDenied:       "You are not permitted to do this.",
+	gs.ReasonSelfAction:             "You cannot do this to your own account.",
+	gs.ReasonDuplicateLike:          "You already liked this message.",
+	gs.ReasonMessageNotFound:        "This message does not exist anymore.",
+	gs.ReasonStoreFailure:           "The server could not save the changes.",
+	gs.ReasonEmailNotVerified:       "Please verify your email with the code sent to it before logging in.",
+	gs.ReasonInvalidCode:            "The code is wrong or has expired. Request a new one.",
+	gs.ReasonMailFailure:            "The server could not send the email.",
+	gs.ReasonTooManyAttempts:        "Too many failed logins.",
+	gs.ReasonRateLimited:            "Too many requests.",
+	gs.ReasonMessageTooLong:         "This message is too long.",
+	gs.ReasonSpam:                   "Please stop repeating the same message.",
+	gs.ReasonTOTPRequired:           "Enter the code of your authenticator app.",
+	gs.ReasonTOTPAlreadyEnabled:     "Two-factor authentication is already enabled.",
+	gs.ReasonTOTPNotEnabled:         "Two-factor authentication is not enabled.",
+}
+
+// reason of the errdetails.ErrorInfo carried by an error, empty if there is none
+func errorReason(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return ""
+	}
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == gs.ErrorDomain {
+			return info.GetReason()
+		}
+	}
+
+	return ""
+}
+
+// a user-facing message for an error returned by the server
+func errorMessage(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err.Error()
+	}
+
+	msg, ok := reasonMessages[errorReason(err)]
+	if !ok {
+		switch st.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded:
+			msg = "Cannot reach the server. Please try again later."
+		default:
+			msg = st.Message()
+		}
+	}
+
+	for _, detail := range st.Details() {
+		if retry, ok := detail.(*errdetails.RetryInfo); ok {
+			delay := max(retry.GetRetryDelay().AsDuration().Round(time.Second), time.Second)
+			msg += fmt.Sprintf(" Try again in %s.", delay)
+		}
+	}
+
+	return msg
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestErrorMessage(t *testing.T) {
+	info := func(reason string) *errdetails.ErrorInfo {
+		return &errdetails.ErrorInfo{Reason: reason, Domain: gs.ErrorDomain}
+	}
+
+	wrongPassword, _ := status.New(codes.Unauthenticated, "Failed to login").WithDetails(info(gs.ReasonWrongCredentials))
+	lockedOut, _ := status.New(codes.ResourceExhausted, "Too many failed attempts").WithDetails(
+		info(gs.ReasonTooManyAttempts), &errdetails.RetryInfo{RetryDelay: durationpb.New(90*time.Second + 400*time.Millisecond)})
+	otherDomain, _ := status.New(codes.Unauthenticated, "Server text").WithDetails(
+		&errdetails.ErrorInfo{Reason: gs.ReasonWrongCredentials, Domain: "example.com"})
+
+	for _, test := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"known reason", wrongPassword.Err(), "Wrong username or password. Please try again."},
+		{"retry delay", lockedOut.Err(), "Too many failed logins. Try again in 1m30s."},
+		{"unknown domain", otherDomain.Err(), "Server text"},
+		{"no reason", status.Error(codes.Internal, "Something broke"), "Something broke"},
+		{"unreachable", status.Error(codes.Unavailable, "connection refused"), "Cannot reach the server. Please try again later."},
+		{"not a status", errors.New("plain"), "plain"},
+	} {
+		if got := errorMessage(test.err); got != test.want {
+			t.Errorf("%s: %q, want %q", test.name, got, test.want)
+		}
+	}
+}
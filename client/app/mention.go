@@ -0,0 +1,137 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"github.com/rivo/tview"
+)
+
+// the @names of a message, like the server finds them
+var mentionTag = regexp.MustCompile(`(^|[^A-Za-z0-9_.-])(@[A-Za-z0-9_.-]+)`)
+
+// highlight the mentions of the user, @here and @all in a room message
+func (ca *ClientApp) highlightMentions(message string) string {
+	return mentionTag.ReplaceAllStringFunc(message, func(match string) string {
+		at := strings.Index(match, "@")
+		name := strings.TrimRight(match[at+1:], ".-")
+		if name != *ca.username && !strings.EqualFold(name, "here") && !strings.EqualFold(name, "all") {
+			return match
+		}
+
+		return match[:at] + "[red::b]" + match[at:] + "[-::-]"
+	})
+}
+
+// a room message mentioned the user, shown on the Mentions button of every page
+func (ca *ClientApp) notifyMention() {
+	if ca.Bell {
+		// rung by the screen on the next draw, tcell owns the terminal
+		ca.app.QueueUpdateDraw(func() {
+			ca.bellPending = true
+		})
+	}
+
+	ca.setUnreadMentions(ca.unreadMentions + 1)
+}
+
+// ring the bell asked for by notifyMention, used as the before draw function of the app
+func (ca *ClientApp) ringBell(screen tcell.Screen) bool {
+	if ca.bellPending {
+		ca.bellPending = false
+		screen.Beep()
+	}
+
+	return false
+}
+
+// fetch how many mentions are unread, e.g. the ones sent while offline
+func (ca *ClientApp) loadUnreadMentions() {
+	mentions, err := ca.stub.ListMentions(ca.authContext(), &gs.UserRequest{Sender: *ca.username})
+	if err != nil {
+		return
+	}
+
+	ca.setUnreadMentions(len(mentions.GetMessages()))
+}
+
+func (ca *ClientApp) setUnreadMentions(count int) {
+	ca.unreadMentions = count
+	for _, button := range ca.mentionButtons {
+		button.SetLabel(ca.mentionButtonLabel())
+	}
+}
+
+func (ca *ClientApp) mentionButtonLabel() string {
+	if ca.unreadMentions == 0 {
+		return "Mentions"
+	}
+
+	return fmt.Sprintf("[red::b]Mentions (%d)", ca.unreadMentions)
+}
+
+// the unread mentions, opening one marks it as read
+func (ca *ClientApp) createMentionsPage() *tview.Flex {
+	mentionList := tview.NewList()
+	mentionList.SetBorder(true).SetTitle("Unread mentions").SetTitleAlign(tview.AlignRight)
+
+	var fill func(mentions *gs.MentionList)
+	fill = func(mentions *gs.MentionList) {
+		ca.setUnreadMentions(len(mentions.GetMessages()))
+
+		mentionList.Clear()
+		if len(mentions.GetMessages()) == 0 {
+			mentionList.AddItem("No unread mention", "", 0, nil)
+		}
+
+		for _, msg := range mentions.GetMessages() {
+			msg := msg
+			mentionList.AddItem(ca.searchResultTitle(msg), msg.GetMessage(), 0, func() {
+				id := msg.GetId()
+				if left, err := ca.stub.MarkMentionsRead(ca.authContext(), &gs.UserRequest{
+					Sender: *ca.username,
+					Target: &id,
+				}); err == nil {
+					fill(left)
+				}
+
+				ca.jumpToMessage(msg)
+			})
+		}
+	}
+
+	mentions, err := ca.stub.ListMentions(ca.authContext(), &gs.UserRequest{Sender: *ca.username})
+	if err != nil {
+		ca.alert("Failed to get the mentions: "+errorMessage(err), "")
+		mentions = &gs.MentionList{}
+	}
+	fill(mentions)
+
+	form := tview.NewForm()
+	form.AddButton("Mark all as read", func() {
+		left, err := ca.stub.MarkMentionsRead(ca.authContext(), &gs.UserRequest{Sender: *ca.username})
+		if err != nil {
+			ca.alert("Failed to mark the mentions as read: "+errorMessage(err), "")
+			return
+		}
+		fill(left)
+	}).
+		AddButton("Move to Chat Room", func() {
+			ca.navigateToPublicChatRoom()
+		})
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow)
+	flex.AddItem(mentionList, 0, 5, true)
+	flex.AddItem(form, 3, 0, false)
+
+	return flex
+}
+
+// Mentions page navigation, the mentions are fetched again every time
+func (ca *ClientApp) navigateToMentions() {
+	ca.navigator.RemovePage("Mentions")
+	ca.navigator.AddAndSwitchToPage("Mentions", ca.createMentionsPage(), true)
+}
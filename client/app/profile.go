@@ -0,0 +1,98 @@
+package app
+
+import (
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"github.com/rivo/tview"
+)
+
+// an edit profile form, same layout as the registration form
+func (ca *ClientApp) createEditProfileForm(profile *gs.PublicUserInfo) *tview.Form {
+
+	form := tview.NewForm()
+
+	ca.addProfileFields(form, profile).
+		AddPasswordField("Current password", "", 30, '*', nil).
+		AddPasswordField("New password", "", 30, '*', nil).
+		AddButton("Save profile", func() {
+			ca.clearFieldErrors(form)
+
+			user := &gs.User{
+				Username: *ca.username,
+			}
+			ca.readProfileFields(form, user)
+
+			_, err := ca.stub.UpdateProfile(ca.authContext(), user)
+			if err != nil {
+				ca.alertFormError(form, err, "Edit Profile")
+			} else {
+				ca.alert("Profile updated successfully!", "")
+				ca.navigateToPublicChatRoom()
+			}
+		}).
+		AddButton("Change password", func() {
+			ca.clearFieldErrors(form)
+
+			oldPasswordField, _ := form.GetFormItemByLabel("Current password").(*tview.InputField)
+			newPasswordField, _ := form.GetFormItemByLabel("New password").(*tview.InputField)
+
+			_, err := ca.stub.ChangePassword(ca.authContext(), &gs.PasswordChangeRequest{
+				Sender:      *ca.username,
+				OldPassword: oldPasswordField.GetText(),
+				NewPassword: newPasswordField.GetText(),
+			})
+
+			oldPasswordField.SetText("")
+			newPasswordField.SetText("")
+
+			if err != nil {
+				ca.alertFormError(form, err, "Edit Profile")
+			} else {
+				ca.alert("Password changed successfully!", "Edit Profile")
+			}
+		}).
+		AddButton("Delete account", func() {
+			passwordField, _ := form.GetFormItemByLabel("Current password").(*tview.InputField)
+
+			// the server closes the chat stream when the account is deleted
+			stream := ca.chatStream
+			ca.chatStream = nil
+
+			_, err := ca.stub.DeleteAccount(ca.authContext(), &gs.UserLoginCredentials{
+				Username: *ca.username,
+				Password: passwordField.GetText(),
+			})
+
+			passwordField.SetText("")
+
+			if err != nil {
+				ca.chatStream = stream
+				ca.alert("Failed to delete account: "+errorMessage(err), "Edit Profile")
+			} else {
+				ca.restart()
+			}
+		}).
+		AddButton("Move to Chat Room", func() {
+			ca.navigateToPublicChatRoom()
+		})
+
+	form.SetBorder(true).SetTitle("Edit Profile").SetTitleAlign(tview.AlignLeft)
+
+	return form
+}
+
+// Edit profile page navigation, always reloads the profile from the server
+func (ca *ClientApp) navigateToEditProfile() {
+	profile, err := ca.stub.GetPeerInfomations(ca.authContext(), &gs.UserRequest{
+		Sender: *ca.username,
+		Target: ca.username,
+	})
+
+	if err != nil {
+		ca.alert("Failed to get user information: "+errorMessage(err), "")
+		return
+	}
+
+	ca.navigator.RemovePage("Edit Profile")
+	flex := ca.createCenterFlexForm(ca.createEditProfileForm(profile), true)
+	ca.navigator.AddAndSwitchToPage("Edit Profile", flex, true)
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"github.com/rivo/tview"
+)
+
+// a form to enter the email verification code sent after registration
+func (ca *ClientApp) createVerifyEmailForm(username string) *tview.Form {
+	form := tview.NewForm()
+
+	form.AddInputField("Username", username, 30, nil, nil).
+		AddInputField("Code", "", 30, nil, nil).
+		AddButton("Verify", func() {
+			usernameField, _ := form.GetFormItemByLabel("Username").(*tview.InputField)
+			codeField, _ := form.GetFormItemByLabel("Code").(*tview.InputField)
+
+			_, err := ca.stub.VerifyEmail(context.Background(), &gs.VerificationCode{
+				Username: usernameField.GetText(),
+				Code:     codeField.GetText(),
+			})
+
+			codeField.SetText("")
+
+			if err != nil {
+				ca.alert(errorMessage(err), "Verify Email")
+			} else {
+				ca.navigateToLogin()
+				ca.alert("Email verified! You can login now.", "")
+			}
+		}).
+		AddButton("Send a new code", func() {
+			usernameField, _ := form.GetFormItemByLabel("Username").(*tview.InputField)
+
+			result, err := ca.stub.ResendVerificationCode(context.Background(), &gs.UserRequest{
+				Sender: usernameField.GetText(),
+			})
+
+			if err != nil {
+				ca.alert(errorMessage(err), "Verify Email")
+			} else {
+				ca.alert(result.GetMessage(), "Verify Email")
+			}
+		}).
+		AddButton("Move to Login", func() {
+			ca.navigateToLogin()
+		})
+
+	form.SetBorder(true).SetTitle("Verify Email").SetTitleAlign(tview.AlignLeft)
+
+	return form
+}
+
+// a form to request a password reset code and set a new password with it
+func (ca *ClientApp) createPasswordResetForm() *tview.Form {
+	form := tview.NewForm()
+
+	form.AddInputField("Username", "", 30, nil, nil).
+		AddInputField("Code", "", 30, nil, nil).
+		AddPasswordField("New password", "", 30, '*', nil).
+		AddButton("Send code", func() {
+			usernameField, _ := form.GetFormItemByLabel("Username").(*tview.InputField)
+
+			result, err := ca.stub.RequestPasswordReset(context.Background(), &gs.UserRequest{
+				Sender: usernameField.GetText(),
+			})
+
+			if err != nil {
+				ca.alert(errorMessage(err), "Reset Password")
+			} else {
+				ca.alert(result.GetMessage(), "Reset Password")
+			}
+		}).
+		AddButton("Reset password", func() {
+			ca.clearFieldErrors(form)
+
+			usernameField, _ := form.GetFormItemByLabel("Username").(*tview.InputField)
+			codeField, _ := form.GetFormItemByLabel("Code").(*tview.InputField)
+			passwordField, _ := form.GetFormItemByLabel("New password").(*tview.InputField)
+
+			_, err := ca.stub.ConfirmPasswordReset(context.Background(), &gs.PasswordResetConfirmation{
+				Username:    usernameField.GetText(),
+				Code:        codeField.GetText(),
+				NewPassword: passwordField.GetText(),
+			})
+
+			passwordField.SetText("")
+
+			if err != nil {
+				ca.alertFormError(form, err, "Reset Password")
+			} else {
+				codeField.SetText("")
+				ca.navigateToLogin()
+				ca.alert("Password reset! You can login with the new password now.", "")
+			}
+		}).
+		AddButton("Move to Login", func() {
+			ca.navigateToLogin()
+		})
+
+	form.SetBorder(true).SetTitle("Reset Password").SetTitleAlign(tview.AlignLeft)
+
+	return form
+}
+
+// Verify email page navigation, the username is filled in
+func (ca *ClientApp) navigateToVerifyEmail(username string) {
+	ca.navigator.RemovePage("Verify Email")
+	flex := ca.createCenterFlexForm(ca.createVerifyEmailForm(username), false)
+	ca.navigator.AddAndSwitchToPage("Verify Email", flex, true)
+}
+
+// Reset password page navigation
+func (ca *ClientApp) navigateToPasswordReset() {
+	if ca.navigator.HasPage("Reset Password") {
+		ca.navigator.SwitchToPage("Reset Password")
+	} else {
+		flex := ca.createCenterFlexForm(ca.createPasswordResetForm(), false)
+		ca.navigator.AddAndSwitchToPage("Reset Password", flex, true)
+	}
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"github.com/rivo/tview"
+)
+
+// layout of the dates of the search form
+const searchDateLayout = "2006-01-02"
+
+// a search form with the results of the last search below it
+func (ca *ClientApp) createSearchPage() *tview.Flex {
+	results := tview.NewList()
+	results.SetBorder(true).SetTitle("Results").SetTitleAlign(tview.AlignRight)
+
+	form := tview.NewForm()
+	form.AddInputField("Words or \"phrase\"", "", 30, nil, nil).
+		AddInputField("From", "", 30, nil, nil).
+		AddInputField("Private chat with", "", 30, nil, nil).
+		AddCheckbox("Room only", false, nil).
+		AddInputField("Since (YYYY-MM-DD)", "", 30, nil, nil).
+		AddInputField("Until (YYYY-MM-DD)", "", 30, nil, nil).
+		AddButton("Search", func() {
+			// Retrieve values from the form fields
+			text := func(label string) string {
+				field, _ := form.GetFormItemByLabel(label).(*tview.InputField)
+				return strings.TrimSpace(field.GetText())
+			}
+			roomOnly, _ := form.GetFormItemByLabel("Room only").(*tview.Checkbox)
+
+			request := &gs.SearchRequest{
+				Sender:   *ca.username,
+				Query:    text("Words or \"phrase\""),
+				RoomOnly: roomOnly.IsChecked(),
+			}
+			if from := text("From"); from != "" {
+				request.From = &from
+			}
+			if peer := text("Private chat with"); peer != "" {
+				request.Peer = &peer
+			}
+
+			// whole days in local time
+			for _, date := range []struct {
+				label string
+				value *int64
+				end   bool
+			}{
+				{"Since (YYYY-MM-DD)", &request.Since, false},
+				{"Until (YYYY-MM-DD)", &request.Until, true},
+			} {
+				if text(date.label) == "" {
+					continue
+				}
+
+				day, err := time.ParseInLocation(searchDateLayout, text(date.label), time.Local)
+				if err != nil {
+					ca.alert("Dates must look like 2024-01-31", "")
+					return
+				}
+				if date.end {
+					day = day.Add(24*time.Hour - time.Second)
+				}
+				*date.value = day.Unix()
+			}
+
+			found, err := ca.stub.SearchMessages(ca.authContext(), request)
+			if err != nil {
+				ca.alert("Failed to search: "+errorMessage(err), "")
+				return
+			}
+
+			results.Clear()
+			if len(found.GetMessages()) == 0 {
+				results.AddItem("No message found", "", 0, nil)
+			}
+
+			for _, msg := range found.GetMessages() {
+				msg := msg
+				results.AddItem(ca.searchResultTitle(msg), msg.GetMessage(), 0, func() {
+					ca.jumpToMessage(msg)
+				})
+			}
+			ca.app.SetFocus(results)
+		}).
+		AddButton("Move to Chat Room", func() {
+			ca.navigateToPublicChatRoom()
+		})
+
+	form.SetBorder(true).SetTitle("Search messages").SetTitleAlign(tview.AlignLeft)
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow)
+	flex.AddItem(form, 0, 2, true)
+	flex.AddItem(results, 0, 3, false)
+
+	return flex
+}
+
+// who sent a search result, to whom and when
+func (ca *ClientApp) searchResultTitle(msg *gs.ChatMessage) string {
+	sender := msg.GetSender()
+	if sender == *ca.username {
+		sender = "You"
+	}
+
+	where := "room"
+	if msg.GetPrivate() > 0 {
+		where = "private to " + msg.GetRecipent()
+		if msg.GetRecipent() == *ca.username {
+			where = "private to you"
+		}
+	}
+
+	return fmt.Sprintf("%s (%s) %s", sender, where, time.Unix(msg.GetTimestamp(), 0).Format("2006-01-02 15:04"))
+}
+
+// show a search result where it is in the chat, messages from before this login are only shown in an alert
+func (ca *ClientApp) jumpToMessage(msg *gs.ChatMessage) {
+	if msg.GetPrivate() > 0 {
+		peer := msg.GetSender()
+		if peer == *ca.username {
+			peer = msg.GetRecipent()
+		}
+
+		if index, ok := ca.privateMessageIndex[msg.GetId()]; ok {
+			ca.navigateToPrivateChatRoom(peer)
+			ca.privateMessageList[peer].SetCurrentItem(index)
+			ca.app.SetFocus(ca.privateMessageList[peer])
+			return
+		}
+	} else if index, ok := ca.publicMessageIndex[msg.GetId()]; ok && index < ca.publicMessageList.GetItemCount() {
+		ca.navigateToPublicChatRoom()
+		ca.publicMessageList.SetCurrentItem(index)
+		ca.app.SetFocus(ca.publicMessageList)
+		return
+	}
+
+	ca.alert(fmt.Sprintf("%s\n\n%s", ca.searchResultTitle(msg), msg.GetMessage()), "")
+}
+
+// Search page navigation, the last search is kept
+func (ca *ClientApp) navigateToSearch() {
+	if ca.navigator.HasPage("Search") {
+		ca.navigator.SwitchToPage("Search")
+	} else {
+		ca.navigator.AddAndSwitchToPage("Search", ca.createSearchPage(), true)
+	}
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"strings"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"github.com/rivo/tview"
+)
+
+// audience options in the order of gs.Audience values
+var audienceOptions = []string{"Everyone", "Moderators & admins", "Nobody"}
+
+// a settings form for the block list and privacy settings
+func (ca *ClientApp) createSettingsForm(settings *gs.UserSettings) *tview.Form {
+	form := tview.NewForm()
+	privacy := settings.GetPrivacy()
+
+	form.AddDropDown("Private messages from", audienceOptions, int(privacy.GetPrivateMessages()), nil).
+		AddDropDown("Email visible to", audienceOptions, int(privacy.GetEmail()), nil).
+		AddDropDown("Birthdate visible to", audienceOptions, int(privacy.GetBirthdate()), nil).
+		AddDropDown("Address visible to", audienceOptions, int(privacy.GetAddress()), nil).
+		AddInputField("Blocked users", strings.Join(settings.GetBlocked(), ", "), 30, nil, nil).
+		AddButton("Save", func() {
+			// Retrieve values from the form fields
+			selected := func(label string) gs.Audience {
+				dropDown, _ := form.GetFormItemByLabel(label).(*tview.DropDown)
+				index, _ := dropDown.GetCurrentOption()
+				return gs.Audience(index)
+			}
+			blockedField, _ := form.GetFormItemByLabel("Blocked users").(*tview.InputField)
+
+			blocked := []string{}
+			for _, username := range strings.Split(blockedField.GetText(), ",") {
+				if username = strings.TrimSpace(username); username != "" {
+					blocked = append(blocked, username)
+				}
+			}
+
+			_, err := ca.stub.UpdateSettings(ca.authContext(), &gs.UserSettingsRequest{
+				Sender: *ca.username,
+				Settings: &gs.UserSettings{
+					Blocked: blocked,
+					Privacy: &gs.PrivacySettings{
+						PrivateMessages: selected("Private messages from"),
+						Email:           selected("Email visible to"),
+						Birthdate:       selected("Birthdate visible to"),
+						Address:         selected("Address visible to"),
+					},
+				},
+			})
+
+			if err != nil {
+				ca.alert("Failed to save settings: "+errorMessage(err), "Settings")
+			} else {
+				ca.alert("Settings saved!", "")
+				ca.navigateToPublicChatRoom()
+			}
+		}).
+		AddButton("Move to Chat Room", func() {
+			ca.navigateToPublicChatRoom()
+		})
+
+	// two-factor authentication is changed on its own, not with Save
+	if settings.GetTotpEnabled() {
+		form.AddInputField("2FA code", "", 30, nil, nil).
+			AddButton("Disable 2FA", func() {
+				codeField, _ := form.GetFormItemByLabel("2FA code").(*tview.InputField)
+
+				_, err := ca.stub.DisableTOTP(ca.authContext(), &gs.TOTPCode{
+					Sender: *ca.username,
+					Code:   strings.TrimSpace(codeField.GetText()),
+				})
+
+				codeField.SetText("")
+
+				if err != nil {
+					ca.alert("Failed to disable two-factor authentication: "+errorMessage(err), "Settings")
+				} else {
+					ca.navigateToSettings()
+					ca.alert("Two-factor authentication disabled!", "")
+				}
+			})
+	} else {
+		form.AddButton("Set up 2FA", func() {
+			ca.navigateToTwoFactorSetup()
+		})
+	}
+
+	form.SetBorder(true).SetTitle("Settings").SetTitleAlign(tview.AlignLeft)
+
+	return form
+}
+
+// Settings page navigation, always reloads the settings from the server
+func (ca *ClientApp) navigateToSettings() {
+	settings, err := ca.stub.GetSettings(ca.authContext(), &gs.UserRequest{
+		Sender: *ca.username,
+	})
+
+	if err != nil {
+		ca.alert("Failed to get settings: "+errorMessage(err), "")
+		return
+	}
+
+	ca.navigator.RemovePage("Settings")
+	flex := ca.createCenterFlexForm(ca.createSettingsForm(settings), true)
+	ca.navigator.AddAndSwitchToPage("Settings", flex, true)
+}
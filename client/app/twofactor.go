@@ -0,0 +1,95 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"github.com/rivo/tview"
+)
+
+// second login step, asking for a TOTP or recovery code
+func (ca *ClientApp) createTwoFactorLoginForm(password string, kickOtherSession bool) *tview.Form {
+	form := tview.NewForm()
+
+	form.AddInputField("Code", "", 30, nil, nil).
+		AddButton("Verify", func() {
+			codeField, _ := form.GetFormItemByLabel("Code").(*tview.InputField)
+			code := strings.TrimSpace(codeField.GetText())
+			codeField.SetText("")
+
+			if ca.requestLogin(password, kickOtherSession, code) {
+				ca.enterChatRoom()
+			}
+		}).
+		AddButton("Move to Login", func() {
+			ca.navigateToLogin()
+		})
+
+	form.SetBorder(true).
+		SetTitle("Enter the code of your authenticator app or a recovery code").
+		SetTitleAlign(tview.AlignLeft)
+
+	return form
+}
+
+// the new secret and recovery codes, with a form to confirm a code of the authenticator
+func (ca *ClientApp) createTwoFactorSetup(enrollment *gs.TOTPEnrollment) *tview.Flex {
+	instructions := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWordWrap(true).
+		SetText(fmt.Sprintf(
+			"Add this account to your authenticator app with the URI or the secret:\n\n%s\n\nSecret: [yellow]%s[-]\n\n"+
+				"Save these recovery codes, each can be used once if you lose the authenticator. They are not shown again:\n\n[yellow]%s[-]",
+			enrollment.GetProvisioningUri(), enrollment.GetSecret(), strings.Join(enrollment.GetRecoveryCodes(), "\n")))
+	instructions.SetBorder(true).SetTitle("Two-factor authentication setup").SetTitleAlign(tview.AlignLeft)
+
+	form := tview.NewForm()
+	form.AddInputField("Code", "", 30, nil, nil).
+		AddButton("Enable", func() {
+			codeField, _ := form.GetFormItemByLabel("Code").(*tview.InputField)
+
+			_, err := ca.stub.ConfirmTOTP(ca.authContext(), &gs.TOTPCode{
+				Sender: *ca.username,
+				Code:   strings.TrimSpace(codeField.GetText()),
+			})
+
+			codeField.SetText("")
+
+			if err != nil {
+				ca.alert(errorMessage(err), "Two-Factor Setup")
+			} else {
+				ca.navigateToSettings()
+				ca.alert("Two-factor authentication enabled!", "")
+			}
+		}).
+		AddButton("Cancel", func() {
+			ca.navigateToSettings()
+		})
+
+	return tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(instructions, 0, 3, false).
+		AddItem(form, 7, 1, true)
+}
+
+// Two-factor login page navigation, the password of the first step is kept for the second
+func (ca *ClientApp) navigateToTwoFactorLogin(password string, kickOtherSession bool) {
+	ca.navigator.RemovePage("Two-Factor Login")
+	flex := ca.createCenterFlexForm(ca.createTwoFactorLoginForm(password, kickOtherSession), false)
+	ca.navigator.AddAndSwitchToPage("Two-Factor Login", flex, true)
+}
+
+// Two-factor setup page navigation, a new secret is enrolled every time
+func (ca *ClientApp) navigateToTwoFactorSetup() {
+	enrollment, err := ca.stub.EnrollTOTP(ca.authContext(), &gs.UserRequest{
+		Sender: *ca.username,
+	})
+
+	if err != nil {
+		ca.alert("Failed to set up two-factor authentication: "+errorMessage(err), "Settings")
+		return
+	}
+
+	ca.navigator.RemovePage("Two-Factor Setup")
+	ca.navigator.AddAndSwitchToPage("Two-Factor Setup", ca.createTwoFactorSetup(enrollment), true)
+}
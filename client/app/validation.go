@@ -0,0 +1,75 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// form labels of the request fields reported by the server validation
+var fieldLabels = map[string]string{
+	"username":        "Username",
+	"password":        "Password",
+	"full_name":       "Full Name",
+	"email":           "Email (optional)",
+	"birthdate":       "Birthdate (optional)",
+	"address.country": "Country",
+	"new_password":    "New password",
+	"code":            "Code",
+}
+
+// label color tag marking an invalid field
+const invalidFieldTag = "[red]"
+
+// remove the invalid field marks of a form, so fields can be found by their label again
+func (ca *ClientApp) clearFieldErrors(form *tview.Form) {
+	for index := 0; index < form.GetFormItemCount(); index++ {
+		if field, ok := form.GetFormItem(index).(*tview.InputField); ok {
+			field.SetLabel(strings.TrimPrefix(field.GetLabel(), invalidFieldTag))
+		}
+	}
+}
+
+// mark the fields rejected by the server validation, returns the violation descriptions
+func (ca *ClientApp) highlightFieldErrors(form *tview.Form, err error) []string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+
+	var descriptions []string
+	for _, detail := range st.Details() {
+		badRequest, ok := detail.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+
+		for _, violation := range badRequest.GetFieldViolations() {
+			descriptions = append(descriptions, violation.GetDescription())
+
+			label, ok := fieldLabels[violation.GetField()]
+			if !ok {
+				continue
+			}
+
+			if field, ok := form.GetFormItemByLabel(label).(*tview.InputField); ok {
+				field.SetLabel(invalidFieldTag + label)
+			}
+		}
+	}
+
+	return descriptions
+}
+
+// alert the server validation errors of a form submission and mark the invalid fields
+func (ca *ClientApp) alertFormError(form *tview.Form, err error, parentPage string) {
+	descriptions := ca.highlightFieldErrors(form, err)
+	if len(descriptions) == 0 {
+		ca.alert(errorMessage(err), parentPage)
+		return
+	}
+
+	ca.alert(strings.Join(descriptions, "\n"), parentPage)
+}
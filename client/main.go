@@ -10,12 +10,20 @@ var (
 	port int = 55555
 	ipaddr = "localhost"
 	refreshInterval = time.Millisecond * 100
+	useTLS = false
+	caFile = ""
+	socket = ""
+	bell = false
 )
 
 func main() {
 	flag.StringVar(&ipaddr, "ipaddr", ipaddr, "server ip address")
 	flag.IntVar(&port, "port", port, "connection port")
 	flag.DurationVar(&refreshInterval, "interval", refreshInterval, "app refresh interval")
+	flag.StringVar(&socket, "socket", socket, "unix socket path of the server, used instead of -ipaddr and -port")
+	flag.BoolVar(&useTLS, "tls", useTLS, "connect with TLS, trusting the system certificates")
+	flag.StringVar(&caFile, "caFile", caFile, "PEM certificate of the CA to trust, implies -tls")
+	flag.BoolVar(&bell, "bell", bell, "ring the terminal bell when a room message mentions you")
 
 	flag.Parse()
 
@@ -23,6 +31,10 @@ func main() {
 		RefreshInterval: refreshInterval,
 		Port: port,
 		Ipaddr: ipaddr,
+		TLS: useTLS,
+		CAFile: caFile,
+		Socket: socket,
+		Bell: bell,
 	}
 	client.Start()
 	defer client.Exit()
@@ -1,5 +1,9 @@
 #!/usr/bin/sh
 
-protoc --go_out=. --go_opt=paths=source_relative \
+# third_party/googleapis holds the google/api HTTP annotations used by the REST gateway
+protoc -I . -I third_party/googleapis \
+    --go_out=. --go_opt=paths=source_relative \
     --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+    --grpc-gateway_out=. --grpc-gateway_opt=paths=source_relative \
+    --openapiv2_out=. \
     grpcService/services.proto
@@ -0,0 +1,39 @@
+package grpcService
+
+// domain of the errdetails.ErrorInfo attached to chat room errors
+const ErrorDomain = "grpc-chatroom"
+
+// reasons of the errdetails.ErrorInfo attached to chat room errors,
+// clients use them to pick a message instead of parsing the error text
+const (
+	ReasonInvalidArgument        = "INVALID_ARGUMENT"
+	ReasonWrongCredentials       = "WRONG_CREDENTIALS"
+	ReasonUserNotFound           = "USER_NOT_FOUND"
+	ReasonUsernameTaken          = "USERNAME_TAKEN"
+	ReasonAccountDisabled        = "ACCOUNT_DISABLED"
+	ReasonNotLoggedIn            = "NOT_LOGGED_IN"
+	ReasonSessionEnded           = "SESSION_ENDED"
+	ReasonSessionNotFound        = "SESSION_NOT_FOUND"
+	ReasonUserOffline            = "USER_OFFLINE"
+	ReasonRecipientOffline       = "RECIPIENT_OFFLINE"
+	ReasonPrivateMessagesRefused = "PRIVATE_MESSAGES_REFUSED"
+	ReasonPermissionDenied       = "PERMISSION_DENIED"
+	ReasonSelfAction             = "SELF_ACTION"
+	ReasonDuplicateLike          = "DUPLICATE_LIKE"
+	ReasonMessageNotFound        = "MESSAGE_NOT_FOUND"
+	ReasonStoreFailure           = "STORE_FAILURE"
+	ReasonEmailNotVerified       = "EMAIL_NOT_VERIFIED"
+	ReasonInvalidCode            = "INVALID_CODE"
+	ReasonMailFailure            = "MAIL_FAILURE"
+	ReasonTooManyAttempts        = "TOO_MANY_ATTEMPTS"
+	ReasonRateLimited            = "RATE_LIMITED"
+	ReasonMessageTooLong         = "MESSAGE_TOO_LONG"
+	ReasonSpam                   = "SPAM"
+	ReasonTOTPRequired           = "TOTP_REQUIRED"
+	ReasonTOTPAlreadyEnabled     = "TOTP_ALREADY_ENABLED"
+	ReasonTOTPNotEnabled         = "TOTP_NOT_ENABLED"
+	ReasonWebhookTokenNotFound   = "WEBHOOK_TOKEN_NOT_FOUND"
+	ReasonScheduledNotFound      = "SCHEDULED_MESSAGE_NOT_FOUND"
+	ReasonTooManyScheduled       = "TOO_MANY_SCHEDULED"
+	ReasonMentionNotFound        = "MENTION_NOT_FOUND"
+)
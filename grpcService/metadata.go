@@ -0,0 +1,4 @@
+package grpcService
+
+// metadata key carrying the session token returned by Login
+const SessionTokenKey = "session-token"
@@ -0,0 +1,8 @@
+package grpcService
+
+import _ "embed"
+
+// OpenAPI description of the REST endpoints, generated from the HTTP annotations of services.proto
+//
+//go:embed services.swagger.json
+var OpenAPISpec []byte
@@ -32,6 +32,58 @@ type ChatRoomClient interface {
 	LikeMessage(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*SentMessageStatus, error)
 	// login using a pair of username and password
 	Login(ctx context.Context, in *UserLoginCredentials, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// end the session of the calling client
+	Logout(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// list every logged in session of the calling user
+	ListSessions(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*SessionList, error)
+	// end one of the sessions of the calling user, target is the session id
+	RevokeSession(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// mute a user in the chat room (moderators only)
+	MuteUser(ctx context.Context, in *MuteRequest, opts ...grpc.CallOption) (*SentMessageStatus, error)
+	// delete a room message by its id, own messages or any message for moderators
+	DeleteMessage(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*SentMessageStatus, error)
+	// make the chat room read-only for non moderators (moderators only)
+	LockRoom(ctx context.Context, in *LockRoomRequest, opts ...grpc.CallOption) (*SentMessageStatus, error)
+	// get the block list and privacy settings of the calling user
+	GetSettings(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*UserSettings, error)
+	// replace the block list and privacy settings of the calling user
+	UpdateSettings(ctx context.Context, in *UserSettingsRequest, opts ...grpc.CallOption) (*UserSettings, error)
+	// hide public messages of target and reject their private messages
+	BlockUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*UserSettings, error)
+	// remove target from the block list
+	UnblockUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*UserSettings, error)
+	// update full name, email, birthdate and address of the calling user
+	UpdateProfile(ctx context.Context, in *User, opts ...grpc.CallOption) (*PublicUserInfo, error)
+	// change the password of the calling user, ending their other sessions
+	ChangePassword(ctx context.Context, in *PasswordChangeRequest, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// delete the account of the calling user, the password is required
+	DeleteAccount(ctx context.Context, in *UserLoginCredentials, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// start two-factor authentication setup for the calling user
+	EnrollTOTP(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*TOTPEnrollment, error)
+	// enable two-factor authentication with a code of the enrolled secret
+	ConfirmTOTP(ctx context.Context, in *TOTPCode, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// disable two-factor authentication, a TOTP or recovery code is required
+	DisableTOTP(ctx context.Context, in *TOTPCode, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// confirm the email address of a user with the code sent to it
+	VerifyEmail(ctx context.Context, in *VerificationCode, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// send a new email verification code, sender is the username
+	ResendVerificationCode(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// email a password reset code, sender is the username
+	RequestPasswordReset(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// set a new password with the code sent by RequestPasswordReset
+	ConfirmPasswordReset(ctx context.Context, in *PasswordResetConfirmation, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// deliver a message to the room, or privately to recipent, at a later time
+	ScheduleMessage(ctx context.Context, in *ScheduleRequest, opts ...grpc.CallOption) (*ScheduledMessage, error)
+	// list the scheduled messages of the calling user waiting for delivery
+	ListScheduled(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*ScheduledMessageList, error)
+	// cancel a scheduled message of the calling user, target is its id
+	CancelScheduled(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*ScheduledMessage, error)
+	// search the room messages and the private messages of the calling user
+	SearchMessages(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResults, error)
+	// list the unread room messages mentioning the calling user
+	ListMentions(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*MentionList, error)
+	// mark a mention as read, target is the id of its message, or every mention when not set
+	MarkMentionsRead(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*MentionList, error)
 	// Get a list of information of connected peers or specific peers
 	GetConnectedPeers(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*PublicUserInfoList, error)
 	// Get a peer information (except password)
@@ -113,6 +165,240 @@ func (c *chatRoomClient) Login(ctx context.Context, in *UserLoginCredentials, op
 	return out, nil
 }
 
+func (c *chatRoomClient) Logout(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/Logout", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) ListSessions(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*SessionList, error) {
+	out := new(SessionList)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/ListSessions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) RevokeSession(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/RevokeSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) MuteUser(ctx context.Context, in *MuteRequest, opts ...grpc.CallOption) (*SentMessageStatus, error) {
+	out := new(SentMessageStatus)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/MuteUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) DeleteMessage(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*SentMessageStatus, error) {
+	out := new(SentMessageStatus)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/DeleteMessage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) LockRoom(ctx context.Context, in *LockRoomRequest, opts ...grpc.CallOption) (*SentMessageStatus, error) {
+	out := new(SentMessageStatus)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/LockRoom", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) GetSettings(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*UserSettings, error) {
+	out := new(UserSettings)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/GetSettings", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) UpdateSettings(ctx context.Context, in *UserSettingsRequest, opts ...grpc.CallOption) (*UserSettings, error) {
+	out := new(UserSettings)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/UpdateSettings", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) BlockUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*UserSettings, error) {
+	out := new(UserSettings)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/BlockUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) UnblockUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*UserSettings, error) {
+	out := new(UserSettings)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/UnblockUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) UpdateProfile(ctx context.Context, in *User, opts ...grpc.CallOption) (*PublicUserInfo, error) {
+	out := new(PublicUserInfo)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/UpdateProfile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) ChangePassword(ctx context.Context, in *PasswordChangeRequest, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/ChangePassword", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) DeleteAccount(ctx context.Context, in *UserLoginCredentials, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/DeleteAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) EnrollTOTP(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*TOTPEnrollment, error) {
+	out := new(TOTPEnrollment)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/EnrollTOTP", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) ConfirmTOTP(ctx context.Context, in *TOTPCode, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/ConfirmTOTP", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) DisableTOTP(ctx context.Context, in *TOTPCode, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/DisableTOTP", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) VerifyEmail(ctx context.Context, in *VerificationCode, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/VerifyEmail", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) ResendVerificationCode(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/ResendVerificationCode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) RequestPasswordReset(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/RequestPasswordReset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) ConfirmPasswordReset(ctx context.Context, in *PasswordResetConfirmation, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/ConfirmPasswordReset", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) ScheduleMessage(ctx context.Context, in *ScheduleRequest, opts ...grpc.CallOption) (*ScheduledMessage, error) {
+	out := new(ScheduledMessage)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/ScheduleMessage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) ListScheduled(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*ScheduledMessageList, error) {
+	out := new(ScheduledMessageList)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/ListScheduled", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) CancelScheduled(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*ScheduledMessage, error) {
+	out := new(ScheduledMessage)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/CancelScheduled", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) SearchMessages(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResults, error) {
+	out := new(SearchResults)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/SearchMessages", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) ListMentions(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*MentionList, error) {
+	out := new(MentionList)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/ListMentions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatRoomClient) MarkMentionsRead(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*MentionList, error) {
+	out := new(MentionList)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/MarkMentionsRead", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *chatRoomClient) GetConnectedPeers(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*PublicUserInfoList, error) {
 	out := new(PublicUserInfoList)
 	err := c.cc.Invoke(ctx, "/grpcService.ChatRoom/GetConnectedPeers", in, out, opts...)
@@ -145,6 +431,58 @@ type ChatRoomServer interface {
 	LikeMessage(context.Context, *UserRequest) (*SentMessageStatus, error)
 	// login using a pair of username and password
 	Login(context.Context, *UserLoginCredentials) (*AuthenticationResult, error)
+	// end the session of the calling client
+	Logout(context.Context, *UserRequest) (*AuthenticationResult, error)
+	// list every logged in session of the calling user
+	ListSessions(context.Context, *UserRequest) (*SessionList, error)
+	// end one of the sessions of the calling user, target is the session id
+	RevokeSession(context.Context, *UserRequest) (*AuthenticationResult, error)
+	// mute a user in the chat room (moderators only)
+	MuteUser(context.Context, *MuteRequest) (*SentMessageStatus, error)
+	// delete a room message by its id, own messages or any message for moderators
+	DeleteMessage(context.Context, *UserRequest) (*SentMessageStatus, error)
+	// make the chat room read-only for non moderators (moderators only)
+	LockRoom(context.Context, *LockRoomRequest) (*SentMessageStatus, error)
+	// get the block list and privacy settings of the calling user
+	GetSettings(context.Context, *UserRequest) (*UserSettings, error)
+	// replace the block list and privacy settings of the calling user
+	UpdateSettings(context.Context, *UserSettingsRequest) (*UserSettings, error)
+	// hide public messages of target and reject their private messages
+	BlockUser(context.Context, *UserRequest) (*UserSettings, error)
+	// remove target from the block list
+	UnblockUser(context.Context, *UserRequest) (*UserSettings, error)
+	// update full name, email, birthdate and address of the calling user
+	UpdateProfile(context.Context, *User) (*PublicUserInfo, error)
+	// change the password of the calling user, ending their other sessions
+	ChangePassword(context.Context, *PasswordChangeRequest) (*AuthenticationResult, error)
+	// delete the account of the calling user, the password is required
+	DeleteAccount(context.Context, *UserLoginCredentials) (*AuthenticationResult, error)
+	// start two-factor authentication setup for the calling user
+	EnrollTOTP(context.Context, *UserRequest) (*TOTPEnrollment, error)
+	// enable two-factor authentication with a code of the enrolled secret
+	ConfirmTOTP(context.Context, *TOTPCode) (*AuthenticationResult, error)
+	// disable two-factor authentication, a TOTP or recovery code is required
+	DisableTOTP(context.Context, *TOTPCode) (*AuthenticationResult, error)
+	// confirm the email address of a user with the code sent to it
+	VerifyEmail(context.Context, *VerificationCode) (*AuthenticationResult, error)
+	// send a new email verification code, sender is the username
+	ResendVerificationCode(context.Context, *UserRequest) (*AuthenticationResult, error)
+	// email a password reset code, sender is the username
+	RequestPasswordReset(context.Context, *UserRequest) (*AuthenticationResult, error)
+	// set a new password with the code sent by RequestPasswordReset
+	ConfirmPasswordReset(context.Context, *PasswordResetConfirmation) (*AuthenticationResult, error)
+	// deliver a message to the room, or privately to recipent, at a later time
+	ScheduleMessage(context.Context, *ScheduleRequest) (*ScheduledMessage, error)
+	// list the scheduled messages of the calling user waiting for delivery
+	ListScheduled(context.Context, *UserRequest) (*ScheduledMessageList, error)
+	// cancel a scheduled message of the calling user, target is its id
+	CancelScheduled(context.Context, *UserRequest) (*ScheduledMessage, error)
+	// search the room messages and the private messages of the calling user
+	SearchMessages(context.Context, *SearchRequest) (*SearchResults, error)
+	// list the unread room messages mentioning the calling user
+	ListMentions(context.Context, *UserRequest) (*MentionList, error)
+	// mark a mention as read, target is the id of its message, or every mention when not set
+	MarkMentionsRead(context.Context, *UserRequest) (*MentionList, error)
 	// Get a list of information of connected peers or specific peers
 	GetConnectedPeers(context.Context, *UserRequest) (*PublicUserInfoList, error)
 	// Get a peer information (except password)
@@ -171,6 +509,84 @@ func (UnimplementedChatRoomServer) LikeMessage(context.Context, *UserRequest) (*
 func (UnimplementedChatRoomServer) Login(context.Context, *UserLoginCredentials) (*AuthenticationResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
 }
+func (UnimplementedChatRoomServer) Logout(context.Context, *UserRequest) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Logout not implemented")
+}
+func (UnimplementedChatRoomServer) ListSessions(context.Context, *UserRequest) (*SessionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedChatRoomServer) RevokeSession(context.Context, *UserRequest) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeSession not implemented")
+}
+func (UnimplementedChatRoomServer) MuteUser(context.Context, *MuteRequest) (*SentMessageStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MuteUser not implemented")
+}
+func (UnimplementedChatRoomServer) DeleteMessage(context.Context, *UserRequest) (*SentMessageStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMessage not implemented")
+}
+func (UnimplementedChatRoomServer) LockRoom(context.Context, *LockRoomRequest) (*SentMessageStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LockRoom not implemented")
+}
+func (UnimplementedChatRoomServer) GetSettings(context.Context, *UserRequest) (*UserSettings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSettings not implemented")
+}
+func (UnimplementedChatRoomServer) UpdateSettings(context.Context, *UserSettingsRequest) (*UserSettings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateSettings not implemented")
+}
+func (UnimplementedChatRoomServer) BlockUser(context.Context, *UserRequest) (*UserSettings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlockUser not implemented")
+}
+func (UnimplementedChatRoomServer) UnblockUser(context.Context, *UserRequest) (*UserSettings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnblockUser not implemented")
+}
+func (UnimplementedChatRoomServer) UpdateProfile(context.Context, *User) (*PublicUserInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateProfile not implemented")
+}
+func (UnimplementedChatRoomServer) ChangePassword(context.Context, *PasswordChangeRequest) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (UnimplementedChatRoomServer) DeleteAccount(context.Context, *UserLoginCredentials) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAccount not implemented")
+}
+func (UnimplementedChatRoomServer) EnrollTOTP(context.Context, *UserRequest) (*TOTPEnrollment, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnrollTOTP not implemented")
+}
+func (UnimplementedChatRoomServer) ConfirmTOTP(context.Context, *TOTPCode) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmTOTP not implemented")
+}
+func (UnimplementedChatRoomServer) DisableTOTP(context.Context, *TOTPCode) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableTOTP not implemented")
+}
+func (UnimplementedChatRoomServer) VerifyEmail(context.Context, *VerificationCode) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyEmail not implemented")
+}
+func (UnimplementedChatRoomServer) ResendVerificationCode(context.Context, *UserRequest) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResendVerificationCode not implemented")
+}
+func (UnimplementedChatRoomServer) RequestPasswordReset(context.Context, *UserRequest) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestPasswordReset not implemented")
+}
+func (UnimplementedChatRoomServer) ConfirmPasswordReset(context.Context, *PasswordResetConfirmation) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmPasswordReset not implemented")
+}
+func (UnimplementedChatRoomServer) ScheduleMessage(context.Context, *ScheduleRequest) (*ScheduledMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ScheduleMessage not implemented")
+}
+func (UnimplementedChatRoomServer) ListScheduled(context.Context, *UserRequest) (*ScheduledMessageList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListScheduled not implemented")
+}
+func (UnimplementedChatRoomServer) CancelScheduled(context.Context, *UserRequest) (*ScheduledMessage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelScheduled not implemented")
+}
+func (UnimplementedChatRoomServer) SearchMessages(context.Context, *SearchRequest) (*SearchResults, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchMessages not implemented")
+}
+func (UnimplementedChatRoomServer) ListMentions(context.Context, *UserRequest) (*MentionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMentions not implemented")
+}
+func (UnimplementedChatRoomServer) MarkMentionsRead(context.Context, *UserRequest) (*MentionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MarkMentionsRead not implemented")
+}
 func (UnimplementedChatRoomServer) GetConnectedPeers(context.Context, *UserRequest) (*PublicUserInfoList, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetConnectedPeers not implemented")
 }
@@ -288,81 +704,1159 @@ func _ChatRoom_Login_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ChatRoom_GetConnectedPeers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _ChatRoom_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UserRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChatRoomServer).GetConnectedPeers(ctx, in)
+		return srv.(ChatRoomServer).Logout(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/grpcService.ChatRoom/GetConnectedPeers",
+		FullMethod: "/grpcService.ChatRoom/Logout",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChatRoomServer).GetConnectedPeers(ctx, req.(*UserRequest))
+		return srv.(ChatRoomServer).Logout(ctx, req.(*UserRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _ChatRoom_GetPeerInfomations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _ChatRoom_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UserRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChatRoomServer).GetPeerInfomations(ctx, in)
+		return srv.(ChatRoomServer).ListSessions(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/grpcService.ChatRoom/GetPeerInfomations",
+		FullMethod: "/grpcService.ChatRoom/ListSessions",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChatRoomServer).GetPeerInfomations(ctx, req.(*UserRequest))
+		return srv.(ChatRoomServer).ListSessions(ctx, req.(*UserRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-// ChatRoom_ServiceDesc is the grpc.ServiceDesc for ChatRoom service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var ChatRoom_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "grpcService.ChatRoom",
-	HandlerType: (*ChatRoomServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "SendPrivateMessage",
-			Handler:    _ChatRoom_SendPrivateMessage_Handler,
-		},
-		{
-			MethodName: "Register",
-			Handler:    _ChatRoom_Register_Handler,
-		},
-		{
-			MethodName: "LikeMessage",
-			Handler:    _ChatRoom_LikeMessage_Handler,
-		},
-		{
-			MethodName: "Login",
-			Handler:    _ChatRoom_Login_Handler,
-		},
-		{
-			MethodName: "GetConnectedPeers",
-			Handler:    _ChatRoom_GetConnectedPeers_Handler,
-		},
-		{
-			MethodName: "GetPeerInfomations",
-			Handler:    _ChatRoom_GetPeerInfomations_Handler,
-		},
-	},
-	Streams: []grpc.StreamDesc{
-		{
-			StreamName:    "Chat",
-			Handler:       _ChatRoom_Chat_Handler,
-			ServerStreams: true,
-			ClientStreams: true,
+func _ChatRoom_RevokeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).RevokeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/RevokeSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).RevokeSession(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_MuteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MuteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).MuteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/MuteUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).MuteUser(ctx, req.(*MuteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_DeleteMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).DeleteMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/DeleteMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).DeleteMessage(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_LockRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockRoomRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).LockRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/LockRoom",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).LockRoom(ctx, req.(*LockRoomRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_GetSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).GetSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/GetSettings",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).GetSettings(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_UpdateSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).UpdateSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/UpdateSettings",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).UpdateSettings(ctx, req.(*UserSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_BlockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).BlockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/BlockUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).BlockUser(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_UnblockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).UnblockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/UnblockUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).UnblockUser(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_UpdateProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(User)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).UpdateProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/UpdateProfile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).UpdateProfile(ctx, req.(*User))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordChangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/ChangePassword",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).ChangePassword(ctx, req.(*PasswordChangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_DeleteAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserLoginCredentials)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).DeleteAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/DeleteAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).DeleteAccount(ctx, req.(*UserLoginCredentials))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_EnrollTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).EnrollTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/EnrollTOTP",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).EnrollTOTP(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_ConfirmTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TOTPCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).ConfirmTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/ConfirmTOTP",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).ConfirmTOTP(ctx, req.(*TOTPCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_DisableTOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TOTPCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).DisableTOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/DisableTOTP",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).DisableTOTP(ctx, req.(*TOTPCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_VerifyEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerificationCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).VerifyEmail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/VerifyEmail",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).VerifyEmail(ctx, req.(*VerificationCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_ResendVerificationCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).ResendVerificationCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/ResendVerificationCode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).ResendVerificationCode(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_RequestPasswordReset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).RequestPasswordReset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/RequestPasswordReset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).RequestPasswordReset(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_ConfirmPasswordReset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordResetConfirmation)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).ConfirmPasswordReset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/ConfirmPasswordReset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).ConfirmPasswordReset(ctx, req.(*PasswordResetConfirmation))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_ScheduleMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScheduleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).ScheduleMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/ScheduleMessage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).ScheduleMessage(ctx, req.(*ScheduleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_ListScheduled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).ListScheduled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/ListScheduled",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).ListScheduled(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_CancelScheduled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).CancelScheduled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/CancelScheduled",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).CancelScheduled(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_SearchMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).SearchMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/SearchMessages",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).SearchMessages(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_ListMentions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).ListMentions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/ListMentions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).ListMentions(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_MarkMentionsRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).MarkMentionsRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/MarkMentionsRead",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).MarkMentionsRead(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_GetConnectedPeers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).GetConnectedPeers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/GetConnectedPeers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).GetConnectedPeers(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatRoom_GetPeerInfomations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatRoomServer).GetPeerInfomations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatRoom/GetPeerInfomations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatRoomServer).GetPeerInfomations(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ChatRoom_ServiceDesc is the grpc.ServiceDesc for ChatRoom service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ChatRoom_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "grpcService.ChatRoom",
+	HandlerType: (*ChatRoomServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SendPrivateMessage",
+			Handler:    _ChatRoom_SendPrivateMessage_Handler,
+		},
+		{
+			MethodName: "Register",
+			Handler:    _ChatRoom_Register_Handler,
+		},
+		{
+			MethodName: "LikeMessage",
+			Handler:    _ChatRoom_LikeMessage_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _ChatRoom_Login_Handler,
+		},
+		{
+			MethodName: "Logout",
+			Handler:    _ChatRoom_Logout_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _ChatRoom_ListSessions_Handler,
+		},
+		{
+			MethodName: "RevokeSession",
+			Handler:    _ChatRoom_RevokeSession_Handler,
+		},
+		{
+			MethodName: "MuteUser",
+			Handler:    _ChatRoom_MuteUser_Handler,
+		},
+		{
+			MethodName: "DeleteMessage",
+			Handler:    _ChatRoom_DeleteMessage_Handler,
+		},
+		{
+			MethodName: "LockRoom",
+			Handler:    _ChatRoom_LockRoom_Handler,
+		},
+		{
+			MethodName: "GetSettings",
+			Handler:    _ChatRoom_GetSettings_Handler,
+		},
+		{
+			MethodName: "UpdateSettings",
+			Handler:    _ChatRoom_UpdateSettings_Handler,
+		},
+		{
+			MethodName: "BlockUser",
+			Handler:    _ChatRoom_BlockUser_Handler,
+		},
+		{
+			MethodName: "UnblockUser",
+			Handler:    _ChatRoom_UnblockUser_Handler,
+		},
+		{
+			MethodName: "UpdateProfile",
+			Handler:    _ChatRoom_UpdateProfile_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _ChatRoom_ChangePassword_Handler,
+		},
+		{
+			MethodName: "DeleteAccount",
+			Handler:    _ChatRoom_DeleteAccount_Handler,
+		},
+		{
+			MethodName: "EnrollTOTP",
+			Handler:    _ChatRoom_EnrollTOTP_Handler,
+		},
+		{
+			MethodName: "ConfirmTOTP",
+			Handler:    _ChatRoom_ConfirmTOTP_Handler,
+		},
+		{
+			MethodName: "DisableTOTP",
+			Handler:    _ChatRoom_DisableTOTP_Handler,
+		},
+		{
+			MethodName: "VerifyEmail",
+			Handler:    _ChatRoom_VerifyEmail_Handler,
+		},
+		{
+			MethodName: "ResendVerificationCode",
+			Handler:    _ChatRoom_ResendVerificationCode_Handler,
+		},
+		{
+			MethodName: "RequestPasswordReset",
+			Handler:    _ChatRoom_RequestPasswordReset_Handler,
+		},
+		{
+			MethodName: "ConfirmPasswordReset",
+			Handler:    _ChatRoom_ConfirmPasswordReset_Handler,
+		},
+		{
+			MethodName: "ScheduleMessage",
+			Handler:    _ChatRoom_ScheduleMessage_Handler,
+		},
+		{
+			MethodName: "ListScheduled",
+			Handler:    _ChatRoom_ListScheduled_Handler,
+		},
+		{
+			MethodName: "CancelScheduled",
+			Handler:    _ChatRoom_CancelScheduled_Handler,
+		},
+		{
+			MethodName: "SearchMessages",
+			Handler:    _ChatRoom_SearchMessages_Handler,
+		},
+		{
+			MethodName: "ListMentions",
+			Handler:    _ChatRoom_ListMentions_Handler,
+		},
+		{
+			MethodName: "MarkMentionsRead",
+			Handler:    _ChatRoom_MarkMentionsRead_Handler,
+		},
+		{
+			MethodName: "GetConnectedPeers",
+			Handler:    _ChatRoom_GetConnectedPeers_Handler,
+		},
+		{
+			MethodName: "GetPeerInfomations",
+			Handler:    _ChatRoom_GetPeerInfomations_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Chat",
+			Handler:       _ChatRoom_Chat_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "grpcService/services.proto",
+}
+
+// ChatAdminClient is the client API for ChatAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ChatAdminClient interface {
+	// list every registered user
+	ListUsers(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AdminUserList, error)
+	// prevent target from logging in and end their sessions
+	DisableUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// allow a disabled target to log in again
+	EnableUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// remove target account and end their sessions
+	DeleteUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// set a new password for target and end their sessions
+	ResetPassword(ctx context.Context, in *PasswordResetRequest, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// list every live session, or only those of target
+	ListLiveSessions(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*SessionList, error)
+	// end every session of target
+	KickUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// change the role of target
+	SetRole(ctx context.Context, in *RoleRequest, opts ...grpc.CallOption) (*AuthenticationResult, error)
+	// broadcast a server announcement to the chat room
+	Announce(ctx context.Context, in *ChatMessage, opts ...grpc.CallOption) (*SentMessageStatus, error)
+	// create an incoming webhook token, external systems post to the room as its bot with it
+	CreateWebhookToken(ctx context.Context, in *WebhookTokenRequest, opts ...grpc.CallOption) (*WebhookToken, error)
+	// list the incoming webhook tokens, without the tokens themselves
+	ListWebhookTokens(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*WebhookTokenList, error)
+	// revoke an incoming webhook token, target is its id
+	RevokeWebhookToken(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error)
+}
+
+type chatAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChatAdminClient(cc grpc.ClientConnInterface) ChatAdminClient {
+	return &chatAdminClient{cc}
+}
+
+func (c *chatAdminClient) ListUsers(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AdminUserList, error) {
+	out := new(AdminUserList)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatAdmin/ListUsers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatAdminClient) DisableUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatAdmin/DisableUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatAdminClient) EnableUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatAdmin/EnableUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatAdminClient) DeleteUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatAdmin/DeleteUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatAdminClient) ResetPassword(ctx context.Context, in *PasswordResetRequest, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatAdmin/ResetPassword", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatAdminClient) ListLiveSessions(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*SessionList, error) {
+	out := new(SessionList)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatAdmin/ListLiveSessions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatAdminClient) KickUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatAdmin/KickUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatAdminClient) SetRole(ctx context.Context, in *RoleRequest, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatAdmin/SetRole", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatAdminClient) Announce(ctx context.Context, in *ChatMessage, opts ...grpc.CallOption) (*SentMessageStatus, error) {
+	out := new(SentMessageStatus)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatAdmin/Announce", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatAdminClient) CreateWebhookToken(ctx context.Context, in *WebhookTokenRequest, opts ...grpc.CallOption) (*WebhookToken, error) {
+	out := new(WebhookToken)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatAdmin/CreateWebhookToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatAdminClient) ListWebhookTokens(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*WebhookTokenList, error) {
+	out := new(WebhookTokenList)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatAdmin/ListWebhookTokens", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chatAdminClient) RevokeWebhookToken(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*AuthenticationResult, error) {
+	out := new(AuthenticationResult)
+	err := c.cc.Invoke(ctx, "/grpcService.ChatAdmin/RevokeWebhookToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ChatAdminServer is the server API for ChatAdmin service.
+// All implementations must embed UnimplementedChatAdminServer
+// for forward compatibility
+type ChatAdminServer interface {
+	// list every registered user
+	ListUsers(context.Context, *UserRequest) (*AdminUserList, error)
+	// prevent target from logging in and end their sessions
+	DisableUser(context.Context, *UserRequest) (*AuthenticationResult, error)
+	// allow a disabled target to log in again
+	EnableUser(context.Context, *UserRequest) (*AuthenticationResult, error)
+	// remove target account and end their sessions
+	DeleteUser(context.Context, *UserRequest) (*AuthenticationResult, error)
+	// set a new password for target and end their sessions
+	ResetPassword(context.Context, *PasswordResetRequest) (*AuthenticationResult, error)
+	// list every live session, or only those of target
+	ListLiveSessions(context.Context, *UserRequest) (*SessionList, error)
+	// end every session of target
+	KickUser(context.Context, *UserRequest) (*AuthenticationResult, error)
+	// change the role of target
+	SetRole(context.Context, *RoleRequest) (*AuthenticationResult, error)
+	// broadcast a server announcement to the chat room
+	Announce(context.Context, *ChatMessage) (*SentMessageStatus, error)
+	// create an incoming webhook token, external systems post to the room as its bot with it
+	CreateWebhookToken(context.Context, *WebhookTokenRequest) (*WebhookToken, error)
+	// list the incoming webhook tokens, without the tokens themselves
+	ListWebhookTokens(context.Context, *UserRequest) (*WebhookTokenList, error)
+	// revoke an incoming webhook token, target is its id
+	RevokeWebhookToken(context.Context, *UserRequest) (*AuthenticationResult, error)
+	mustEmbedUnimplementedChatAdminServer()
+}
+
+// UnimplementedChatAdminServer must be embedded to have forward compatible implementations.
+type UnimplementedChatAdminServer struct {
+}
+
+func (UnimplementedChatAdminServer) ListUsers(context.Context, *UserRequest) (*AdminUserList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
+}
+func (UnimplementedChatAdminServer) DisableUser(context.Context, *UserRequest) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableUser not implemented")
+}
+func (UnimplementedChatAdminServer) EnableUser(context.Context, *UserRequest) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnableUser not implemented")
+}
+func (UnimplementedChatAdminServer) DeleteUser(context.Context, *UserRequest) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
+}
+func (UnimplementedChatAdminServer) ResetPassword(context.Context, *PasswordResetRequest) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetPassword not implemented")
+}
+func (UnimplementedChatAdminServer) ListLiveSessions(context.Context, *UserRequest) (*SessionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLiveSessions not implemented")
+}
+func (UnimplementedChatAdminServer) KickUser(context.Context, *UserRequest) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KickUser not implemented")
+}
+func (UnimplementedChatAdminServer) SetRole(context.Context, *RoleRequest) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRole not implemented")
+}
+func (UnimplementedChatAdminServer) Announce(context.Context, *ChatMessage) (*SentMessageStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Announce not implemented")
+}
+func (UnimplementedChatAdminServer) CreateWebhookToken(context.Context, *WebhookTokenRequest) (*WebhookToken, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateWebhookToken not implemented")
+}
+func (UnimplementedChatAdminServer) ListWebhookTokens(context.Context, *UserRequest) (*WebhookTokenList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhookTokens not implemented")
+}
+func (UnimplementedChatAdminServer) RevokeWebhookToken(context.Context, *UserRequest) (*AuthenticationResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeWebhookToken not implemented")
+}
+func (UnimplementedChatAdminServer) mustEmbedUnimplementedChatAdminServer() {}
+
+// UnsafeChatAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ChatAdminServer will
+// result in compilation errors.
+type UnsafeChatAdminServer interface {
+	mustEmbedUnimplementedChatAdminServer()
+}
+
+func RegisterChatAdminServer(s grpc.ServiceRegistrar, srv ChatAdminServer) {
+	s.RegisterService(&ChatAdmin_ServiceDesc, srv)
+}
+
+func _ChatAdmin_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatAdminServer).ListUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatAdmin/ListUsers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatAdminServer).ListUsers(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatAdmin_DisableUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatAdminServer).DisableUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatAdmin/DisableUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatAdminServer).DisableUser(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatAdmin_EnableUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatAdminServer).EnableUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatAdmin/EnableUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatAdminServer).EnableUser(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatAdmin_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatAdminServer).DeleteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatAdmin/DeleteUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatAdminServer).DeleteUser(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatAdmin_ResetPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordResetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatAdminServer).ResetPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatAdmin/ResetPassword",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatAdminServer).ResetPassword(ctx, req.(*PasswordResetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatAdmin_ListLiveSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatAdminServer).ListLiveSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatAdmin/ListLiveSessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatAdminServer).ListLiveSessions(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatAdmin_KickUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatAdminServer).KickUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatAdmin/KickUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatAdminServer).KickUser(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatAdmin_SetRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatAdminServer).SetRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatAdmin/SetRole",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatAdminServer).SetRole(ctx, req.(*RoleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatAdmin_Announce_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChatMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatAdminServer).Announce(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatAdmin/Announce",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatAdminServer).Announce(ctx, req.(*ChatMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatAdmin_CreateWebhookToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WebhookTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatAdminServer).CreateWebhookToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatAdmin/CreateWebhookToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatAdminServer).CreateWebhookToken(ctx, req.(*WebhookTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatAdmin_ListWebhookTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatAdminServer).ListWebhookTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatAdmin/ListWebhookTokens",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatAdminServer).ListWebhookTokens(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChatAdmin_RevokeWebhookToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChatAdminServer).RevokeWebhookToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/grpcService.ChatAdmin/RevokeWebhookToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChatAdminServer).RevokeWebhookToken(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ChatAdmin_ServiceDesc is the grpc.ServiceDesc for ChatAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ChatAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "grpcService.ChatAdmin",
+	HandlerType: (*ChatAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListUsers",
+			Handler:    _ChatAdmin_ListUsers_Handler,
+		},
+		{
+			MethodName: "DisableUser",
+			Handler:    _ChatAdmin_DisableUser_Handler,
+		},
+		{
+			MethodName: "EnableUser",
+			Handler:    _ChatAdmin_EnableUser_Handler,
+		},
+		{
+			MethodName: "DeleteUser",
+			Handler:    _ChatAdmin_DeleteUser_Handler,
+		},
+		{
+			MethodName: "ResetPassword",
+			Handler:    _ChatAdmin_ResetPassword_Handler,
+		},
+		{
+			MethodName: "ListLiveSessions",
+			Handler:    _ChatAdmin_ListLiveSessions_Handler,
+		},
+		{
+			MethodName: "KickUser",
+			Handler:    _ChatAdmin_KickUser_Handler,
+		},
+		{
+			MethodName: "SetRole",
+			Handler:    _ChatAdmin_SetRole_Handler,
+		},
+		{
+			MethodName: "Announce",
+			Handler:    _ChatAdmin_Announce_Handler,
+		},
+		{
+			MethodName: "CreateWebhookToken",
+			Handler:    _ChatAdmin_CreateWebhookToken_Handler,
+		},
+		{
+			MethodName: "ListWebhookTokens",
+			Handler:    _ChatAdmin_ListWebhookTokens_Handler,
+		},
+		{
+			MethodName: "RevokeWebhookToken",
+			Handler:    _ChatAdmin_RevokeWebhookToken_Handler,
 		},
 	},
+	Streams:  []grpc.StreamDesc{},
 	Metadata: "grpcService/services.proto",
 }
@@ -0,0 +1,145 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+)
+
+// update full name, email, birthdate and address of the calling user
+func (cs *ChatServer) UpdateProfile(ctx context.Context, profile *gs.User) (*gs.PublicUserInfo, error) {
+	username := profile.GetUsername()
+	slog.Info("Profile update request", "user", username)
+
+	if _, err := cs.authenticate(ctx, username); err != nil {
+		return nil, err
+	}
+
+	if err := cs.validateProfile(profile); err != nil {
+		return nil, err
+	}
+
+	var updated *gs.User
+	emailChanged := false
+	err := cs.users.update(username, func(user *gs.User) error {
+		// a new address has to be verified again
+		emailChanged = user.GetEmail() != profile.GetEmail()
+		if emailChanged || profile.Email == nil {
+			user.EmailVerified = nil
+		}
+
+		// password, role and settings have their own RPCs
+		user.FullName = profile.GetFullName()
+		user.Email = profile.Email
+		user.Birthdate = profile.Birthdate
+		user.Address = profile.GetAddress()
+
+		updated = user
+		return nil
+	})
+
+	if err != nil {
+		slog.Warn("Profile update failed", "user", username, "error", err)
+		return nil, storeError(err, username)
+	}
+
+	slog.Info("Profile updated", "user", username)
+	// saving the profile again does not send another code, nothing is sent when email is not configured
+	if emailChanged && cs.Mailer != nil {
+		if err := cs.sendVerificationCode(updated); err != nil {
+			slog.Error("Failed to send a verification code", "user", username, "error", err)
+		}
+	}
+
+	return publicUserInfo(updated), nil
+}
+
+// change the password of the calling user, ending their other sessions
+func (cs *ChatServer) ChangePassword(ctx context.Context, request *gs.PasswordChangeRequest) (*gs.AuthenticationResult, error) {
+	username := request.GetSender()
+	slog.Info("Password change request", "user", username)
+
+	current, err := cs.authenticate(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cs.validatePassword("new_password", request.GetNewPassword()); err != nil {
+		return nil, err
+	}
+
+	err = cs.users.update(username, func(user *gs.User) error {
+		if user.Password != request.GetOldPassword() {
+			return rpcError(codes.Unauthenticated, gs.ReasonWrongCredentials, "Failed to change password of %s: Wrong password!", username)
+		}
+
+		user.Password = request.GetNewPassword()
+		return nil
+	})
+
+	if err != nil {
+		slog.Warn("Password change failed", "user", username, "error", err)
+		return nil, storeError(err, username)
+	}
+
+	// other devices have to login again with the new password
+	cs.mu.Lock()
+	for _, s := range cs.loggedInAccount[username] {
+		if s != current {
+			cs.endSession(s, "password changed")
+		}
+	}
+	cs.mu.Unlock()
+
+	msg := fmt.Sprintf("Password of %s was changed successfully!", username)
+	slog.Info(msg)
+	return &gs.AuthenticationResult{
+		Username: username,
+		Status:   int32(codes.OK),
+		Message:  &msg,
+	}, nil
+}
+
+// delete the account of the calling user, the password is required
+func (cs *ChatServer) DeleteAccount(ctx context.Context, cred *gs.UserLoginCredentials) (*gs.AuthenticationResult, error) {
+	username := cred.GetUsername()
+	slog.Info("Account deletion request", "user", username)
+
+	if _, err := cs.authenticate(ctx, username); err != nil {
+		return nil, err
+	}
+
+	user := cs.users.find(username)
+	if user == nil {
+		return nil, userNotFoundError(username)
+	}
+
+	if user.Password != cred.GetPassword() {
+		return nil, rpcError(codes.Unauthenticated, gs.ReasonWrongCredentials, "Failed to delete account %s: Wrong password!", username)
+	}
+
+	if err := cs.users.remove(username); err != nil {
+		slog.Warn("Account deletion failed", "user", username, "error", err)
+		return nil, storeError(err, username)
+	}
+
+	cs.mu.Lock()
+	cs.forgetUser(username, "account deleted")
+
+	msg := fmt.Sprintf("User %s has deleted their account!", username)
+	cs.broadcast(&gs.ChatMessage{
+		Message: msg,
+		Sender:  "Server",
+	}, nil)
+	cs.mu.Unlock()
+
+	slog.Info(msg)
+	return &gs.AuthenticationResult{
+		Username: username,
+		Status:   int32(codes.OK),
+		Message:  &msg,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package backend
+
+import (
+	"testing"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// like the last room message of target as liker
+func like(ts *testServer, liker *testChat, target string) error {
+	_, err := ts.client.LikeMessage(liker.ctx, &gs.UserRequest{Sender: liker.username, Target: &target})
+	return err
+}
+
+func TestLikeAfterAccountDeletion(t *testing.T) {
+	ts := newTestServer(t, nil)
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	alice.say(t, "hello")
+	bob.expect(t, from("alice", "hello"))
+	if err := like(ts, bob, "alice"); err != nil {
+		t.Fatalf("like: %v", err)
+	}
+
+	_, err := ts.client.DeleteAccount(alice.ctx, &gs.UserLoginCredentials{Username: "alice", Password: testPassword})
+	if err != nil {
+		t.Fatalf("delete account: %v", err)
+	}
+
+	err = like(ts, bob, "alice")
+	if status.Code(err) != codes.NotFound || reasonOf(err) != gs.ReasonMessageNotFound {
+		t.Fatalf("like after the account was deleted: %v, want NotFound", err)
+	}
+
+	// the server is still serving
+	if err := like(ts, bob, "nobody"); status.Code(err) != codes.NotFound {
+		t.Fatalf("like of a user who never logged in: %v, want NotFound", err)
+	}
+}
+
+func TestLikeAfterAdminDeletion(t *testing.T) {
+	ts := newTestServer(t, nil)
+	admin := ts.joinAs(t, "carol", gs.Role_ADMIN)
+	alice := ts.join(t, "alice")
+
+	alice.say(t, "hello")
+	admin.expect(t, from("alice", "hello"))
+
+	ts.cs.mu.Lock()
+	ts.cs.mutedUntil["alice"] = ts.cs.Clock().Add(testTimeout)
+	ts.cs.mu.Unlock()
+
+	target := "alice"
+	if _, err := ts.admin.DeleteUser(admin.ctx, &gs.UserRequest{Sender: "carol", Target: &target}); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+
+	if err := like(ts, admin, "alice"); status.Code(err) != codes.NotFound {
+		t.Fatalf("like after the user was deleted: %v, want NotFound", err)
+	}
+
+	// a new account with the same name starts clean
+	ts.cs.mu.Lock()
+	_, muted := ts.cs.mutedUntil["alice"]
+	_, flood := ts.cs.flood["alice"]
+	ts.cs.mu.Unlock()
+	if muted || flood {
+		t.Errorf("deleted user kept its state: muted %v, flood %v", muted, flood)
+	}
+}
+
+func TestUpdateProfileSendsCodeForNewEmail(t *testing.T) {
+	mailer := &testMailer{}
+	ts := newMailServer(t, mailer, nil)
+	ctx := ts.login(t, "alice")
+	_, registered := mailer.last()
+
+	update := func(email string) {
+		t.Helper()
+		if _, err := ts.client.UpdateProfile(ctx, &gs.User{Username: "alice", FullName: "Alice", Email: &email}); err != nil {
+			t.Fatalf("update profile: %v", err)
+		}
+	}
+
+	// the address did not change
+	update("alice@example.com")
+	if _, sent := mailer.last(); sent != registered {
+		t.Errorf("%d codes sent when the email did not change", sent-registered)
+	}
+
+	update("alice@example.org")
+	if _, sent := mailer.last(); sent != registered+1 {
+		t.Errorf("%d codes sent for a new email, want 1", sent-registered)
+	}
+}
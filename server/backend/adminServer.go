@@ -0,0 +1,363 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+)
+
+// Admin service for gRPC-ChatRoom, operating on the state of a ChatServer
+type AdminServer struct {
+	cs *ChatServer
+	gs.UnimplementedChatAdminServer
+}
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// check that the caller is logged in from this client with an admin account
+func (as *AdminServer) authorize(ctx context.Context, username string) error {
+	if _, err := as.cs.authenticate(ctx, username); err != nil {
+		return err
+	}
+
+	if !as.cs.can(username, PermAdminister) {
+		slog.Warn("User is not permitted to use the admin service", "user", username)
+		return rpcError(codes.PermissionDenied, gs.ReasonPermissionDenied, "User %s is not an admin!", username)
+	}
+
+	return nil
+}
+
+// check that an admin does not act on their own account
+func checkNotSelf(sender, target string) error {
+	if sender == target {
+		return rpcError(codes.FailedPrecondition, gs.ReasonSelfAction, "Admins cannot perform this action on their own account!")
+	}
+
+	return nil
+}
+
+// a successful admin action result
+func adminResult(target string, msg string) *gs.AuthenticationResult {
+	slog.Info(msg)
+	return &gs.AuthenticationResult{
+		Username: target,
+		Status:   int32(codes.OK),
+		Message:  &msg,
+	}
+}
+
+// end every session of a user, they will have to login again
+func (as *AdminServer) endUserSessions(username string, reason string) {
+	as.cs.mu.Lock()
+	defer as.cs.mu.Unlock()
+	as.cs.endAllSessions(username, reason)
+}
+
+// ---------------------------------------------------------//
+// ------------------------- RPC ---------------------------//
+
+// list every registered user
+func (as *AdminServer) ListUsers(ctx context.Context, request *gs.UserRequest) (*gs.AdminUserList, error) {
+	if err := as.authorize(ctx, request.GetSender()); err != nil {
+		return nil, err
+	}
+
+	users := as.cs.users.list()
+
+	as.cs.mu.Lock()
+	defer as.cs.mu.Unlock()
+
+	result := &gs.AdminUserList{}
+	for _, user := range users {
+		result.User = append(result.User, &gs.AdminUserInfo{
+			Info:     publicUserInfo(user),
+			Role:     user.GetRole(),
+			Disabled: user.GetDisabled(),
+			Sessions: int32(len(as.cs.loggedInAccount[user.Username])),
+		})
+	}
+
+	return result, nil
+}
+
+// prevent a user from logging in and end their sessions
+func (as *AdminServer) DisableUser(ctx context.Context, request *gs.UserRequest) (*gs.AuthenticationResult, error) {
+	sender := request.GetSender()
+	target := request.GetTarget()
+
+	if err := as.authorize(ctx, sender); err != nil {
+		return nil, err
+	}
+	if err := checkNotSelf(sender, target); err != nil {
+		return nil, err
+	}
+
+	err := as.cs.users.update(target, func(user *gs.User) error {
+		disabled := true
+		user.Disabled = &disabled
+		return nil
+	})
+	if err != nil {
+		return nil, storeError(err, target)
+	}
+
+	as.endUserSessions(target, "account disabled by "+sender)
+	return adminResult(target, fmt.Sprintf("User %s was disabled by %s", target, sender)), nil
+}
+
+// allow a disabled user to log in again
+func (as *AdminServer) EnableUser(ctx context.Context, request *gs.UserRequest) (*gs.AuthenticationResult, error) {
+	sender := request.GetSender()
+	target := request.GetTarget()
+
+	if err := as.authorize(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	err := as.cs.users.update(target, func(user *gs.User) error {
+		user.Disabled = nil
+		return nil
+	})
+	if err != nil {
+		return nil, storeError(err, target)
+	}
+
+	return adminResult(target, fmt.Sprintf("User %s was enabled by %s", target, sender)), nil
+}
+
+// remove an account and end its sessions
+func (as *AdminServer) DeleteUser(ctx context.Context, request *gs.UserRequest) (*gs.AuthenticationResult, error) {
+	sender := request.GetSender()
+	target := request.GetTarget()
+
+	if err := as.authorize(ctx, sender); err != nil {
+		return nil, err
+	}
+	if err := checkNotSelf(sender, target); err != nil {
+		return nil, err
+	}
+
+	if err := as.cs.users.remove(target); err != nil {
+		return nil, storeError(err, target)
+	}
+
+	as.cs.mu.Lock()
+	as.cs.forgetUser(target, "account deleted by "+sender)
+	as.cs.mu.Unlock()
+
+	return adminResult(target, fmt.Sprintf("User %s was deleted by %s", target, sender)), nil
+}
+
+// set a new password for a user and end their sessions
+func (as *AdminServer) ResetPassword(ctx context.Context, request *gs.PasswordResetRequest) (*gs.AuthenticationResult, error) {
+	sender := request.GetSender()
+	target := request.GetTarget()
+
+	if err := as.authorize(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	if err := as.cs.validatePassword("new_password", request.GetNewPassword()); err != nil {
+		return nil, err
+	}
+
+	err := as.cs.users.update(target, func(user *gs.User) error {
+		user.Password = request.GetNewPassword()
+		return nil
+	})
+	if err != nil {
+		return nil, storeError(err, target)
+	}
+
+	as.endUserSessions(target, "password reset by "+sender)
+	return adminResult(target, fmt.Sprintf("Password of %s was reset by %s", target, sender)), nil
+}
+
+// list every live session, or only the sessions of the target user
+func (as *AdminServer) ListLiveSessions(ctx context.Context, request *gs.UserRequest) (*gs.SessionList, error) {
+	if err := as.authorize(ctx, request.GetSender()); err != nil {
+		return nil, err
+	}
+
+	as.cs.mu.Lock()
+	defer as.cs.mu.Unlock()
+
+	result := &gs.SessionList{}
+	for username, sessions := range as.cs.loggedInAccount {
+		if request.Target != nil && request.GetTarget() != username {
+			continue
+		}
+
+		for token, s := range sessions {
+			_, streaming := as.cs.clientStream[username][token]
+			result.Session = append(result.Session, &gs.SessionInfo{
+				Id:        s.id,
+				Peer:      s.peer,
+				LoginAt:   s.loginAt.Unix(),
+				LastSeen:  s.lastSeen.Unix(),
+				Streaming: streaming,
+				Username:  &s.username,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// end every session of a user
+func (as *AdminServer) KickUser(ctx context.Context, request *gs.UserRequest) (*gs.AuthenticationResult, error) {
+	sender := request.GetSender()
+	target := request.GetTarget()
+
+	if err := as.authorize(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	as.cs.mu.Lock()
+	defer as.cs.mu.Unlock()
+
+	if !as.cs.isLoggedIn(target) {
+		return nil, rpcError(codes.NotFound, gs.ReasonUserOffline, "User %s is not logged in!", target)
+	}
+
+	as.cs.endAllSessions(target, "kicked by "+sender)
+	as.cs.broadcast(&gs.ChatMessage{
+		Message: fmt.Sprintf("User %s was kicked from the chat room!", target),
+		Sender:  "Server",
+	}, nil)
+
+	return adminResult(target, fmt.Sprintf("User %s was kicked by %s", target, sender)), nil
+}
+
+// change the role of a user
+func (as *AdminServer) SetRole(ctx context.Context, request *gs.RoleRequest) (*gs.AuthenticationResult, error) {
+	sender := request.GetSender()
+	target := request.GetTarget()
+
+	if err := as.authorize(ctx, sender); err != nil {
+		return nil, err
+	}
+	if err := checkNotSelf(sender, target); err != nil {
+		return nil, err
+	}
+
+	if _, ok := gs.Role_name[int32(request.GetRole())]; !ok {
+		return nil, rpcError(codes.InvalidArgument, gs.ReasonInvalidArgument, "Unknown role %d!", request.GetRole())
+	}
+
+	err := as.cs.users.update(target, func(user *gs.User) error {
+		user.Role = request.GetRole()
+		return nil
+	})
+	if err != nil {
+		return nil, storeError(err, target)
+	}
+
+	return adminResult(target, fmt.Sprintf("User %s is now %s, set by %s", target, request.GetRole(), sender)), nil
+}
+
+// broadcast a server announcement to the chat room
+func (as *AdminServer) Announce(ctx context.Context, msg *gs.ChatMessage) (*gs.SentMessageStatus, error) {
+	sender := msg.GetSender()
+
+	if err := as.authorize(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	if msg.GetMessage() == "" {
+		return nil, rpcError(codes.InvalidArgument, gs.ReasonInvalidArgument, "Blank announcement is not allowed!")
+	}
+
+	timestamp := time.Now().Unix()
+	slog.LogAttrs(ctx, slog.LevelInfo, "Announcement", slog.String("user", sender), as.cs.messageAttr(msg.GetMessage()))
+
+	as.cs.mu.Lock()
+	as.cs.broadcast(&gs.ChatMessage{
+		Message: "[Announcement] " + msg.GetMessage(),
+		Sender:  "Server",
+	}, nil)
+	as.cs.mu.Unlock()
+
+	return &gs.SentMessageStatus{
+		Id:        fmt.Sprintf("%d-%s", timestamp, sender),
+		Timestamp: timestamp,
+		Status:    int32(codes.OK),
+	}, nil
+}
+
+// create an incoming webhook token posting to the chat room as a bot
+func (as *AdminServer) CreateWebhookToken(ctx context.Context, request *gs.WebhookTokenRequest) (*gs.WebhookToken, error) {
+	sender := request.GetSender()
+	botName := request.GetBotName()
+
+	if err := as.authorize(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	// bots are named like users, but cannot take the name of one
+	var v fieldViolations
+	as.cs.Validation.checkUsername(&v, botName)
+	if err := v.err("Invalid bot name!"); err != nil {
+		return nil, err
+	}
+
+	if as.cs.users.find(botName) != nil {
+		return nil, rpcError(codes.AlreadyExists, gs.ReasonUsernameTaken, "Bot name %s is the name of a user!", botName)
+	}
+	if as.cs.Bots.has(botName) {
+		return nil, rpcError(codes.AlreadyExists, gs.ReasonUsernameTaken, "Bot name %s is the name of a server bot!", botName)
+	}
+
+	token, secret, err := as.cs.webhookTokens.add(botName, sender)
+	if err != nil {
+		return nil, retryableError(codes.Unavailable, gs.ReasonStoreFailure, storeRetryDelay, "Failed to save the webhook token: %v", err)
+	}
+
+	slog.Info("Webhook token created", "user", sender, "target", botName, "token", token.ID)
+	result := token.info()
+	result.Token = &secret
+	return result, nil
+}
+
+// list the incoming webhook tokens, their secrets are never shown again
+func (as *AdminServer) ListWebhookTokens(ctx context.Context, request *gs.UserRequest) (*gs.WebhookTokenList, error) {
+	if err := as.authorize(ctx, request.GetSender()); err != nil {
+		return nil, err
+	}
+
+	return &gs.WebhookTokenList{Tokens: as.cs.webhookTokens.list()}, nil
+}
+
+// revoke an incoming webhook token by its id, it cannot post anymore
+func (as *AdminServer) RevokeWebhookToken(ctx context.Context, request *gs.UserRequest) (*gs.AuthenticationResult, error) {
+	sender := request.GetSender()
+	id := request.GetTarget()
+
+	if err := as.authorize(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	token, err := as.cs.webhookTokens.remove(id)
+	if err != nil {
+		return nil, retryableError(codes.Unavailable, gs.ReasonStoreFailure, storeRetryDelay, "Failed to save the webhook tokens: %v", err)
+	}
+	if token == nil {
+		return nil, rpcError(codes.NotFound, gs.ReasonWebhookTokenNotFound, "Webhook token %s not found!", id)
+	}
+
+	as.cs.mu.Lock()
+	delete(as.cs.botBuckets, token.ID)
+	as.cs.mu.Unlock()
+
+	return adminResult(token.BotName, fmt.Sprintf("Webhook token %s of bot %s revoked by %s", token.ID, token.BotName, sender)), nil
+}
+
+func NewAdminServer(cs *ChatServer) *AdminServer {
+	return &AdminServer{cs: cs}
+}
@@ -0,0 +1,141 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWebhookTokenNames(t *testing.T) {
+	ts := newBotServer(t, BotConfig{Kind: "echo", Name: "echobot"})
+	admin := ts.joinAs(t, "carol", gs.Role_ADMIN)
+	ts.register(t, "alice")
+
+	// a user and a server bot already have these names
+	for _, name := range []string{"alice", "echobot"} {
+		_, err := ts.admin.CreateWebhookToken(admin.ctx, &gs.WebhookTokenRequest{Sender: "carol", BotName: name})
+		if status.Code(err) != codes.AlreadyExists || reasonOf(err) != gs.ReasonUsernameTaken {
+			t.Errorf("webhook bot named %s: %v, want AlreadyExists", name, err)
+		}
+	}
+
+	token, err := ts.admin.CreateWebhookToken(admin.ctx, &gs.WebhookTokenRequest{Sender: "carol", BotName: "deploybot"})
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	if token.GetToken() == "" {
+		t.Errorf("the secret of the new token was not returned")
+	}
+}
+
+func loginError(ts *testServer, username string, password string) error {
+	_, err := ts.client.Login(context.Background(), &gs.UserLoginCredentials{Username: username, Password: password})
+	return err
+}
+
+func TestAdminServiceNeedsAdmin(t *testing.T) {
+	ts := newTestServer(t, nil)
+	alice := ts.joinAs(t, "alice", gs.Role_MODERATOR)
+
+	if _, err := ts.admin.ListUsers(alice.ctx, &gs.UserRequest{Sender: "alice"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("list users as a moderator: %v, want PermissionDenied", err)
+	}
+	if _, err := ts.admin.ListUsers(context.Background(), &gs.UserRequest{Sender: "alice"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("list users without a session: %v, want Unauthenticated", err)
+	}
+}
+
+func TestDisableUser(t *testing.T) {
+	ts := newTestServer(t, nil)
+	admin := ts.joinAs(t, "carol", gs.Role_ADMIN)
+	alice := ts.join(t, "alice")
+
+	target := "alice"
+	if _, err := ts.admin.DisableUser(admin.ctx, &gs.UserRequest{Sender: "carol", Target: &target}); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	alice.expectEnd(t)
+
+	err := loginError(ts, "alice", testPassword)
+	if status.Code(err) != codes.PermissionDenied || reasonOf(err) != gs.ReasonAccountDisabled {
+		t.Fatalf("login of a disabled user: %v, want PermissionDenied", err)
+	}
+
+	if _, err := ts.admin.EnableUser(admin.ctx, &gs.UserRequest{Sender: "carol", Target: &target}); err != nil {
+		t.Fatalf("enable: %v", err)
+	}
+	if err := loginError(ts, "alice", testPassword); err != nil {
+		t.Errorf("login of an enabled user: %v", err)
+	}
+
+	// admins cannot lock themselves out
+	self := "carol"
+	_, err = ts.admin.DisableUser(admin.ctx, &gs.UserRequest{Sender: "carol", Target: &self})
+	if status.Code(err) != codes.FailedPrecondition || reasonOf(err) != gs.ReasonSelfAction {
+		t.Errorf("disable self: %v, want FailedPrecondition", err)
+	}
+}
+
+func TestKickUser(t *testing.T) {
+	ts := newTestServer(t, nil)
+	admin := ts.joinAs(t, "carol", gs.Role_ADMIN)
+	alice := ts.join(t, "alice")
+	phone := ts.connect(t, "alice")
+
+	sessions, err := ts.admin.ListLiveSessions(admin.ctx, &gs.UserRequest{Sender: "carol", Target: &alice.username})
+	if err != nil || len(sessions.GetSession()) != 2 {
+		t.Fatalf("live sessions of alice: %v, %v, want 2", sessions.GetSession(), err)
+	}
+
+	if _, err := ts.admin.KickUser(admin.ctx, &gs.UserRequest{Sender: "carol", Target: &alice.username}); err != nil {
+		t.Fatalf("kick: %v", err)
+	}
+	alice.expectEnd(t)
+	phone.expectEnd(t)
+	admin.expect(t, from("Server", "User alice was kicked from the chat room!"))
+
+	// a kicked user can log in again
+	if err := loginError(ts, "alice", testPassword); err != nil {
+		t.Errorf("login after a kick: %v", err)
+	}
+
+	offline := "dave"
+	ts.register(t, offline)
+	if _, err := ts.admin.KickUser(admin.ctx, &gs.UserRequest{Sender: "carol", Target: &offline}); status.Code(err) != codes.NotFound {
+		t.Errorf("kick of an offline user: %v, want NotFound", err)
+	}
+}
+
+func TestAdminResetPasswordAndRole(t *testing.T) {
+	ts := newTestServer(t, nil)
+	admin := ts.joinAs(t, "carol", gs.Role_ADMIN)
+	alice := ts.join(t, "alice")
+
+	_, err := ts.admin.ResetPassword(admin.ctx, &gs.PasswordResetRequest{Sender: "carol", Target: "alice", NewPassword: "another12"})
+	if err != nil {
+		t.Fatalf("reset password: %v", err)
+	}
+	alice.expectEnd(t)
+	if err := loginError(ts, "alice", testPassword); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("login with the old password: %v, want Unauthenticated", err)
+	}
+	if err := loginError(ts, "alice", "another12"); err != nil {
+		t.Errorf("login with the new password: %v", err)
+	}
+
+	if _, err := ts.admin.SetRole(admin.ctx, &gs.RoleRequest{Sender: "carol", Target: "alice", Role: gs.Role_MODERATOR}); err != nil {
+		t.Fatalf("set role: %v", err)
+	}
+	users, err := ts.admin.ListUsers(admin.ctx, &gs.UserRequest{Sender: "carol"})
+	if err != nil {
+		t.Fatalf("list users: %v", err)
+	}
+	for _, user := range users.GetUser() {
+		if user.GetInfo().GetUsername() == "alice" && user.GetRole() != gs.Role_MODERATOR {
+			t.Errorf("alice is %s, want %s", user.GetRole(), gs.Role_MODERATOR)
+		}
+	}
+}
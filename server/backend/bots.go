@@ -0,0 +1,495 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+)
+
+// events of the room waiting for each bot, newer events are dropped when a bot is that far behind
+const botQueueSize = 256
+
+// an event of the room given to the bots
+type BotEvent struct {
+	// EventMessage, EventPrivateMessage, EventLogin or EventLogout
+	Type string
+	// the user doing the action
+	User string
+	// the recipient of a private message, always the bot receiving it
+	Target  string
+	Message string
+	Time    time.Time
+}
+
+// a bot running inside the server. it gets the messages of the room, the private messages
+// sent to it and the logins and logouts of users, one event at a time
+type Bot interface {
+	// the name the bot posts under, users cannot register it
+	Name() string
+	Handle(event BotEvent, reply BotReply)
+}
+
+// how a bot answers, it can be kept to post later
+type BotReply interface {
+	// post a message to the room
+	Say(message string)
+	// send a private message to an online user
+	Whisper(username string, message string)
+}
+
+// builds a bot from its config
+type BotFactory func(config BotConfig) (Bot, error)
+
+// optional interfaces of a bot
+type (
+	// a line of the !help answer, like "!echo <text>: repeat a message"
+	botHelper interface{ Help() string }
+	// starts the background work of a bot, like posting at fixed times
+	botStarter interface{ Start(reply BotReply) }
+	// stops the background work of a bot when the server shuts down
+	botCloser interface{ Close() }
+)
+
+var (
+	botFactories   = map[string]BotFactory{}
+	botFactoriesMu sync.Mutex
+)
+
+// make a kind of bot available to the "bots" of the config, the built-in kinds are echo, help and reminder
+func RegisterBot(kind string, factory BotFactory) {
+	botFactoriesMu.Lock()
+	defer botFactoriesMu.Unlock()
+	botFactories[kind] = factory
+}
+
+func botFactory(kind string) BotFactory {
+	botFactoriesMu.Lock()
+	defer botFactoriesMu.Unlock()
+	return botFactories[kind]
+}
+
+func init() {
+	RegisterBot("echo", newEchoBot)
+	RegisterBot("help", newHelpBot)
+	RegisterBot("reminder", newReminderBot)
+}
+
+type runningBot struct {
+	bot   Bot
+	queue chan BotEvent
+}
+
+// Runs the bots enabled in the config, each in its own goroutine so a slow bot
+// does not hold the room. a nil *Bots runs nothing
+type Bots struct {
+	cs     *ChatServer
+	bots   map[string]*runningBot
+	closed bool
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+}
+
+// build the bots of the config and start them, they answer through cs
+func NewBots(cs *ChatServer, configs []BotConfig) (*Bots, error) {
+	b := &Bots{
+		cs:   cs,
+		bots: make(map[string]*runningBot),
+	}
+
+	var built []Bot
+	for _, config := range configs {
+		factory := botFactory(config.Kind)
+		if factory == nil {
+			return nil, fmt.Errorf("bot %s: unknown kind %q", config.Name, config.Kind)
+		}
+
+		bot, err := factory(config)
+		if err != nil {
+			return nil, fmt.Errorf("bot %s: %v", config.Name, err)
+		}
+
+		name := strings.ToLower(bot.Name())
+		if _, ok := b.bots[name]; ok {
+			return nil, fmt.Errorf("bot %s is configured twice", bot.Name())
+		}
+		if cs.users.find(bot.Name()) != nil || cs.webhookTokens.hasBot(bot.Name()) {
+			return nil, fmt.Errorf("bot %s has the name of a user", bot.Name())
+		}
+
+		b.bots[name] = &runningBot{bot: bot, queue: make(chan BotEvent, botQueueSize)}
+		built = append(built, bot)
+	}
+
+	// the help bot lists the commands of the others
+	for _, bot := range built {
+		if help, ok := bot.(*helpBot); ok {
+			help.bots = built
+		}
+	}
+
+	for _, running := range b.bots {
+		if starter, ok := running.bot.(botStarter); ok {
+			starter.Start(botReply{cs: cs, name: running.bot.Name()})
+		}
+
+		b.wg.Add(1)
+		go b.run(running)
+	}
+
+	return b, nil
+}
+
+// stop giving events to the bots, wait for the events already queued and stop the bots
+func (b *Bots) Close() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for _, running := range b.bots {
+		close(running.queue)
+	}
+	b.mu.Unlock()
+
+	b.wg.Wait()
+	for _, running := range b.bots {
+		if closer, ok := running.bot.(botCloser); ok {
+			closer.Close()
+		}
+	}
+}
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// check if username is the name of a running bot
+func (b *Bots) has(username string) bool {
+	if b == nil {
+		return false
+	}
+
+	_, ok := b.bots[strings.ToLower(username)]
+	return ok
+}
+
+// the names of the running bots, sorted
+func (b *Bots) names() []string {
+	if b == nil {
+		return nil
+	}
+
+	var names []string
+	for _, running := range b.bots {
+		names = append(names, running.bot.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// queue an event for the bots, private messages only go to the bot they are sent to
+func (b *Bots) post(event BotEvent) {
+	if b == nil {
+		return
+	}
+
+	event.Time = time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	for name, running := range b.bots {
+		if event.Type == EventPrivateMessage && name != strings.ToLower(event.Target) {
+			continue
+		}
+
+		// bots do not get their own messages
+		if strings.EqualFold(event.User, running.bot.Name()) {
+			continue
+		}
+
+		select {
+		case running.queue <- event:
+		default:
+			slog.Warn("Bot is too slow, event dropped", "user", running.bot.Name(), "type", event.Type)
+		}
+	}
+}
+
+// give the events of a bot to it in order, until the bots are closed
+func (b *Bots) run(running *runningBot) {
+	defer b.wg.Done()
+
+	reply := botReply{cs: b.cs, name: running.bot.Name()}
+	for event := range running.queue {
+		b.handle(running.bot, event, reply)
+	}
+}
+
+// a bot failing on an event must not take the server down
+func (b *Bots) handle(bot Bot, event BotEvent, reply BotReply) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Bot failed handling an event", "user", bot.Name(), "type", event.Type, "error", r)
+		}
+	}()
+
+	bot.Handle(event, reply)
+}
+
+// posts the answers of a bot through the chat server
+type botReply struct {
+	cs   *ChatServer
+	name string
+}
+
+func (r botReply) Say(message string) {
+	bot := true
+	slog.LogAttrs(context.Background(), slog.LevelInfo, "Bot message", slog.String("user", r.name), r.cs.messageAttr(message))
+
+	r.cs.mu.Lock()
+	defer r.cs.mu.Unlock()
+	r.cs.broadcast(&gs.ChatMessage{
+		Sender:  r.name,
+		Message: message,
+		Bot:     &bot,
+	}, nil)
+}
+
+func (r botReply) Whisper(username string, message string) {
+	bot := true
+	var private int32 = 1
+
+	r.cs.mu.Lock()
+	defer r.cs.mu.Unlock()
+	if !r.cs.isConnected(username) {
+		slog.Info("Bot private message not sent, user is offline", "user", r.name, "target", username)
+		return
+	}
+
+	id := r.cs.nextMessageID()
+	timestamp := time.Now().Unix()
+	slog.LogAttrs(context.Background(), slog.LevelInfo, "Bot private message", slog.String("user", r.name), slog.String("target", username), r.cs.messageAttr(message))
+	r.cs.sendToUser(username, &gs.ChatMessage{
+		Sender:    r.name,
+		Message:   message,
+		Private:   &private,
+		Bot:       &bot,
+		Id:        &id,
+		Timestamp: &timestamp,
+	}, nil)
+	r.cs.history.add(&historyEntry{ID: id, Sender: r.name, Recipient: username, Message: message, Timestamp: timestamp})
+}
+
+// the command of a message like "!echo hello" and its argument, ok is false for other messages
+func botCommand(message string) (command string, argument string, ok bool) {
+	message = strings.TrimSpace(message)
+	if !strings.HasPrefix(message, "!") {
+		return "", "", false
+	}
+
+	command, argument, _ = strings.Cut(message[1:], " ")
+	return strings.ToLower(command), strings.TrimSpace(argument), command != ""
+}
+
+// answer where the event came from: privately to private messages, to the room otherwise
+func answer(event BotEvent, reply BotReply, message string) {
+	if event.Type == EventPrivateMessage {
+		reply.Whisper(event.User, message)
+	} else {
+		reply.Say(message)
+	}
+}
+
+// ---------------------------------------------------------//
+// ------------------ BUILT-IN BOTS ------------------------//
+
+// repeats "!echo <text>" in the room and every private message sent to it
+type echoBot struct {
+	name string
+}
+
+func newEchoBot(config BotConfig) (Bot, error) {
+	return &echoBot{name: config.Name}, nil
+}
+
+func (b *echoBot) Name() string { return b.name }
+func (b *echoBot) Help() string { return "!echo <text>: repeat a message" }
+
+func (b *echoBot) Handle(event BotEvent, reply BotReply) {
+	switch event.Type {
+	case EventPrivateMessage:
+		reply.Whisper(event.User, event.Message)
+	case EventMessage:
+		if command, text, ok := botCommand(event.Message); ok && command == "echo" && text != "" {
+			reply.Say(text)
+		}
+	}
+}
+
+// answers "!help" with the commands of the running bots
+type helpBot struct {
+	name string
+	bots []Bot
+}
+
+func newHelpBot(config BotConfig) (Bot, error) {
+	return &helpBot{name: config.Name}, nil
+}
+
+func (b *helpBot) Name() string { return b.name }
+func (b *helpBot) Help() string { return "!help: list the bot commands" }
+
+func (b *helpBot) Handle(event BotEvent, reply BotReply) {
+	if event.Type != EventMessage && event.Type != EventPrivateMessage {
+		return
+	}
+
+	if command, _, ok := botCommand(event.Message); !ok || command != "help" {
+		return
+	}
+
+	// one line, chat clients show each message on a single line
+	var lines []string
+	for _, bot := range b.bots {
+		if helper, ok := bot.(botHelper); ok {
+			lines = append(lines, helper.Help())
+		}
+	}
+	answer(event, reply, "Bot commands: "+strings.Join(lines, " | "))
+}
+
+// posts the "message" option to the room "every" duration, like a standup reminder,
+// and reminds users privately with "!remind <duration> <text>"
+type reminderBot struct {
+	name    string
+	every   time.Duration
+	message string
+	timers  map[*time.Timer]bool
+	stop    chan struct{}
+	// no reminder is taken after Close
+	closed bool
+	mu     sync.Mutex
+}
+
+// longest wait of a "!remind" command
+const maxReminderDelay = 7 * 24 * time.Hour
+
+func newReminderBot(config BotConfig) (Bot, error) {
+	b := &reminderBot{
+		name:    config.Name,
+		message: config.Options["message"],
+		timers:  make(map[*time.Timer]bool),
+		stop:    make(chan struct{}),
+	}
+
+	if every := config.Options["every"]; every != "" {
+		d, err := time.ParseDuration(every)
+		if err != nil || d < time.Minute {
+			return nil, fmt.Errorf("option every must be a duration of at least 1m, not %q", every)
+		}
+		if b.message == "" {
+			return nil, fmt.Errorf("option message must be set with every")
+		}
+		b.every = d
+	}
+
+	return b, nil
+}
+
+func (b *reminderBot) Name() string { return b.name }
+func (b *reminderBot) Help() string {
+	return "!remind <duration> <text>: get a private reminder, like !remind 2h review the PR"
+}
+
+// post the announcement until the bot is closed
+func (b *reminderBot) Start(reply BotReply) {
+	if b.every == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(b.every)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				reply.Say(b.message)
+			case <-b.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (b *reminderBot) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+
+	close(b.stop)
+	for timer := range b.timers {
+		timer.Stop()
+		delete(b.timers, timer)
+	}
+}
+
+func (b *reminderBot) Handle(event BotEvent, reply BotReply) {
+	if event.Type != EventMessage && event.Type != EventPrivateMessage {
+		return
+	}
+
+	command, argument, ok := botCommand(event.Message)
+	if !ok || command != "remind" {
+		return
+	}
+
+	delay, text, _ := strings.Cut(argument, " ")
+	text = strings.TrimSpace(text)
+	d, err := time.ParseDuration(delay)
+	if err != nil || d <= 0 || d > maxReminderDelay || text == "" {
+		answer(event, reply, "Usage: !remind <duration> <text>, like !remind 2h review the PR (at most 168h)")
+		return
+	}
+
+	user := event.User
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		b.mu.Lock()
+		_, pending := b.timers[timer]
+		delete(b.timers, timer)
+		b.mu.Unlock()
+
+		if pending {
+			reply.Whisper(user, "Reminder: "+text)
+		}
+	})
+	b.timers[timer] = true
+	b.mu.Unlock()
+
+	answer(event, reply, fmt.Sprintf("OK %s, I will remind you in %s", user, d))
+}
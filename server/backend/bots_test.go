@@ -0,0 +1,218 @@
+package backend
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+)
+
+// start a server running the bots of configs
+func newBotServer(t *testing.T, configs ...BotConfig) *testServer {
+	t.Helper()
+
+	return newTestServer(t, func(cs *ChatServer) {
+		bots, err := NewBots(cs, configs)
+		if err != nil {
+			t.Fatalf("NewBots: %v", err)
+		}
+		cs.Bots = bots
+	})
+}
+
+// a private message from sender with the text message
+func privateFrom(sender string, message string) func(m *gs.ChatMessage) bool {
+	return func(m *gs.ChatMessage) bool {
+		return m.GetPrivate() > 0 && m.GetSender() == sender && m.GetMessage() == message
+	}
+}
+
+func TestEchoBot(t *testing.T) {
+	ts := newBotServer(t, BotConfig{Kind: "echo", Name: "echobot"})
+	alice := ts.join(t, "alice")
+
+	alice.say(t, "!echo hello room")
+	msg := alice.expect(t, from("echobot", "hello room"))
+	if !msg.GetBot() {
+		t.Errorf("echo in the room is not marked as a bot message")
+	}
+
+	_, err := ts.client.SendPrivateMessage(alice.ctx, &gs.PrivateChatMessage{Sender: "alice", Recipent: "echobot", Message: "ping"})
+	if err != nil {
+		t.Fatalf("private message to the bot: %v", err)
+	}
+	alice.expect(t, privateFrom("echobot", "ping"))
+
+	// bots do not answer themselves or other bots
+	alice.expectNone(t, 200*time.Millisecond, from("echobot", "hello room"))
+}
+
+func TestHelpBot(t *testing.T) {
+	ts := newBotServer(t,
+		BotConfig{Kind: "help", Name: "helpbot"},
+		BotConfig{Kind: "echo", Name: "echobot"},
+		BotConfig{Kind: "reminder", Name: "remindbot"},
+	)
+	alice := ts.join(t, "alice")
+
+	alice.say(t, "!help")
+	msg := alice.expect(t, func(m *gs.ChatMessage) bool { return m.GetSender() == "helpbot" })
+	for _, command := range []string{"!help", "!echo <text>", "!remind <duration> <text>"} {
+		if !strings.Contains(msg.GetMessage(), command) {
+			t.Errorf("help %q does not list %s", msg.GetMessage(), command)
+		}
+	}
+
+	// a private !help is answered privately
+	_, err := ts.client.SendPrivateMessage(alice.ctx, &gs.PrivateChatMessage{Sender: "alice", Recipent: "helpbot", Message: "!help"})
+	if err != nil {
+		t.Fatalf("private message to the bot: %v", err)
+	}
+	alice.expect(t, func(m *gs.ChatMessage) bool { return m.GetPrivate() > 0 && m.GetSender() == "helpbot" })
+}
+
+func TestReminderBot(t *testing.T) {
+	ts := newBotServer(t, BotConfig{Kind: "reminder", Name: "remindbot"})
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	_, err := ts.client.SendPrivateMessage(alice.ctx, &gs.PrivateChatMessage{Sender: "alice", Recipent: "remindbot", Message: "!remind 1s stretch"})
+	if err != nil {
+		t.Fatalf("private message to the bot: %v", err)
+	}
+	alice.expect(t, privateFrom("remindbot", "OK alice, I will remind you in 1s"))
+	alice.expect(t, privateFrom("remindbot", "Reminder: stretch"))
+
+	// a wrong command gets the usage, in the room when it was asked in the room
+	bob.say(t, "!remind soon stretch")
+	bob.expect(t, func(m *gs.ChatMessage) bool {
+		return m.GetSender() == "remindbot" && strings.HasPrefix(m.GetMessage(), "Usage:")
+	})
+
+	if _, err := newReminderBot(BotConfig{Name: "r", Options: map[string]string{"every": "10s", "message": "x"}}); err == nil {
+		t.Errorf("an announcement every 10s was accepted")
+	}
+	if _, err := newReminderBot(BotConfig{Name: "r", Options: map[string]string{"every": "1h"}}); err == nil {
+		t.Errorf("an announcement without a message was accepted")
+	}
+}
+
+// counts the answers of a bot
+type countingReply struct{ answers atomic.Int32 }
+
+func (r *countingReply) Say(message string)                      { r.answers.Add(1) }
+func (r *countingReply) Whisper(username string, message string) { r.answers.Add(1) }
+
+func TestReminderBotClose(t *testing.T) {
+	bot, err := newReminderBot(BotConfig{Name: "remindbot"})
+	if err != nil {
+		t.Fatalf("newReminderBot: %v", err)
+	}
+	reply := &countingReply{}
+
+	bot.Handle(BotEvent{Type: EventMessage, User: "alice", Message: "!remind 1h stretch"}, reply)
+	bot.(botCloser).Close()
+	bot.(botCloser).Close()
+
+	// an event still queued when the bot is closed is ignored
+	bot.Handle(BotEvent{Type: EventMessage, User: "alice", Message: "!remind 1h stretch"}, reply)
+	if answers := reply.answers.Load(); answers != 1 {
+		t.Errorf("closed bot answered, %d answers", answers)
+	}
+}
+
+// panics on "!boom", answers "!ping" with "pong"
+type panickyBot struct{ name string }
+
+func (b *panickyBot) Name() string { return b.name }
+
+func (b *panickyBot) Handle(event BotEvent, reply BotReply) {
+	switch event.Message {
+	case "!boom":
+		panic("boom")
+	case "!ping":
+		reply.Say("pong")
+	}
+}
+
+func TestBotPanicIsRecovered(t *testing.T) {
+	RegisterBot("test-panicky", func(config BotConfig) (Bot, error) {
+		return &panickyBot{name: config.Name}, nil
+	})
+
+	ts := newBotServer(t, BotConfig{Kind: "test-panicky", Name: "panicky"}, BotConfig{Kind: "echo", Name: "echobot"})
+	alice := ts.join(t, "alice")
+
+	alice.say(t, "!boom")
+	// the bot and the server keep running
+	alice.say(t, "!ping")
+	alice.expect(t, from("panicky", "pong"))
+	alice.say(t, "!echo still here")
+	alice.expect(t, from("echobot", "still here"))
+}
+
+// blocks on every event until release is closed
+type slowBot struct {
+	name    string
+	release chan struct{}
+	handled atomic.Int32
+}
+
+func (b *slowBot) Name() string { return b.name }
+
+func (b *slowBot) Handle(event BotEvent, reply BotReply) {
+	<-b.release
+	b.handled.Add(1)
+}
+
+func TestBotQueues(t *testing.T) {
+	slow := &slowBot{release: make(chan struct{})}
+	RegisterBot("test-slow", func(config BotConfig) (Bot, error) {
+		slow.name = config.Name
+		return slow, nil
+	})
+
+	ts := newBotServer(t, BotConfig{Kind: "test-slow", Name: "slowbot"}, BotConfig{Kind: "echo", Name: "echobot"})
+	alice := ts.join(t, "alice")
+
+	// each bot has its own queue, the blocked bot does not hold the others
+	alice.say(t, "!echo not blocked")
+	alice.expect(t, from("echobot", "not blocked"))
+
+	// a full queue drops the new events instead of blocking the room
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 2*botQueueSize; i++ {
+			ts.cs.Bots.post(BotEvent{Type: EventMessage, User: "alice", Message: "flood"})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(testTimeout):
+		t.Fatalf("posting to a full bot queue blocked")
+	}
+
+	close(slow.release)
+	ts.cs.Bots.Close()
+	// the blocked event, then at most a full queue
+	if handled := slow.handled.Load(); handled > botQueueSize+2 {
+		t.Errorf("slow bot handled %d events, more than its queue holds", handled)
+	}
+	if handled := slow.handled.Load(); handled == 0 {
+		t.Errorf("slow bot handled no event")
+	}
+}
+
+func TestBotNameCannotBeRegistered(t *testing.T) {
+	ts := newBotServer(t, BotConfig{Kind: "echo", Name: "echobot"})
+
+	_, err := ts.client.Register(context.Background(), &gs.User{Username: "echobot", Password: testPassword, FullName: "Echo"})
+	if err == nil {
+		t.Fatalf("a user registered the name of a bot")
+	}
+}
@@ -3,14 +3,9 @@ package backend
 import (
 	"context"
 	"crypto/rand"
-	"crypto/sha1"
-	"encoding/base64"
 	"encoding/hex"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -19,97 +14,165 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-var hasher = sha1.New()
-
 type MessageLikes struct {
 	whoLike map[string]bool
 	nLike   int
 }
 
 type ChatServer struct {
-	registeredAccount     gs.UserList
-	loggedInAccount       map[string]bool
-	clientStream          map[string]gs.ChatRoom_ChatServer
-	messageLikes          map[string]MessageLikes
-	mu                    sync.Mutex
-	pathToUserCredentials string
+	users              *userStore
+	loggedInAccount    map[string]map[string]*session
+	clientStream       map[string]map[string]gs.ChatRoom_ChatServer
+	messageLikes       map[string]MessageLikes
+	mutedUntil         map[string]time.Time
+	roomLocked         bool
+	recentMessages     map[string]*gs.ChatMessage
+	recentOrder        []string
+	messageSeq         uint64
+	oneTimeCodes       map[codeKey]*oneTimeCode
+	codesSent          map[codeKey][]time.Time
+	loginFailures      map[string]*loginFailures
+	flood              map[string]*floodState
+	webhookTokens      *webhookTokenStore
+	botBuckets         map[string]*tokenBucket
+	scheduled          *scheduleStore
+	history            *messageHistory
+	mentions           *mentionStore
+	mu                 sync.Mutex
+	SessionIdleTimeout time.Duration
+	Validation         ValidationRules
+	// sends verification and password reset codes, nil disables them
+	Mailer Mailer
+	// users with an unverified email cannot login
+	RequireEmailVerification bool
+	// how long verification and password reset codes are valid
+	CodeTTL     time.Duration
+	LoginLimits LoginLimits
+	FloodLimits FloodLimits
+	// current time used to check TOTP codes and deliver scheduled messages, replaceable with a fixed clock
+	Clock func() time.Time
+	// log the length of chat messages instead of their text
+	RedactMessages bool
+	// Prometheus metrics, nil disables them
+	Metrics *Metrics
+	// likes the previous room message of a user needs before they can post again
+	LikesToPost int
+	// room messages remembered so moderators can delete them
+	MaxRecentMessages int
+	// posts chat events to external services, nil disables them
+	Webhooks *Webhooks
+	// bots running inside the server, nil disables them
+	Bots *Bots
 	gs.UnimplementedChatRoomServer
 }
 
 // ---------------------------------------------------------//
 // ------------------ HELPER -------------------------------//
 
-// get the client stream for the given username
-func (cs *ChatServer) getClientStream(username string) gs.ChatRoom_ChatServer {
-	stream, ok := cs.clientStream[username]
-	if ok {
-		return stream
-	} else {
-		return nil
-	}
+// get the client streams (one per session) for the given username
+func (cs *ChatServer) getClientStreams(username string) map[string]gs.ChatRoom_ChatServer {
+	return cs.clientStream[username]
 }
 
 // Check if a peer already connect to server
 func (cs *ChatServer) isConnected(username string) bool {
-	stream, ok := cs.clientStream[username]
-	return ok && stream != nil
+	return len(cs.clientStream[username]) > 0
 }
 
 // Check if a peer is logged in and online
 func (cs *ChatServer) isLoggedIn(username string) bool {
-	online, ok := cs.loggedInAccount[username]
-	return ok && online
+	return len(cs.loggedInAccount[username]) > 0
 }
 
-// Load credentials from a json file
-func (cs *ChatServer) loadUserInformation(filePath string) error {
-	// Read the JSON file
-	jsonData, err := os.ReadFile(filePath)
-	if err != nil {
-		return err
+// user information without the password
+func publicUserInfo(user *gs.User) *gs.PublicUserInfo {
+	return &gs.PublicUserInfo{
+		Username:  user.Username,
+		FullName:  user.FullName,
+		Address:   user.GetAddress(),
+		Birthdate: user.Birthdate,
+		Email:     user.Email,
 	}
+}
 
-	// Unmarshal the JSON data into UserList
+// add the client stream of a session to the connected client stream map
+func (cs *ChatServer) addClientStream(s *session, stream gs.ChatRoom_ChatServer) {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
-	if err := json.Unmarshal(jsonData, &cs.registeredAccount); err != nil {
-		return err
-	}
 
-	return nil
+	if _, ok := cs.clientStream[s.username]; !ok {
+		cs.clientStream[s.username] = make(map[string]gs.ChatRoom_ChatServer)
+	}
+	cs.clientStream[s.username][s.token] = stream
 }
 
-// write the user information to a json file
-func (cs *ChatServer) writeUserInformation(filePath string) error {
-	// Marshal the registeredAccount struct to JSON
+// delete the client stream of a session from the map when cleint is no longer online
+func (cs *ChatServer) removeClientStream(s *session) {
 	cs.mu.Lock()
-	jsonData, err := json.MarshalIndent(&cs.registeredAccount, "", "  ")
-	cs.mu.Unlock()
-	if err != nil {
-		return err
+	defer cs.mu.Unlock()
+
+	streams := cs.clientStream[s.username]
+	delete(streams, s.token)
+	if len(streams) == 0 {
+		delete(cs.clientStream, s.username)
 	}
+}
 
-	// Write the JSON data to the specified file path
-	err = os.WriteFile(filePath, jsonData, 0644)
-	if err != nil {
-		return err
+// send a message to every stream of a user, except the one of the skipped session.
+// cs.mu must be held by the caller
+func (cs *ChatServer) sendToUser(username string, msg *gs.ChatMessage, skip *session) error {
+	var lastErr error
+	for token, recvStream := range cs.clientStream[username] {
+		if skip != nil && token == skip.token {
+			continue
+		}
+
+		if err := recvStream.Send(msg); err != nil {
+			slog.Error("Error sending message", "user", username, "error", err)
+			cs.Metrics.sendError()
+			lastErr = err
+		}
 	}
 
-	return nil
+	return lastErr
 }
 
-// add the client stream to the connected client stream map
-func (cs *ChatServer) addClientStream(username string, stream gs.ChatRoom_ChatServer) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-	cs.clientStream[username] = stream
-}
+// deliver a private message to every device of the recipient, echo it to the other devices of the
+// sender and record it. cs.mu must be held by the caller
+func (cs *ChatServer) deliverPrivateMessage(sender, recipient, message string, origin *session) (id string, timestamp int64, err error) {
+	var private int32 = 1
+	id = cs.nextMessageID()
+	timestamp = time.Now().Unix()
+
+	err = cs.sendToUser(recipient, &gs.ChatMessage{
+		Sender:    sender,
+		Message:   message,
+		Private:   &private,
+		Id:        &id,
+		Timestamp: &timestamp,
+	}, nil)
+
+	cs.sendToUser(sender, &gs.ChatMessage{
+		Sender:    sender,
+		Message:   message,
+		Private:   &private,
+		Recipent:  &recipient,
+		Id:        &id,
+		Timestamp: &timestamp,
+	}, origin)
 
-// delete a client stream from the map when cleint is no longer online
-func (cs *ChatServer) removeClientStream(username string) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-	delete(cs.clientStream, username)
+	if err != nil {
+		return "", 0, err
+	}
+
+	cs.Metrics.privateMessage()
+	cs.history.add(&historyEntry{ID: id, Sender: sender, Recipient: recipient, Message: message, Timestamp: timestamp})
+	if cs.Webhooks.isBotUser(recipient) {
+		cs.Webhooks.post(WebhookEvent{Type: EventPrivateMessage, User: sender, Target: recipient, MessageID: id, Message: message})
+	}
+	cs.Bots.post(BotEvent{Type: EventPrivateMessage, User: sender, Target: recipient, Message: message})
+
+	return id, timestamp, nil
 }
 
 // ---------------------------------------------------------//
@@ -123,17 +186,18 @@ func (cs *ChatServer) Chat(stream gs.ChatRoom_ChatServer) error {
 	*/
 	msg, err := stream.Recv()
 	if err != nil {
-		log.Printf("Error reciving message: %v", err)
+		slog.Error("Error receiving message", "error", err)
 		return err
 	}
 
 	username := msg.GetSender()
-	log.Printf("User %s request to join the chat room!\n", username)
+	slog.Info("Chat room join request", "user", username)
 
-	// check if the user is already connected
-	if !cs.isLoggedIn(username) {
-		log.Printf("Unlogged in user: %s is not permit to chat!!!\n", username)
-		return errors.New("Unlogged in user: " + username + " is not permit to chat!!!")
+	// check if the user is already logged in from this client
+	userSession, err := cs.authenticate(stream.Context(), username)
+	if err != nil {
+		slog.Warn("User is not logged in and cannot chat", "user", username)
+		return err
 	}
 
 	// welcome user to join the chat room
@@ -142,77 +206,165 @@ func (cs *ChatServer) Chat(stream gs.ChatRoom_ChatServer) error {
 		Sender:  "Server",
 	})
 
-	log.Printf("User %s is allowed to join the chat room!\n", username)
-	cs.addClientStream(username, stream)
+	slog.Info("User joined the chat room", "user", username)
+	cs.addClientStream(userSession, stream)
 
 	/*
-		other messages received from client will be used as messages in chat
+		other messages received from client will be used as messages in chat.
+		they are received in background so the session can be ended from outside
 	*/
+	messages := make(chan *gs.ChatMessage)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+
+			select {
+			case messages <- msg:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+
 	for {
-		// Recieve messages from client
-		msg, err = stream.Recv()
-		if err != nil {
+		select {
+		case <-userSession.done:
+			// session was ended by logout, expiry or another login
+			stream.Send(&gs.ChatMessage{
+				Message: "Your session has ended!",
+				Sender:  "Server",
+			})
+			cs.removeClientStream(userSession)
+			return rpcError(codes.Unauthenticated, gs.ReasonSessionEnded, "Your session has ended!")
+
+		case err := <-recvErr:
 			s, _ := status.FromError(err)
 			switch s.Code() {
 			case codes.Canceled:
-				log.Printf("Client offline: %s!\n", username)
-				cs.removeClientStream(username)
-				delete(cs.loggedInAccount, username)
-				return err
+				slog.Info("Client offline", "user", username)
 			default:
-				log.Printf("Error reciving message: %v", err)
-				cs.removeClientStream(username)
-				delete(cs.loggedInAccount, username)
-				return err
+				slog.Error("Error receiving message", "error", err)
 			}
-		}
 
-		/*
-			Check for previous message likes.
-			If previous message are not enough 2 likes, prevent them from sending the message
-		*/
-		log.Printf("Room chat request from %s: %s\n", username, msg.GetMessage())
+			cs.removeClientStream(userSession)
+			cs.mu.Lock()
+			cs.endSession(userSession, "chat stream closed")
+			cs.mu.Unlock()
+			return err
+
+		case msg := <-messages:
+			/*
+				Check for previous message likes.
+				If previous message has not enough likes (LikesToPost), prevent them from sending the message
+			*/
+			slog.LogAttrs(stream.Context(), slog.LevelInfo, "Room chat request", slog.String("user", username), cs.messageAttr(msg.GetMessage()))
+
+			// check role, mute, room lock and flooding before the like gate
+			cs.mu.Lock()
+			rejection := cs.postRejection(username)
+			flooded := rejection == "" && cs.floodRejection(username, msg.GetMessage(), false) != nil
+			needsLikes := cs.messageLikes[username].nLike < cs.LikesToPost
+			cs.mu.Unlock()
+
+			// the sender was already warned, muted or disconnected
+			if flooded {
+				continue
+			}
 
-		if cs.messageLikes[username].nLike < 2 {
-			log.Printf("User %s has not enough likes to send new message to the room!\n", username)
+			if rejection != "" {
+				slog.Info("User cannot send message to the room", "user", username, "reason", rejection)
 
-			err := stream.Send(&gs.ChatMessage{
-				Message: "Get more likes to send messages to room!!!",
-				Sender:  "Server",
-			})
+				err := stream.Send(&gs.ChatMessage{
+					Message: rejection,
+					Sender:  "Server",
+				})
 
-			if err != nil {
-				log.Printf("Error sending message to %s %v\n", username, err)
+				if err != nil {
+					slog.Error("Error sending message", "user", username, "error", err)
+					cs.Metrics.sendError()
+				}
+
+				continue
 			}
 
-			continue
-		}
+			if needsLikes {
+				slog.Info("User has not enough likes to send a message to the room", "user", username)
 
-		// Broadcast message to all other users
-		cs.mu.Lock()
-		cs.broadcast(msg)
-		cs.mu.Unlock()
+				err := stream.Send(&gs.ChatMessage{
+					Message: "Get more likes to send messages to room!!!",
+					Sender:  "Server",
+				})
+
+				if err != nil {
+					slog.Error("Error sending message", "user", username, "error", err)
+					cs.Metrics.sendError()
+				}
+
+				continue
+			}
+
+			// the server picks the id and kind and the sender is the user of the session.
+			// only incoming webhooks and bots post bot messages, which skip the like gate
+			roomMsg := &gs.ChatMessage{
+				Message: msg.GetMessage(),
+				Sender:  username,
+			}
+
+			// Broadcast message to all other users and the other devices of the sender
+			cs.mu.Lock()
+			cs.broadcast(roomMsg, userSession)
+			cs.mu.Unlock()
+		}
 	}
 }
 
-func (cs *ChatServer) broadcast(msg *gs.ChatMessage) {
-	for recipient, recvStream := range cs.clientStream {
-		if recipient != msg.GetSender() {
-			err := recvStream.Send(&gs.ChatMessage{
-				Message: msg.GetMessage(),
-				Sender:  msg.GetSender(),
-			})
+// send a message to every connected stream, except the stream of the origin session (nil for server messages)
+func (cs *ChatServer) broadcast(msg *gs.ChatMessage, origin *session) {
+	timestamp := time.Now().Unix()
+	roomMsg := &gs.ChatMessage{
+		Message:   msg.GetMessage(),
+		Sender:    msg.GetSender(),
+		Id:        msg.Id,
+		Timestamp: &timestamp,
+		Kind:      msg.GetKind(),
+		Bot:       msg.Bot,
+	}
 
-			if err != nil {
-				log.Printf("Error sending message to %s %v\n", recipient, err)
-			}
+	if roomMsg.Id == nil {
+		id := cs.nextMessageID()
+		roomMsg.Id = &id
+	}
+
+	start := time.Now()
+	for recipient := range cs.clientStream {
+		// users do not see public messages of people they blocked
+		if cs.hasBlocked(recipient, msg.GetSender()) {
+			continue
 		}
+
+		cs.sendToUser(recipient, roomMsg, origin)
 	}
+	cs.Metrics.broadcast(start)
 
 	if msg.GetSender() != "Server" {
-		cs.messageLikes[msg.GetSender()] = MessageLikes{
-			nLike:   0,
-			whoLike: make(map[string]bool),
+		cs.rememberMessage(roomMsg)
+		cs.history.add(&historyEntry{ID: roomMsg.GetId(), Sender: roomMsg.Sender, Message: roomMsg.Message, Timestamp: timestamp})
+		cs.notifyMentions(roomMsg)
+		cs.Webhooks.post(WebhookEvent{Type: EventMessage, User: roomMsg.Sender, MessageID: roomMsg.GetId(), Message: roomMsg.Message, Bot: roomMsg.GetBot()})
+
+		// bots answering each other would never stop, and their messages cannot be liked
+		if !roomMsg.GetBot() {
+			cs.Bots.post(BotEvent{Type: EventMessage, User: roomMsg.Sender, Message: roomMsg.Message})
+
+			cs.messageLikes[msg.GetSender()] = MessageLikes{
+				nLike:   0,
+				whoLike: make(map[string]bool),
+			}
 		}
 	}
 }
@@ -222,180 +374,270 @@ func (cs *ChatServer) LikeMessage(ctx context.Context, command *gs.UserRequest)
 	sender := command.GetSender()
 	recipent := command.GetTarget()
 
+	if _, err := cs.authorize(ctx, sender, PermLike); err != nil {
+		return nil, err
+	}
+
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 	timestamp := time.Now().Unix()
 	id := fmt.Sprintf("%d-%s", timestamp, sender)
-	var status codes.Code
-	var err error
 
-	messageLike, _ := cs.messageLikes[recipent]
+	// bots, users who never logged in and deleted accounts have no message to like
+	messageLike, ok := cs.messageLikes[recipent]
+	if !ok || messageLike.whoLike == nil || cs.Bots.has(recipent) || cs.webhookTokens.hasBot(recipent) {
+		slog.Info("No message to like", "user", sender, "target", recipent)
+		return nil, rpcError(codes.NotFound, gs.ReasonMessageNotFound, "User %s has no message to like!", recipent)
+	}
+
 	if messageLike.whoLike[sender] == true {
-		log.Printf("User %s already liked message of %s and cannot like again\n", sender, recipent)
+		slog.Info("User already liked this message", "user", sender, "target", recipent)
 
 		cs.broadcast(&gs.ChatMessage{
 			Message: fmt.Sprintf("%s try to like message of %s but rejected!!", sender, recipent),
 			Sender:  "Server",
-		})
+		}, nil)
 
-		status = codes.AlreadyExists
-		err = errors.New("Duplicated like request")
-	} else {
-		messageLike.nLike++
-		messageLike.whoLike[sender] = true
-		cs.messageLikes[recipent] = messageLike
+		return nil, rpcError(codes.AlreadyExists, gs.ReasonDuplicateLike, "You already liked the message of %s!", recipent)
+	}
 
-		log.Printf("User %s just liked Message of %s\n", sender, recipent)
-		cs.broadcast(&gs.ChatMessage{
-			Message: fmt.Sprintf("%s just liked Message of %s", sender, recipent),
-			Sender:  "Server",
-		})
+	messageLike.nLike++
+	messageLike.whoLike[sender] = true
+	cs.messageLikes[recipent] = messageLike
 
-		status = codes.OK
-		err = nil
-	}
+	slog.Info("User liked a message", "user", sender, "target", recipent)
+	cs.Metrics.like()
+	cs.Webhooks.post(WebhookEvent{Type: EventLike, User: sender, Target: recipent})
+	cs.broadcast(&gs.ChatMessage{
+		Message: fmt.Sprintf("%s just liked Message of %s", sender, recipent),
+		Sender:  "Server",
+	}, nil)
 
 	return &gs.SentMessageStatus{
 		Id:        id,
 		Timestamp: timestamp,
-		Status:    int32(status),
-	}, err
+		Status:    int32(codes.OK),
+	}, nil
 }
 
 // handle private message from client to client
 func (cs *ChatServer) SendPrivateMessage(ctx context.Context, msg *gs.PrivateChatMessage) (*gs.SentMessageStatus, error) {
 
-	log.Printf("%s sent a message to %s: %s\n", msg.Sender, msg.Recipent, msg.Message)
+	slog.LogAttrs(ctx, slog.LevelInfo, "Private message request", slog.String("user", msg.Sender), slog.String("target", msg.Recipent), cs.messageAttr(msg.Message))
 
-	timestamp := time.Now().Unix()
-	id := fmt.Sprintf("%d-%s", timestamp, msg.Sender)
-	status := 1
+	senderSession, err := cs.authorize(ctx, msg.GetSender(), PermPrivateMessage)
+	if err != nil {
+		return nil, err
+	}
 
-	stream, ok := cs.clientStream[msg.Recipent]
-	if !ok {
-		return nil, errors.New(fmt.Sprintf("User %s not found!", msg.Recipent))
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if err := cs.floodRejection(msg.GetSender(), msg.GetMessage(), true); err != nil {
+		return nil, err
 	}
 
-	var private int32 = 1
+	// bots answer through the webhooks or run in the server, they do not need an account or to be online
+	toBot := cs.Webhooks.isBotUser(msg.Recipent) || cs.Bots.has(msg.Recipent)
 
-	err := stream.Send(&gs.ChatMessage{
-		Sender:  msg.GetSender(),
-		Message: msg.GetMessage(),
-		Private: &private,
-	})
+	recipient := cs.users.find(msg.Recipent)
+	if recipient == nil && !toBot {
+		return nil, userNotFoundError(msg.Recipent)
+	}
+
+	if !cs.isConnected(msg.Recipent) && !toBot {
+		return nil, rpcError(codes.FailedPrecondition, gs.ReasonRecipientOffline, "User %s is offline!", msg.Recipent)
+	}
+
+	// the recipient decides who can send them private messages
+	if recipient != nil && !cs.inAudience(recipient, recipient.GetPrivacy().GetPrivateMessages(), msg.GetSender()) {
+		slog.Info("Recipient does not accept private messages from the sender", "user", msg.Sender, "target", msg.Recipent)
+		return nil, rpcError(codes.PermissionDenied, gs.ReasonPrivateMessagesRefused, "User %s does not accept private messages from you!", msg.Recipent)
+	}
 
+	id, timestamp, err := cs.deliverPrivateMessage(msg.GetSender(), msg.Recipent, msg.GetMessage(), senderSession)
 	if err != nil {
-		log.Printf("Failed sending message from %s to %s: %s\n", msg.Sender, msg.Recipent, err.Error())
-	} else {
-		log.Printf("Message sent from %s to %s successfully\n", msg.Sender, msg.Recipent)
+		slog.Error("Failed sending private message", "user", msg.Sender, "target", msg.Recipent, "error", err)
+		return nil, rpcError(codes.Unavailable, gs.ReasonRecipientOffline, "Failed to deliver the message to %s!", msg.Recipent)
 	}
 
+	slog.Info("Private message sent", "user", msg.Sender, "target", msg.Recipent, "id", id)
 	return &gs.SentMessageStatus{
-			Id:        id,
-			Timestamp: timestamp,
-			Status:    int32(status)},
-		err
+		Id:        id,
+		Timestamp: timestamp,
+		Status:    int32(codes.OK),
+	}, nil
 }
 
 // Login to server using registered account (username and password)
 func (cs *ChatServer) Login(ctx context.Context, in *gs.UserLoginCredentials) (*gs.AuthenticationResult, error) {
-	hasher.Write([]byte(in.GetPassword()))
-
-	log.Printf("Login request from %s: %s\n", in.Username, base64.StdEncoding.EncodeToString(hasher.Sum(nil)))
-
 	result := gs.AuthenticationResult{}
 	result.Username = in.Username
+	host := peerHost(ctx)
+	slog.Info("Login request", "user", in.Username, "peer", host)
 
-	// check for user that is already logged in or not
-	if cs.isLoggedIn(in.Username) {
-		msg := fmt.Sprintf("Failed to login as %s: Already login from another place!", in.Username)
-		result.Message = &msg
-		result.Status = int32(codes.AlreadyExists)
+	// too many failed logins for this username or from this address
+	cs.mu.Lock()
+	lockout := cs.loginLockout(in.Username, host)
+	cs.mu.Unlock()
 
-		log.Println(msg)
-		return &result, errors.New(msg)
+	if lockout > 0 {
+		slog.Warn("Login refused, locked out", "user", in.Username, "peer", host, "lockout", lockout)
+		cs.Metrics.login("locked_out")
+		return nil, retryableError(codes.ResourceExhausted, gs.ReasonTooManyAttempts, lockout, "Too many failed logins, try again later!")
 	}
 
 	// allow user to login with correct username and password
-	for _, user := range cs.registeredAccount.User {
-		if user.Username != in.Username {
-			continue
+	user := cs.users.find(in.Username)
+	if user == nil || in.Password != user.Password {
+		cs.mu.Lock()
+		cs.recordLoginFailure(in.Username, host)
+		cs.mu.Unlock()
+
+		// the client gets the same answer in both cases, so usernames cannot be probed
+		if user == nil {
+			slog.Warn("Failed to login, user not found", "user", in.Username, "peer", host)
+		} else {
+			slog.Warn("Failed to login, wrong password", "user", in.Username, "peer", host)
 		}
+		return nil, rpcError(codes.Unauthenticated, gs.ReasonWrongCredentials, "Failed to login: Wrong username or password!")
+	}
 
-		if in.Password != user.Password {
-			msg := fmt.Sprintf("Failed to login as %s: Wrong password!", in.Username)
-			result.Message = &msg
-			result.Status = int32(codes.Unauthenticated)
+	if user.GetDisabled() {
+		slog.Warn("Failed to login, account is disabled", "user", in.Username, "peer", host)
+		return nil, rpcError(codes.PermissionDenied, gs.ReasonAccountDisabled, "Failed to login as %s: Account is disabled!", in.Username)
+	}
 
-			log.Println(msg)
-			return &result, errors.New(msg)
-		} else {
-			// handle successful login
-			cs.loggedInAccount[in.Username] = true
-			cs.messageLikes[in.Username] = MessageLikes{
-				nLike:   2,
-				whoLike: make(map[string]bool),
+	if cs.needsVerification(user) {
+		slog.Warn("Failed to login, email is not verified", "user", in.Username, "peer", host)
+		return nil, rpcError(codes.FailedPrecondition, gs.ReasonEmailNotVerified, "Failed to login as %s: Email is not verified!", in.Username)
+	}
+
+	// second step of the login when two-factor authentication is enabled
+	if user.GetTotpEnabled() {
+		if err := cs.checkSecondFactor(in.Username, in.GetTotpCode()); err != nil {
+			if in.GetTotpCode() != "" {
+				cs.mu.Lock()
+				cs.recordLoginFailure(in.Username, host)
+				cs.mu.Unlock()
 			}
 
-			msg := fmt.Sprintf("User %s has logged in successfully!", in.Username)
-			result.Message = &msg
-			result.Status = int32(codes.OK)
+			slog.Warn("Failed to login", "user", in.Username, "peer", host, "error", err)
+			return nil, err
+		}
+	}
 
-			log.Println(msg)
-			cs.broadcast(&gs.ChatMessage{
-				Message: msg,
-				Sender:  "Server",
-			})
-			return &result, nil
+	// handle successful login, a user may be logged in from several devices at once
+	cs.mu.Lock()
+	cs.resetLoginFailures(in.Username)
+	cs.Metrics.login("success")
+	if in.GetKickOtherSession() {
+		cs.endAllSessions(in.Username, "kicked by a new login")
+	}
+
+	firstSession := !cs.isLoggedIn(in.Username)
+	userSession := newSession(ctx, in.Username)
+	cs.addSession(userSession)
+
+	msg := fmt.Sprintf("User %s has logged in successfully!", in.Username)
+	result.Message = &msg
+	result.Status = int32(codes.OK)
+	result.Token = &userSession.token
+
+	slog.Info("User logged in", "user", in.Username, "session", userSession.id, "peer", userSession.peer)
+	cs.Webhooks.post(WebhookEvent{Type: EventLogin, User: in.Username})
+	cs.Bots.post(BotEvent{Type: EventLogin, User: in.Username})
+	if firstSession {
+		cs.messageLikes[in.Username] = MessageLikes{
+			nLike:   cs.LikesToPost,
+			whoLike: make(map[string]bool),
 		}
+
+		cs.broadcast(&gs.ChatMessage{
+			Message: msg,
+			Sender:  "Server",
+		}, nil)
 	}
+	cs.mu.Unlock()
+	return &result, nil
+}
+
+// Logout from server, ending the session of the calling client
+func (cs *ChatServer) Logout(ctx context.Context, request *gs.UserRequest) (*gs.AuthenticationResult, error) {
+	username := request.GetSender()
+	slog.Info("Logout request", "user", username)
+
+	userSession, err := cs.authenticate(ctx, username)
+	if err != nil {
+		slog.Warn("Failed to logout", "user", username, "error", err)
+		return nil, err
+	}
+
+	cs.mu.Lock()
+	cs.endSession(userSession, "logged out")
+	cs.Webhooks.post(WebhookEvent{Type: EventLogout, User: username})
+	cs.Bots.post(BotEvent{Type: EventLogout, User: username})
+
+	msg := fmt.Sprintf("User %s has logged out!", username)
+	if !cs.isLoggedIn(username) {
+		cs.broadcast(&gs.ChatMessage{
+			Message: msg,
+			Sender:  "Server",
+		}, nil)
+	}
+	cs.mu.Unlock()
 
-	// other cases
-	msg := fmt.Sprintf("Failed to login as %s: User not found!", in.Username)
-	result.Status = int32(codes.Unauthenticated)
-	return &result, errors.New(msg)
+	return &gs.AuthenticationResult{
+		Username: username,
+		Status:   int32(codes.OK),
+		Message:  &msg,
+	}, nil
 }
 
 // handle register new account command from client
 func (cs *ChatServer) Register(ctx context.Context, user *gs.User) (*gs.AuthenticationResult, error) {
-	log.Printf("Register request from %s\n", user.Username)
+	slog.Info("Register request", "user", user.Username)
 
-	result := gs.AuthenticationResult{}
-	result.Username = user.Username
-
-	// Handle blank username or password case
-	if user.Username == "" || user.Password == "" {
-		msg := "Blank username or password is not allowed!"
-		log.Println(msg)
-		return nil, errors.New(msg)
+	// Handle invalid username, password or profile data
+	if err := cs.validateRegistration(user); err != nil {
+		slog.Warn("Register failed", "user", user.Username, "error", err)
+		return nil, err
 	}
 
-	// handle duplicate username case
-	for _, registeredUser := range cs.registeredAccount.User {
-		if registeredUser.Username == user.Username {
-
-			msg := fmt.Sprintf("Username %s is already taken! Register failed", user.Username)
-			authenticateMsg := gs.AuthenticationResult{
-				Username: user.Username,
-				Status:   int32(codes.AlreadyExists),
-				Message:  &msg,
-			}
+	// new accounts always start as enabled members
+	user.Role = gs.Role_MEMBER
+	user.Disabled = nil
+	user.EmailVerified = nil
 
-			log.Println(msg)
-			return &authenticateMsg, errors.New(msg)
-		}
+	// the name of a bot would let the user pass as it
+	err := errUserExists
+	if !cs.webhookTokens.hasBot(user.Username) && !cs.Bots.has(user.Username) {
+		err = cs.users.add(user)
 	}
 
-	// validated case
-	cs.registeredAccount.User = append(cs.registeredAccount.User, user)
-	err := cs.writeUserInformation(cs.pathToUserCredentials)
+	// handle duplicate username case
+	if err == errUserExists {
+		slog.Warn("Register failed, username is already taken", "user", user.Username)
+		return nil, rpcError(codes.AlreadyExists, gs.ReasonUsernameTaken, "Username %s is already taken! Register failed", user.Username)
+	}
 
 	if err == nil {
-		log.Printf("%s was just registered successfully\n", user.Username)
-		return &gs.AuthenticationResult{Username: user.Username, Status: int32(codes.OK)}, nil
+		slog.Info("User registered", "user", user.Username)
+
+		msg := fmt.Sprintf("User %s registered successfully!", user.Username)
+		// nothing is sent when email is not configured
+		if user.Email != nil && cs.Mailer != nil {
+			if err := cs.sendVerificationCode(user); err != nil {
+				msg += " The verification email could not be sent, request a new code later."
+			} else {
+				msg += " A verification code was sent to your email."
+			}
+		}
+
+		return &gs.AuthenticationResult{Username: user.Username, Status: int32(codes.OK), Message: &msg}, nil
 	} else {
-		log.Printf("Register failed: %s\n", err.Error())
-		return &gs.AuthenticationResult{Username: user.Username, Status: int32(codes.Internal)}, err
+		slog.Error("Register failed", "user", user.Username, "error", err)
+		return nil, storeError(err, user.Username)
 	}
 }
 
@@ -404,36 +646,39 @@ func (cs *ChatServer) GetPeerInfomations(ctx context.Context, cmd *gs.UserReques
 	sender := cmd.GetSender()
 	target := cmd.GetTarget()
 
-	log.Printf("%s requested information about %s\n", sender, target)
+	slog.Debug("User information request", "user", sender, "target", target)
 
-	for _, user := range cs.registeredAccount.User {
-		if user.Username == target {
-			result := &gs.PublicUserInfo{
-				Username:  user.Username,
-				FullName:  user.FullName,
-				Address:   user.GetAddress(),
-				Birthdate: user.Birthdate,
-				Email:     user.Email,
-			}
+	if _, err := cs.authorize(ctx, sender, PermViewProfile); err != nil {
+		return nil, err
+	}
 
-			return result, nil
-		}
+	if user := cs.users.find(target); user != nil {
+		return cs.visibleUserInfo(user, sender), nil
 	}
 
-	return nil, errors.New(fmt.Sprintf("User %s not found or offline!", target))
+	if cs.Bots.has(target) {
+		return &gs.PublicUserInfo{Username: target, FullName: "Bot"}, nil
+	}
+
+	return nil, userNotFoundError(target)
 }
 
 func (cs *ChatServer) GetConnectedPeers(ctx context.Context, request *gs.UserRequest) (*gs.PublicUserInfoList, error) {
 	sender := request.GetSender()
 
-	// NOTE: consider log here because of heavy logging
-	/*--*/
-	// log.Printf("%s requested connected users list\n", sender)
-	/*--*/
-	// --------------------------------------------------//
+	// not logged here, the client polls this all the time.
+	// the logging interceptor logs it at debug level
+
+	// the registered usernames are only listed to logged in users
+	if _, err := cs.authenticate(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
 
 	result := &gs.PublicUserInfoList{}
-	for _, user := range cs.registeredAccount.User {
+	for _, user := range cs.users.list() {
 		if user.Username == sender {
 			continue
 		}
@@ -446,6 +691,12 @@ func (cs *ChatServer) GetConnectedPeers(ctx context.Context, request *gs.UserReq
 		}
 	}
 
+	// the bots of the server are always there to talk to
+	for _, bot := range cs.Bots.names() {
+		result.Username = append(result.Username, bot)
+		result.Status = append(result.Status, "Bot")
+	}
+
 	return result, nil
 }
 
@@ -459,14 +710,50 @@ func GenerateSecureToken(length int) string {
 	return hex.EncodeToString(b)
 }
 
+// give a registered user a role, used to bootstrap admins from the command line
+func (cs *ChatServer) GrantRole(username string, role gs.Role) error {
+	return cs.users.update(username, func(user *gs.User) error {
+		user.Role = role
+		return nil
+	})
+}
+
 func NewChatServer(pathToUserCredentials string) *ChatServer {
 	cs := ChatServer{}
-	cs.clientStream = make(map[string]gs.ChatRoom_ChatServer)
-	cs.loggedInAccount = make(map[string]bool)
+	cs.clientStream = make(map[string]map[string]gs.ChatRoom_ChatServer)
+	cs.loggedInAccount = make(map[string]map[string]*session)
 	cs.messageLikes = make(map[string]MessageLikes)
+	cs.mutedUntil = make(map[string]time.Time)
+	cs.recentMessages = make(map[string]*gs.ChatMessage)
+	cs.oneTimeCodes = make(map[codeKey]*oneTimeCode)
+	cs.codesSent = make(map[codeKey][]time.Time)
+	cs.loginFailures = make(map[string]*loginFailures)
+	cs.flood = make(map[string]*floodState)
+	cs.webhookTokens = newWebhookTokenStore("")
+	cs.botBuckets = make(map[string]*tokenBucket)
+	cs.scheduled = newScheduleStore("")
+	cs.history = newMessageHistory()
+	cs.mentions = newMentionStore("")
 	cs.mu = sync.Mutex{}
-	cs.pathToUserCredentials = pathToUserCredentials
-	cs.loadUserInformation(cs.pathToUserCredentials)
+	cs.users = newUserStore(pathToUserCredentials)
+	cs.SessionIdleTimeout = 5 * time.Minute
+	cs.Validation = DefaultValidationRules()
+	cs.CodeTTL = 15 * time.Minute
+	cs.LoginLimits = DefaultLoginLimits()
+	cs.FloodLimits = DefaultFloodLimits()
+	cs.LikesToPost = 2
+	cs.MaxRecentMessages = 1000
+	cs.Clock = time.Now
+	if err := cs.users.load(); err != nil {
+		slog.Error("Failed to load user credentials", "path", pathToUserCredentials, "error", err)
+	}
 
 	return &cs
 }
+
+// start expiring idle sessions and delivering scheduled messages. called once the server is
+// configured, since these jobs use the mailer, webhooks, bots and metrics
+func (cs *ChatServer) Start() {
+	go cs.expireIdleSessions()
+	go cs.deliverScheduled()
+}
@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+)
+
+func TestUserCannotPostBotMessages(t *testing.T) {
+	ts := newTestServer(t, func(cs *ChatServer) { cs.LikesToPost = 2 })
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	bot := true
+	for _, message := range []string{"first", "second"} {
+		if err := alice.stream.Send(&gs.ChatMessage{Sender: "alice", Message: message, Bot: &bot}); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+
+	if msg := bob.expect(t, from("alice", "first")); msg.GetBot() {
+		t.Errorf("a user message was marked as a bot message")
+	}
+
+	// the first message still needs likes like any other
+	alice.expect(t, from("Server", "Get more likes to send messages to room!!!"))
+	bob.expectNone(t, 100*time.Millisecond, from("alice", "second"))
+}
+
+func TestUserCannotSpoofRoomMessages(t *testing.T) {
+	ts := newTestServer(t, nil)
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	bob.say(t, "mine")
+	original := alice.expect(t, from("bob", "mine"))
+
+	// another sender, the id of the message of bob and a deletion frame
+	id := original.GetId()
+	for _, sender := range []string{"bob", "Server"} {
+		err := alice.stream.Send(&gs.ChatMessage{Sender: sender, Message: "spoofed as " + sender, Id: &id, Kind: gs.MessageKind_MESSAGE_DELETED})
+		if err != nil {
+			t.Fatalf("send: %v", err)
+		}
+
+		msg := bob.expect(t, func(m *gs.ChatMessage) bool { return m.GetMessage() == "spoofed as "+sender })
+		if msg.GetSender() != "alice" || msg.GetId() == id || msg.GetKind() != gs.MessageKind_MESSAGE_NORMAL {
+			t.Errorf("room message %v, want a normal message of alice with a new id", msg)
+		}
+	}
+
+	ts.cs.mu.Lock()
+	kept := ts.cs.recentMessages[id]
+	ts.cs.mu.Unlock()
+	if kept.GetSender() != "bob" || kept.GetMessage() != "mine" {
+		t.Errorf("message %s was replaced by %v", id, kept)
+	}
+}
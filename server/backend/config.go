@@ -0,0 +1,533 @@
+package backend
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// prefix of the environment variables overriding the config file
+const configEnvPrefix = "CHAT"
+
+// A time.Duration written as "90s" or "5m" in config files and environment variables
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// where the server listens: a tcp, tcp4 or tcp6 host and port, or a unix socket path
+type ListenerConfig struct {
+	Network string `json:"network"`
+	Address string `json:"address"`
+	Port    int    `json:"port,omitempty"`
+	// octal permissions of a unix socket, 0660 when empty
+	SocketMode string `json:"socketMode,omitempty"`
+}
+
+// the server certificate used on the TCP listeners, TLS is disabled when both files are empty
+type TLSConfig struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+}
+
+type StoreConfig struct {
+	// json file of the registered users
+	Credentials string `json:"credentials"`
+	// json file of the incoming webhook tokens
+	WebhookTokens string `json:"webhookTokens"`
+	// json file of the messages waiting for their delivery time
+	ScheduledMessages string `json:"scheduledMessages"`
+	// JSON lines of the room and private messages, searched with SearchMessages
+	History string `json:"history"`
+	// json file of the unread mentions of every user
+	Mentions string `json:"mentions"`
+}
+
+// emails are sent over SMTP when SMTPAddr is set, otherwise appended to File ("-" for stdout).
+// email is disabled when neither is set
+// the SMTP password is only read from the SMTP_PASSWORD environment variable
+type MailConfig struct {
+	SMTPAddr string `json:"smtpAddr"`
+	SMTPFrom string `json:"smtpFrom"`
+	SMTPUser string `json:"smtpUser"`
+	File     string `json:"file"`
+}
+
+type AccountConfig struct {
+	// usernames given the admin role at startup
+	Admins                   []string `json:"admins"`
+	SessionIdleTimeout       Duration `json:"sessionIdleTimeout"`
+	RequireEmailVerification bool     `json:"requireEmailVerification"`
+	CodeTTL                  Duration `json:"codeTTL"`
+	PasswordMinLength        int      `json:"passwordMinLength"`
+	PasswordRequireUpper     bool     `json:"passwordRequireUpper"`
+	PasswordRequireSymbol    bool     `json:"passwordRequireSymbol"`
+	// added to the built-in reserved usernames
+	ReservedUsernames []string `json:"reservedUsernames"`
+}
+
+type RateLimitConfig struct {
+	// RPCs per second and burst of each client address
+	Requests                float64  `json:"requests"`
+	Burst                   int      `json:"burst"`
+	LoginMaxFailures        int      `json:"loginMaxFailures"`
+	LoginMaxFailuresPerPeer int      `json:"loginMaxFailuresPerPeer"`
+	LoginLockout            Duration `json:"loginLockout"`
+	LoginMaxLockout         Duration `json:"loginMaxLockout"`
+}
+
+type PostingConfig struct {
+	// likes the previous message of a user needs before they can post again
+	LikesToPost         int     `json:"likesToPost"`
+	MessageRate         float64 `json:"messageRate"`
+	MessageBurst        int     `json:"messageBurst"`
+	PrivateMessageRate  float64 `json:"privateMessageRate"`
+	PrivateMessageBurst int     `json:"privateMessageBurst"`
+	// messages of each incoming webhook token
+	BotMessageRate       float64  `json:"botMessageRate"`
+	BotMessageBurst      int      `json:"botMessageBurst"`
+	MaxMessageLength     int      `json:"maxMessageLength"`
+	MaxDuplicateMessages int      `json:"maxDuplicateMessages"`
+	FloodMuteAfter       int      `json:"floodMuteAfter"`
+	FloodMuteDuration    Duration `json:"floodMuteDuration"`
+	FloodDisconnectAfter int      `json:"floodDisconnectAfter"`
+}
+
+type RetentionConfig struct {
+	// room messages remembered so they can be deleted by moderators
+	RecentMessages int `json:"recentMessages"`
+}
+
+type LoggingConfig struct {
+	Dir            string     `json:"dir"`
+	Format         string     `json:"format"`
+	Level          slog.Level `json:"level"`
+	MaxSizeMB      int64      `json:"maxSizeMB"`
+	MaxAge         Duration   `json:"maxAge"`
+	Retention      int        `json:"retention"`
+	RedactMessages bool       `json:"redactMessages"`
+}
+
+// the HTTP endpoints for operations, disabled when empty
+type OpsConfig struct {
+	MetricsAddr string `json:"metricsAddr"`
+	HealthAddr  string `json:"healthAddr"`
+	Reflection  bool   `json:"reflection"`
+}
+
+// the HTTP listener serving gRPC-Web, a WebSocket chat bridge and the REST API, disabled when Addr is empty
+type GatewayConfig struct {
+	Addr string `json:"addr"`
+	// host patterns of the web pages allowed to call the gateway from another origin, like "chat.example.com" or "*"
+	AllowedOrigins []string `json:"allowedOrigins"`
+}
+
+// an URL receiving chat events
+type WebhookConfig struct {
+	URL string `json:"url"`
+	// key of the HMAC-SHA256 signature of the payloads
+	Secret string `json:"secret"`
+	// event types posted to the URL, every type when empty
+	Events []string `json:"events"`
+}
+
+type WebhooksConfig struct {
+	Endpoints []WebhookConfig `json:"endpoints"`
+	// private messages to these users are posted to the webhooks
+	BotUsers    []string `json:"botUsers"`
+	MaxAttempts int      `json:"maxAttempts"`
+	// wait before the first retry, doubled after every failed attempt
+	Backoff Duration `json:"backoff"`
+	Timeout Duration `json:"timeout"`
+	// JSON lines of the events that could not be delivered, in logging.dir when empty
+	DeadLetterFile string `json:"deadLetterFile"`
+}
+
+// a bot running inside the server
+type BotConfig struct {
+	// echo, help, reminder or a kind added with RegisterBot
+	Kind string `json:"kind"`
+	// the name the bot posts under
+	Name string `json:"name"`
+	// settings of the kind, like "every" and "message" of the reminder bot
+	Options map[string]string `json:"options"`
+}
+
+// All the settings of the server, read from a JSON file and CHAT_* environment variables
+type Config struct {
+	Listener ListenerConfig `json:"listener"`
+	// served together with Listener, e.g. a unix socket for local admin tools
+	Listeners []ListenerConfig `json:"listeners"`
+	TLS       TLSConfig        `json:"tls"`
+	Store     StoreConfig      `json:"store"`
+	Mail      MailConfig       `json:"mail"`
+	Accounts  AccountConfig    `json:"accounts"`
+	RateLimit RateLimitConfig  `json:"rateLimit"`
+	Posting   PostingConfig    `json:"posting"`
+	Retention RetentionConfig  `json:"retention"`
+	Logging   LoggingConfig    `json:"logging"`
+	Ops       OpsConfig        `json:"ops"`
+	Gateway   GatewayConfig    `json:"gateway"`
+	Webhooks  WebhooksConfig   `json:"webhooks"`
+	Bots      []BotConfig      `json:"bots"`
+}
+
+// the settings used when nothing else is configured
+func DefaultConfig() Config {
+	validation := DefaultValidationRules()
+	login := DefaultLoginLimits()
+	flood := DefaultFloodLimits()
+
+	return Config{
+		Listener: ListenerConfig{
+			Network: "tcp",
+			Address: "localhost",
+			Port:    55555,
+		},
+		Store: StoreConfig{
+			Credentials:       "db/UserCredentials.json",
+			WebhookTokens:     "db/WebhookTokens.json",
+			ScheduledMessages: "db/ScheduledMessages.json",
+			History:           "db/History.jsonl",
+			Mentions:          "db/Mentions.json",
+		},
+		Accounts: AccountConfig{
+			SessionIdleTimeout:    Duration(5 * time.Minute),
+			CodeTTL:               Duration(15 * time.Minute),
+			PasswordMinLength:     validation.PasswordMinLength,
+			PasswordRequireUpper:  validation.PasswordRequireUpper,
+			PasswordRequireSymbol: validation.PasswordRequireOther,
+		},
+		RateLimit: RateLimitConfig{
+			// each client polls the user list 10 times per second
+			Requests:                50,
+			Burst:                   100,
+			LoginMaxFailures:        login.MaxFailures,
+			LoginMaxFailuresPerPeer: login.MaxFailuresPerPeer,
+			LoginLockout:            Duration(login.BaseLockout),
+			LoginMaxLockout:         Duration(login.MaxLockout),
+		},
+		Posting: PostingConfig{
+			LikesToPost:          2,
+			MessageRate:          flood.PublicRate,
+			MessageBurst:         flood.PublicBurst,
+			PrivateMessageRate:   flood.PrivateRate,
+			PrivateMessageBurst:  flood.PrivateBurst,
+			BotMessageRate:       flood.BotRate,
+			BotMessageBurst:      flood.BotBurst,
+			MaxMessageLength:     flood.MaxMessageLength,
+			MaxDuplicateMessages: flood.MaxDuplicates,
+			FloodMuteAfter:       flood.MuteAfterStrikes,
+			FloodMuteDuration:    Duration(flood.MuteDuration),
+			FloodDisconnectAfter: flood.DisconnectAfterStrikes,
+		},
+		Retention: RetentionConfig{
+			RecentMessages: 1000,
+		},
+		Logging: LoggingConfig{
+			Dir:       "log",
+			Format:    "text",
+			Level:     slog.LevelInfo,
+			MaxSizeMB: 10,
+			MaxAge:    Duration(24 * time.Hour),
+			Retention: 7,
+		},
+		Webhooks: WebhooksConfig{
+			MaxAttempts: 5,
+			Backoff:     Duration(time.Second),
+			Timeout:     Duration(10 * time.Second),
+		},
+	}
+}
+
+// read a JSON config file over c, settings missing from the file are kept
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	return nil
+}
+
+// override settings from environment variables named after their path in the file,
+// e.g. CHAT_LISTENER_PORT or CHAT_RATE_LIMIT_REQUESTS. lists are comma separated
+func (c *Config) LoadEnv() error {
+	return loadEnv(reflect.ValueOf(c).Elem(), configEnvPrefix)
+}
+
+// SCREAMING_SNAKE_CASE of a camelCase json name
+func envName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) && !unicode.IsUpper(rune(name[i-1])) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+func loadEnv(v reflect.Value, prefix string) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		name := prefix + "_" + envName(strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0])
+
+		if field.Kind() == reflect.Struct {
+			if err := loadEnv(field, name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		if err := setFromString(field, value); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// set a config field from its text form
+func setFromString(field reflect.Value, value string) error {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("can only be set in the config file")
+		}
+		field.Set(reflect.ValueOf(SplitList(value)))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+
+	return nil
+}
+
+// the non-empty items of a comma separated list
+func SplitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// check the settings, returning every problem found
+func (c Config) Validate() error {
+	var problems []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			problems = append(problems, fmt.Errorf(format, args...))
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, l := range c.AllListeners() {
+		name := "listener"
+		if i > 0 {
+			name = fmt.Sprintf("listeners[%d]", i-1)
+		}
+
+		switch l.Network {
+		case "tcp", "tcp4", "tcp6":
+			check(l.Port >= 0 && l.Port < 65536, "%s.port must be between 0 and 65535", name)
+		case "unix":
+			check(l.Address != "", "%s.address must be the path of the socket", name)
+			_, err := l.socketMode()
+			check(err == nil, "%s: %v", name, err)
+		default:
+			check(false, "%s.network must be tcp, tcp4, tcp6 or unix, not %q", name, l.Network)
+		}
+
+		check(!seen[l.Network+" "+l.Addr()], "%s: %s is listed twice", name, l.Addr())
+		seen[l.Network+" "+l.Addr()] = true
+	}
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "tls.certFile and tls.keyFile must be set together")
+	for _, file := range []string{c.TLS.CertFile, c.TLS.KeyFile} {
+		if file != "" {
+			_, err := os.Stat(file)
+			check(err == nil, "tls: %v", err)
+		}
+	}
+	check(c.Store.Credentials != "", "store.credentials must be set")
+	check(c.Store.WebhookTokens != "", "store.webhookTokens must be set")
+	check(c.Store.ScheduledMessages != "", "store.scheduledMessages must be set")
+	check(c.Store.History != "", "store.history must be set")
+	check(c.Store.Mentions != "", "store.mentions must be set")
+	check(c.Mail.SMTPAddr == "" || c.Mail.SMTPFrom != "", "mail.smtpFrom must be set with mail.smtpAddr")
+	check(!c.Accounts.RequireEmailVerification || c.Mail.SMTPAddr != "" || c.Mail.File != "", "accounts.requireEmailVerification needs mail.smtpAddr or mail.file")
+
+	check(c.Accounts.SessionIdleTimeout >= 0, "accounts.sessionIdleTimeout cannot be negative")
+	check(c.Accounts.CodeTTL > 0, "accounts.codeTTL must be positive")
+	check(c.Accounts.PasswordMinLength > 0, "accounts.passwordMinLength must be positive")
+
+	check(c.RateLimit.Requests >= 0, "rateLimit.requests cannot be negative")
+	check(c.RateLimit.Requests == 0 || c.RateLimit.Burst > 0, "rateLimit.burst must be positive")
+	check(c.RateLimit.LoginMaxFailures >= 0 && c.RateLimit.LoginMaxFailuresPerPeer >= 0, "rateLimit login failures cannot be negative")
+	check(c.RateLimit.LoginLockout > 0, "rateLimit.loginLockout must be positive")
+	check(c.RateLimit.LoginMaxLockout >= c.RateLimit.LoginLockout, "rateLimit.loginMaxLockout cannot be shorter than rateLimit.loginLockout")
+
+	check(c.Posting.LikesToPost >= 0, "posting.likesToPost cannot be negative")
+	check(c.Posting.MessageRate >= 0 && c.Posting.PrivateMessageRate >= 0, "posting message rates cannot be negative")
+	check(c.Posting.MessageRate == 0 || c.Posting.MessageBurst > 0, "posting.messageBurst must be positive")
+	check(c.Posting.PrivateMessageRate == 0 || c.Posting.PrivateMessageBurst > 0, "posting.privateMessageBurst must be positive")
+	check(c.Posting.BotMessageRate >= 0, "posting.botMessageRate cannot be negative")
+	check(c.Posting.BotMessageRate == 0 || c.Posting.BotMessageBurst > 0, "posting.botMessageBurst must be positive")
+	check(c.Posting.MaxMessageLength >= 0 && c.Posting.MaxDuplicateMessages >= 0, "posting message limits cannot be negative")
+	check(c.Posting.FloodMuteAfter >= 0 && c.Posting.FloodDisconnectAfter >= 0, "posting flood strikes cannot be negative")
+	check(c.Posting.FloodMuteDuration >= 0, "posting.floodMuteDuration cannot be negative")
+
+	check(c.Retention.RecentMessages > 0, "retention.recentMessages must be positive")
+
+	check(c.Logging.Format == "text" || c.Logging.Format == "json", "logging.format must be text or json, not %q", c.Logging.Format)
+	check(c.Logging.Dir != "", "logging.dir must be set")
+	check(c.Logging.MaxSizeMB >= 0 && c.Logging.MaxAge >= 0 && c.Logging.Retention >= 0, "logging rotation settings cannot be negative")
+
+	for i, w := range c.Webhooks.Endpoints {
+		u, err := url.Parse(w.URL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "webhooks.endpoints[%d].url must be an http or https URL", i)
+		check(w.Secret != "", "webhooks.endpoints[%d].secret must be set", i)
+		for _, event := range w.Events {
+			check(webhookEventTypes[event], "webhooks.endpoints[%d]: unknown event %q", i, event)
+		}
+	}
+	check(c.Webhooks.MaxAttempts > 0, "webhooks.maxAttempts must be positive")
+	check(c.Webhooks.Backoff > 0 && c.Webhooks.Timeout > 0, "webhooks.backoff and webhooks.timeout must be positive")
+
+	for i, bot := range c.Bots {
+		check(bot.Name != "", "bots[%d].name must be set", i)
+		check(botFactory(bot.Kind) != nil, "bots[%d]: unknown kind %q", i, bot.Kind)
+	}
+
+	return errors.Join(problems...)
+}
+
+// c with the settings that can change at runtime taken from next,
+// equal to next when nothing else changed
+func (c Config) WithReloadable(next Config) Config {
+	c.Accounts.SessionIdleTimeout = next.Accounts.SessionIdleTimeout
+	c.RateLimit = next.RateLimit
+	c.Posting = next.Posting
+	c.Retention = next.Retention
+	c.Logging.Level = next.Logging.Level
+	return c
+}
+
+// the main listener followed by the additional ones
+func (c Config) AllListeners() []ListenerConfig {
+	return append([]ListenerConfig{c.Listener}, c.Listeners...)
+}
+
+// validation rules of the config
+func (c Config) ValidationRules() ValidationRules {
+	rules := DefaultValidationRules()
+	rules.PasswordMinLength = c.Accounts.PasswordMinLength
+	rules.PasswordRequireUpper = c.Accounts.PasswordRequireUpper
+	rules.PasswordRequireOther = c.Accounts.PasswordRequireSymbol
+	rules.ReservedUsernames = append(rules.ReservedUsernames, c.Accounts.ReservedUsernames...)
+	return rules
+}
+
+func (c Config) loginLimits() LoginLimits {
+	limits := DefaultLoginLimits()
+	limits.MaxFailures = c.RateLimit.LoginMaxFailures
+	limits.MaxFailuresPerPeer = c.RateLimit.LoginMaxFailuresPerPeer
+	limits.BaseLockout = time.Duration(c.RateLimit.LoginLockout)
+	limits.MaxLockout = time.Duration(c.RateLimit.LoginMaxLockout)
+	return limits
+}
+
+func (c Config) floodLimits() FloodLimits {
+	limits := DefaultFloodLimits()
+	limits.PublicRate = c.Posting.MessageRate
+	limits.PublicBurst = c.Posting.MessageBurst
+	limits.PrivateRate = c.Posting.PrivateMessageRate
+	limits.PrivateBurst = c.Posting.PrivateMessageBurst
+	limits.BotRate = c.Posting.BotMessageRate
+	limits.BotBurst = c.Posting.BotMessageBurst
+	limits.MaxMessageLength = c.Posting.MaxMessageLength
+	limits.MaxDuplicates = c.Posting.MaxDuplicateMessages
+	limits.MuteAfterStrikes = c.Posting.FloodMuteAfter
+	limits.MuteDuration = time.Duration(c.Posting.FloodMuteDuration)
+	limits.DisconnectAfterStrikes = c.Posting.FloodDisconnectAfter
+	return limits
+}
+
+// apply every setting of c, before the server starts serving
+func (cs *ChatServer) Configure(c Config) {
+	cs.Validation = c.ValidationRules()
+	cs.RequireEmailVerification = c.Accounts.RequireEmailVerification
+	cs.CodeTTL = time.Duration(c.Accounts.CodeTTL)
+	cs.RedactMessages = c.Logging.RedactMessages
+	cs.Reload(c)
+}
+
+// apply the settings of c that can change while the server is running
+func (cs *ChatServer) Reload(c Config) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.SessionIdleTimeout = time.Duration(c.Accounts.SessionIdleTimeout)
+	cs.LoginLimits = c.loginLimits()
+	cs.FloodLimits = c.floodLimits()
+	cs.LikesToPost = c.Posting.LikesToPost
+	cs.MaxRecentMessages = c.Retention.RecentMessages
+}
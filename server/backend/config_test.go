@@ -0,0 +1,138 @@
+package backend
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// write a config file in a temporary directory
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	return path
+}
+
+func TestEnvName(t *testing.T) {
+	for name, want := range map[string]string{
+		"port":               "PORT",
+		"rateLimit":          "RATE_LIMIT",
+		"sessionIdleTimeout": "SESSION_IDLE_TIMEOUT",
+		"maxSizeMB":          "MAX_SIZE_MB",
+	} {
+		if got := envName(name); got != want {
+			t.Errorf("envName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	cfg := DefaultConfig()
+	path := writeConfig(t, `{"listener": {"port": 6000}, "posting": {"floodMuteDuration": "90s"}, "logging": {"level": "debug"}}`)
+
+	if err := cfg.LoadFile(path); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Listener.Port != 6000 || cfg.Posting.FloodMuteDuration != Duration(90*time.Second) || cfg.Logging.Level != slog.LevelDebug {
+		t.Errorf("settings of the file: %+v %+v %+v", cfg.Listener, cfg.Posting, cfg.Logging)
+	}
+
+	// the settings missing from the file keep their defaults
+	if cfg.Listener.Address != "localhost" || cfg.Posting.LikesToPost != 2 {
+		t.Errorf("defaults were lost: %+v %+v", cfg.Listener, cfg.Posting)
+	}
+
+	// typos are reported instead of being ignored
+	if err := cfg.LoadFile(writeConfig(t, `{"listener": {"prot": 6000}}`)); err == nil || !strings.Contains(err.Error(), "prot") {
+		t.Errorf("unknown setting: %v", err)
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("CHAT_LISTENER_PORT", "7000")
+	t.Setenv("CHAT_RATE_LIMIT_REQUESTS", "2.5")
+	t.Setenv("CHAT_ACCOUNTS_SESSION_IDLE_TIMEOUT", "1h")
+	t.Setenv("CHAT_LOGGING_LEVEL", "warn")
+	t.Setenv("CHAT_LOGGING_REDACT_MESSAGES", "true")
+	t.Setenv("CHAT_GATEWAY_ALLOWED_ORIGINS", "chat.example.com, ,*.example.org")
+
+	cfg := DefaultConfig()
+	if err := cfg.LoadFile(writeConfig(t, `{"listener": {"port": 6000}, "rateLimit": {"requests": 1}}`)); err != nil {
+		t.Fatalf("load file: %v", err)
+	}
+	if err := cfg.LoadEnv(); err != nil {
+		t.Fatalf("load env: %v", err)
+	}
+
+	if cfg.Listener.Port != 7000 || cfg.RateLimit.Requests != 2.5 {
+		t.Errorf("the environment does not override the file: %+v %+v", cfg.Listener, cfg.RateLimit)
+	}
+	if cfg.Accounts.SessionIdleTimeout != Duration(time.Hour) || cfg.Logging.Level != slog.LevelWarn || !cfg.Logging.RedactMessages {
+		t.Errorf("settings of the environment: %+v %+v", cfg.Accounts, cfg.Logging)
+	}
+	if !slices.Equal(cfg.Gateway.AllowedOrigins, []string{"chat.example.com", "*.example.org"}) {
+		t.Errorf("allowed origins: %q", cfg.Gateway.AllowedOrigins)
+	}
+
+	t.Setenv("CHAT_POSTING_LIKES_TO_POST", "many")
+	if err := cfg.LoadEnv(); err == nil || !strings.Contains(err.Error(), "CHAT_POSTING_LIKES_TO_POST") {
+		t.Errorf("invalid number: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("default config: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Listener.Network = "udp"
+	cfg.RateLimit.LoginMaxLockout = Duration(time.Second)
+	cfg.Logging.Format = "xml"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatalf("invalid config was accepted")
+	}
+	for _, problem := range []string{"listener.network", "rateLimit.loginMaxLockout", "logging.format"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("%s is not reported in %q", problem, err)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	current := DefaultConfig()
+	next := DefaultConfig()
+	next.Posting.LikesToPost = 0
+	next.Posting.MaxMessageLength = 50
+	next.RateLimit.LoginMaxFailures = 2
+	next.Accounts.SessionIdleTimeout = Duration(time.Hour)
+
+	// only reloadable settings changed
+	if reloaded := current.WithReloadable(next); !slices.Equal(reloaded.AllListeners(), next.AllListeners()) || reloaded.Posting != next.Posting {
+		t.Errorf("reloadable settings were not taken")
+	}
+
+	next.Listener.Port = 6000
+	if current.WithReloadable(next).Listener.Port != current.Listener.Port {
+		t.Errorf("the listener changed without a restart")
+	}
+
+	cs := NewChatServer(filepath.Join(t.TempDir(), "UserCredentials.json"))
+	cs.Configure(current)
+	cs.Reload(next)
+
+	if cs.LikesToPost != 0 || cs.FloodLimits.MaxMessageLength != 50 || cs.LoginLimits.MaxFailures != 2 || cs.SessionIdleTimeout != time.Hour {
+		t.Errorf("reload: likes %d, length %d, failures %d, idle %v", cs.LikesToPost, cs.FloodLimits.MaxMessageLength, cs.LoginLimits.MaxFailures, cs.SessionIdleTimeout)
+	}
+}
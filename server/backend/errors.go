@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"fmt"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// how long clients should wait before retrying after a credential store failure
+const storeRetryDelay = time.Second
+
+func errorInfo(reason string) *errdetails.ErrorInfo {
+	return &errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: gs.ErrorDomain,
+	}
+}
+
+// a status error carrying an errdetails.ErrorInfo with the reason
+func rpcError(code codes.Code, reason string, format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+
+	st, err := status.New(code, msg).WithDetails(errorInfo(reason))
+	if err != nil {
+		return status.Error(code, msg)
+	}
+
+	return st.Err()
+}
+
+// like rpcError, with an errdetails.RetryInfo telling the client when to try again
+func retryableError(code codes.Code, reason string, retryDelay time.Duration, format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+
+	st, err := status.New(code, msg).WithDetails(errorInfo(reason), &errdetails.RetryInfo{
+		RetryDelay: durationpb.New(retryDelay),
+	})
+	if err != nil {
+		return status.Error(code, msg)
+	}
+
+	return st.Err()
+}
+
+func userNotFoundError(username string) error {
+	return rpcError(codes.NotFound, gs.ReasonUserNotFound, "User %s not found!", username)
+}
+
+// turn an error of the user store into a gRPC status error,
+// status errors returned by update callbacks are kept as they are
+func storeError(err error, username string) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch err {
+	case errUserNotFound:
+		return userNotFoundError(username)
+	default:
+		return retryableError(codes.Unavailable, gs.ReasonStoreFailure, storeRetryDelay, "Failed to update user %s: %v", username, err)
+	}
+}
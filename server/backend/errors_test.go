@@ -0,0 +1,93 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorDetails(t *testing.T) {
+	st := status.Convert(retryableError(codes.ResourceExhausted, gs.ReasonRateLimited, 3*time.Second, "Slow down %s!", "alice"))
+
+	if st.Code() != codes.ResourceExhausted || st.Message() != "Slow down alice!" {
+		t.Errorf("status: %v %q", st.Code(), st.Message())
+	}
+
+	var info *errdetails.ErrorInfo
+	var retry *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.RetryInfo:
+			retry = d
+		}
+	}
+
+	if info.GetDomain() != gs.ErrorDomain || info.GetReason() != gs.ReasonRateLimited {
+		t.Errorf("error info: %v", info)
+	}
+	if retry.GetRetryDelay().AsDuration() != 3*time.Second {
+		t.Errorf("retry info: %v", retry)
+	}
+}
+
+func TestStoreError(t *testing.T) {
+	if err := storeError(errUserNotFound, "alice"); status.Code(err) != codes.NotFound || reasonOf(err) != gs.ReasonUserNotFound {
+		t.Errorf("user not found: %v", err)
+	}
+	if err := storeError(errors.New("disk full"), "alice"); status.Code(err) != codes.Unavailable || reasonOf(err) != gs.ReasonStoreFailure {
+		t.Errorf("write failure: %v", err)
+	}
+
+	// status errors of update callbacks are kept
+	denied := rpcError(codes.PermissionDenied, gs.ReasonPermissionDenied, "no")
+	if err := storeError(denied, "alice"); err != denied {
+		t.Errorf("status error: %v, want %v", err, denied)
+	}
+}
+
+func TestRPCStatusCodes(t *testing.T) {
+	ts := newTestServer(t, nil)
+	alice := ts.join(t, "alice")
+
+	for _, test := range []struct {
+		name   string
+		call   func() error
+		code   codes.Code
+		reason string
+	}{
+		{"wrong password", func() error { return loginError(ts, "alice", "wrong password") }, codes.Unauthenticated, gs.ReasonWrongCredentials},
+		{"username taken", func() error {
+			_, err := ts.client.Register(context.Background(), &gs.User{Username: "alice", Password: testPassword, FullName: "alice"})
+			return err
+		}, codes.AlreadyExists, gs.ReasonUsernameTaken},
+		{"not logged in", func() error {
+			_, err := ts.client.GetSettings(context.Background(), &gs.UserRequest{Sender: "alice"})
+			return err
+		}, codes.Unauthenticated, gs.ReasonNotLoggedIn},
+		{"unknown user", func() error {
+			_, err := ts.client.GetPeerInfomations(alice.ctx, toward("alice", "nobody"))
+			return err
+		}, codes.NotFound, gs.ReasonUserNotFound},
+		{"offline recipient", func() error {
+			ts.register(t, "bob")
+			return whisper(ts, alice, "bob", "hi")
+		}, codes.FailedPrecondition, gs.ReasonRecipientOffline},
+		{"admin only", func() error {
+			_, err := ts.admin.ListUsers(alice.ctx, &gs.UserRequest{Sender: "alice"})
+			return err
+		}, codes.PermissionDenied, gs.ReasonPermissionDenied},
+	} {
+		err := test.call()
+		if status.Code(err) != test.code || reasonOf(err) != test.reason {
+			t.Errorf("%s: %v (%s), want %v (%s)", test.name, status.Code(err), reasonOf(err), test.code, test.reason)
+		}
+	}
+}
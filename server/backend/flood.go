@@ -0,0 +1,175 @@
+package backend
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Limits on the messages a user can send, and what happens when they are broken
+type FloodLimits struct {
+	// messages per second and burst per user, a rate of 0 disables the limit
+	PublicRate   float64
+	PublicBurst  int
+	PrivateRate  float64
+	PrivateBurst int
+	// messages per second and burst of each incoming webhook token
+	BotRate  float64
+	BotBurst int
+	// longest message in characters, 0 for no limit
+	MaxMessageLength int
+	// the same message sent MaxDuplicates times in a row within DuplicateWindow is spam
+	MaxDuplicates   int
+	DuplicateWindow time.Duration
+
+	// every broken limit is a strike: the first strikes get a warning,
+	// then the user is muted, then all their sessions are ended
+	MuteAfterStrikes       int
+	MuteDuration           time.Duration
+	DisconnectAfterStrikes int
+	// strikes older than this are forgotten
+	StrikeWindow time.Duration
+}
+
+// the limits used when nothing else is configured
+func DefaultFloodLimits() FloodLimits {
+	return FloodLimits{
+		PublicRate:       1,
+		PublicBurst:      5,
+		PrivateRate:      2,
+		PrivateBurst:     10,
+		BotRate:          1,
+		BotBurst:         5,
+		MaxMessageLength: 2000,
+		MaxDuplicates:    3,
+		DuplicateWindow:  30 * time.Second,
+
+		MuteAfterStrikes:       3,
+		MuteDuration:           time.Minute,
+		DisconnectAfterStrikes: 5,
+		StrikeWindow:           10 * time.Minute,
+	}
+}
+
+// the messages recently sent by a user
+type floodState struct {
+	public      tokenBucket
+	private     tokenBucket
+	lastMessage string
+	lastSentAt  time.Time
+	duplicates  int
+	strikes     int
+	lastStrike  time.Time
+}
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// check a message against the flood limits, returns the broken limit as an error.
+// cs.mu must be held by the caller
+func (cs *ChatServer) checkFlood(username string, message string, private bool) error {
+	limits := cs.FloodLimits
+	now := time.Now()
+
+	state, ok := cs.flood[username]
+	if !ok {
+		state = &floodState{}
+		cs.flood[username] = state
+	}
+
+	if limits.MaxMessageLength > 0 && len([]rune(message)) > limits.MaxMessageLength {
+		return rpcError(codes.InvalidArgument, gs.ReasonMessageTooLong, "Message is too long, at most %d characters are allowed!", limits.MaxMessageLength)
+	}
+
+	bucket, rate, burst := &state.public, limits.PublicRate, limits.PublicBurst
+	if private {
+		bucket, rate, burst = &state.private, limits.PrivateRate, limits.PrivateBurst
+	}
+
+	if rate > 0 {
+		if ok, wait := bucket.take(rate, burst, now); !ok {
+			return retryableError(codes.ResourceExhausted, gs.ReasonRateLimited, wait, "You are sending messages too fast!")
+		}
+	}
+
+	// the same text over and over again
+	normalized := strings.ToLower(strings.TrimSpace(message))
+	if normalized == state.lastMessage && now.Sub(state.lastSentAt) < limits.DuplicateWindow {
+		state.duplicates++
+	} else {
+		state.duplicates = 1
+	}
+	state.lastMessage = normalized
+	state.lastSentAt = now
+
+	if limits.MaxDuplicates > 0 && state.duplicates >= limits.MaxDuplicates {
+		return rpcError(codes.ResourceExhausted, gs.ReasonSpam, "Please stop repeating the same message!")
+	}
+
+	return nil
+}
+
+// give a user a strike for breaking a flood limit, and warn, mute or disconnect them.
+// cs.mu must be held by the caller
+func (cs *ChatServer) punishFlood(username string, violation error) {
+	limits := cs.FloodLimits
+	now := time.Now()
+
+	state := cs.flood[username]
+	if now.Sub(state.lastStrike) > limits.StrikeWindow {
+		state.strikes = 0
+	}
+	state.strikes++
+	state.lastStrike = now
+
+	reason := status.Convert(violation).Message()
+	slog.Info("User broke a flood limit", "user", username, "strikes", state.strikes, "reason", reason)
+
+	var warning string
+	switch {
+	case limits.DisconnectAfterStrikes > 0 && state.strikes >= limits.DisconnectAfterStrikes:
+		slog.Warn("User disconnected for flooding", "audit", true, "user", username, "strikes", state.strikes)
+		state.strikes = 0
+
+		cs.endAllSessions(username, "flooding")
+		return
+
+	case limits.MuteAfterStrikes > 0 && state.strikes >= limits.MuteAfterStrikes:
+		until := now.Add(limits.MuteDuration)
+		if cs.mutedUntil[username].Before(until) {
+			cs.mutedUntil[username] = until
+		}
+
+		slog.Warn("User muted for flooding", "audit", true, "user", username, "duration", limits.MuteDuration, "strikes", state.strikes)
+		warning = fmt.Sprintf("%s You are muted for %s.", reason, limits.MuteDuration)
+
+	default:
+		warning = fmt.Sprintf("%s Keep going and you will be muted.", reason)
+	}
+
+	cs.sendToUser(username, &gs.ChatMessage{
+		Message: warning,
+		Sender:  "Server",
+		Kind:    gs.MessageKind_MESSAGE_WARNING,
+	}, nil)
+}
+
+// check a message against the flood limits and punish the sender when one is broken,
+// moderators and admins are not limited. cs.mu must be held by the caller
+func (cs *ChatServer) floodRejection(username string, message string, private bool) error {
+	if cs.can(username, PermModerate) {
+		return nil
+	}
+
+	if err := cs.checkFlood(username, message, private); err != nil {
+		cs.punishFlood(username, err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package backend
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// a warning of the server with the text message
+func warning(message string) func(m *gs.ChatMessage) bool {
+	return func(m *gs.ChatMessage) bool {
+		return from("Server", message)(m) && m.GetKind() == gs.MessageKind_MESSAGE_WARNING
+	}
+}
+
+func TestFloodRate(t *testing.T) {
+	ts := newTestServer(t, func(cs *ChatServer) {
+		cs.FloodLimits.PublicRate = 0.001
+		cs.FloodLimits.PublicBurst = 2
+	})
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	for _, message := range []string{"one", "two", "three"} {
+		alice.say(t, message)
+	}
+
+	bob.expect(t, from("alice", "two"))
+	alice.expect(t, warning("You are sending messages too fast! Keep going and you will be muted."))
+	bob.expectNone(t, 100*time.Millisecond, from("alice", "three"))
+}
+
+func TestFloodDuplicates(t *testing.T) {
+	ts := newTestServer(t, func(cs *ChatServer) { cs.FloodLimits.MaxDuplicates = 3 })
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	// case and surrounding spaces do not make a message different
+	for _, message := range []string{"buy now", "Buy now ", "BUY NOW"} {
+		alice.say(t, message)
+	}
+
+	bob.expect(t, from("alice", "Buy now "))
+	alice.expect(t, warning("Please stop repeating the same message! Keep going and you will be muted."))
+	bob.expectNone(t, 100*time.Millisecond, from("alice", "BUY NOW"))
+
+	// another message starts over
+	alice.say(t, "sorry")
+	bob.expect(t, from("alice", "sorry"))
+}
+
+func TestFloodStrikes(t *testing.T) {
+	ts := newTestServer(t, func(cs *ChatServer) {
+		cs.FloodLimits.MaxMessageLength = 10
+		cs.FloodLimits.MuteAfterStrikes = 2
+		cs.FloodLimits.DisconnectAfterStrikes = 3
+	})
+	alice := ts.join(t, "alice")
+	ts.join(t, "bob")
+	long := strings.Repeat("a", 11)
+
+	// first strike: a warning
+	err := whisper(ts, alice, "bob", long)
+	if status.Code(err) != codes.InvalidArgument || reasonOf(err) != gs.ReasonMessageTooLong {
+		t.Fatalf("long message: %v, want InvalidArgument", err)
+	}
+	alice.expect(t, warning("Message is too long, at most 10 characters are allowed! Keep going and you will be muted."))
+
+	// second strike: muted
+	alice.say(t, long)
+	alice.expect(t, warning("Message is too long, at most 10 characters are allowed! You are muted for 1m0s."))
+	alice.say(t, "short")
+	alice.expect(t, serverSays("You are muted until"))
+
+	// third strike: every session is ended
+	whisper(ts, alice, "bob", long)
+	alice.expectEnd(t)
+	expectLoggedOut(t, ts, alice)
+}
+
+func TestStaffAreNotFloodLimited(t *testing.T) {
+	ts := newTestServer(t, func(cs *ChatServer) { cs.FloodLimits.MaxDuplicates = 2 })
+	mod := ts.joinAs(t, "mod", gs.Role_MODERATOR)
+	alice := ts.join(t, "alice")
+
+	for i := 0; i < 3; i++ {
+		mod.say(t, "read the rules")
+		alice.expect(t, from("mod", "read the rules"))
+	}
+}
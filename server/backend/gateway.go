@@ -0,0 +1,272 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"nhooyr.io/websocket"
+)
+
+// path of the WebSocket bridge of the Chat stream
+const chatWebSocketPath = "/chat"
+
+// prefix of the REST endpoints and path of their OpenAPI description
+const restPathPrefix = "/v1/"
+const openAPIPath = "/openapi.json"
+
+// incoming webhooks post to this path followed by their token
+const incomingWebhookPath = "/hooks/"
+
+// longest JSON message accepted from a WebSocket
+const webSocketReadLimit = 64 * 1024
+
+// Serves the ChatRoom service over HTTP without a proxy: gRPC-Web for the unary RPCs,
+// a JSON over WebSocket bridge for the bidirectional Chat stream, REST endpoints for tools
+// and incoming webhooks for bots
+type Gateway struct {
+	cs      *ChatServer
+	grpcWeb *grpcweb.WrappedGrpcServer
+	rest    http.Handler
+	// host patterns of the web pages allowed to call the gateway, like "chat.example.com" or "*"
+	origins []string
+	// applied to the bridged Chat streams like the gRPC server applies them
+	interceptors []grpc.StreamServerInterceptor
+}
+
+func NewGateway(grpcServer *grpc.Server, cs *ChatServer, origins []string, interceptors ...grpc.StreamServerInterceptor) (*Gateway, error) {
+	g := &Gateway{
+		cs:           cs,
+		origins:      origins,
+		interceptors: interceptors,
+	}
+
+	g.grpcWeb = grpcweb.WrapServer(grpcServer, grpcweb.WithOriginFunc(g.originAllowed))
+
+	rest, err := newRESTHandler(grpcServer)
+	if err != nil {
+		return nil, err
+	}
+	g.rest = rest
+
+	return g, nil
+}
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// check the Origin of a browser request against the allowed host patterns
+func (g *Gateway) originAllowed(origin string) bool {
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	for _, pattern := range g.origins {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(u.Host)); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// the request context of a bridged stream, with the session token and the address of the browser
+func webSocketContext(r *http.Request) context.Context {
+	ctx := r.Context()
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		token = r.Header.Get(gs.SessionTokenKey)
+	}
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(gs.SessionTokenKey, token))
+
+	if addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: addr})
+	}
+
+	return ctx
+}
+
+// a grpc.ServerStream sending and receiving protobuf JSON text messages over a WebSocket
+type webSocketStream struct {
+	ctx  context.Context
+	conn *websocket.Conn
+}
+
+func (s *webSocketStream) Context() context.Context     { return s.ctx }
+func (s *webSocketStream) SetHeader(metadata.MD) error  { return nil }
+func (s *webSocketStream) SendHeader(metadata.MD) error { return nil }
+func (s *webSocketStream) SetTrailer(metadata.MD)       {}
+func (s *webSocketStream) SendMsg(m any) error          { return s.send(m.(proto.Message)) }
+func (s *webSocketStream) RecvMsg(m any) error          { return s.recv(m.(proto.Message)) }
+func (s *webSocketStream) send(msg proto.Message) error {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return s.conn.Write(s.ctx, websocket.MessageText, data)
+}
+
+func (s *webSocketStream) recv(msg proto.Message) error {
+	_, data, err := s.conn.Read(s.ctx)
+	if err != nil {
+		// a closed page is a client going offline, like a cancelled gRPC stream
+		var closeErr websocket.CloseError
+		if errors.As(err, &closeErr) || errors.Is(err, io.EOF) || errors.Is(err, context.Canceled) {
+			return status.Error(codes.Canceled, "the browser closed the connection")
+		}
+		return err
+	}
+
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return rpcError(codes.InvalidArgument, gs.ReasonInvalidArgument, "Invalid message: %v", err)
+	}
+
+	return nil
+}
+
+// the typed Chat stream over any grpc.ServerStream
+type chatServerStream struct {
+	grpc.ServerStream
+}
+
+func (s chatServerStream) Send(msg *gs.ChatMessage) error {
+	return s.SendMsg(msg)
+}
+
+func (s chatServerStream) Recv() (*gs.ChatMessage, error) {
+	msg := new(gs.ChatMessage)
+	if err := s.RecvMsg(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// bridge a WebSocket to the Chat stream of the chat server, through the interceptors
+func (g *Gateway) serveChat(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: g.origins})
+	if err != nil {
+		return
+	}
+	conn.SetReadLimit(webSocketReadLimit)
+
+	info := &grpc.StreamServerInfo{
+		FullMethod:     "/" + gs.ChatRoom_ServiceDesc.ServiceName + "/Chat",
+		IsClientStream: true,
+		IsServerStream: true,
+	}
+
+	handler := func(srv any, stream grpc.ServerStream) error {
+		return g.cs.Chat(chatServerStream{stream})
+	}
+	for i := len(g.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := g.interceptors[i], handler
+		handler = func(srv any, stream grpc.ServerStream) error {
+			return interceptor(srv, stream, info, next)
+		}
+	}
+
+	err = handler(g.cs, &webSocketStream{ctx: webSocketContext(r), conn: conn})
+
+	// the reason of a close frame is limited to 123 bytes
+	reason := status.Convert(err).Message()
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+
+	if err != nil && status.Code(err) != codes.Canceled {
+		conn.Close(websocket.StatusPolicyViolation, reason)
+	} else {
+		conn.Close(websocket.StatusNormalClosure, "")
+	}
+}
+
+// post the JSON {"message": "..."} of an external system to the room, as the bot of the token in the path
+func (g *Gateway) serveIncomingWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "expected a POST request", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, webSocketReadLimit)).Decode(&body); err != nil {
+		http.Error(w, `expected a JSON body like {"message": "..."}`, http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: addr})
+	}
+
+	result, err := g.cs.postBotMessage(ctx, strings.TrimPrefix(r.URL.Path, incomingWebhookPath), body.Message)
+	if err != nil {
+		st := status.Convert(err)
+		for _, detail := range st.Details() {
+			if retry, ok := detail.(*errdetails.RetryInfo); ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.GetRetryDelay().AsDuration().Seconds()))))
+			}
+		}
+		http.Error(w, st.Message(), runtime.HTTPStatusFromCode(st.Code()))
+		return
+	}
+
+	data, _ := protojson.Marshal(result)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == chatWebSocketPath:
+		g.serveChat(w, r)
+
+	case strings.HasPrefix(r.URL.Path, incomingWebhookPath):
+		g.serveIncomingWebhook(w, r)
+
+	case strings.HasPrefix(r.URL.Path, restPathPrefix):
+		g.rest.ServeHTTP(w, r)
+
+	case r.URL.Path == openAPIPath:
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(gs.OpenAPISpec)
+
+	// only the ChatRoom service is exposed, not the admin service
+	case !strings.HasPrefix(r.URL.Path, "/"+gs.ChatRoom_ServiceDesc.ServiceName+"/"):
+		http.NotFound(w, r)
+
+	case g.grpcWeb.IsGrpcWebRequest(r) || g.grpcWeb.IsAcceptableGrpcCorsRequest(r):
+		g.grpcWeb.ServeHTTP(w, r)
+
+	default:
+		http.Error(w, "expected a gRPC-Web request", http.StatusUnsupportedMediaType)
+	}
+}
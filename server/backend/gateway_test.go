@@ -0,0 +1,182 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"nhooyr.io/websocket"
+)
+
+// a browser connected to the WebSocket bridge of the Chat stream
+type webSocketChat struct {
+	conn *websocket.Conn
+	ctx  context.Context
+}
+
+// open the Chat stream of the session token over the WebSocket bridge
+func dialChat(t *testing.T, server *httptest.Server, token string) *webSocketChat {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	t.Cleanup(cancel)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + chatWebSocketPath + "?token=" + token
+	conn, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close(websocket.StatusNormalClosure, "") })
+
+	return &webSocketChat{conn: conn, ctx: ctx}
+}
+
+func (c *webSocketChat) send(t *testing.T, msg *gs.ChatMessage) {
+	t.Helper()
+
+	data, _ := protojson.Marshal(msg)
+	if err := c.conn.Write(c.ctx, websocket.MessageText, data); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+}
+
+// wait for a message matching match, the other messages are skipped
+func (c *webSocketChat) expect(t *testing.T, match func(m *gs.ChatMessage) bool) {
+	t.Helper()
+
+	for {
+		_, data, err := c.conn.Read(c.ctx)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+
+		msg := &gs.ChatMessage{}
+		if err := protojson.Unmarshal(data, msg); err != nil {
+			t.Fatalf("message %s: %v", data, err)
+		}
+		if match(msg) {
+			return
+		}
+	}
+}
+
+func TestOriginAllowed(t *testing.T) {
+	g := &Gateway{origins: []string{"chat.example.com", "*.example.org"}}
+
+	for origin, want := range map[string]bool{
+		"":                         true,
+		"https://chat.example.com": true,
+		"https://CHAT.example.com": true,
+		"https://web.example.org":  true,
+		"https://example.org":      false,
+		"https://evil.example.com": false,
+		"http://localhost:8080":    false,
+	} {
+		if got := g.originAllowed(origin); got != want {
+			t.Errorf("origin %q allowed: %v, want %v", origin, got, want)
+		}
+	}
+}
+
+func TestWebSocketChat(t *testing.T) {
+	ts := newTestServer(t, nil)
+	server := newTestGateway(t, ts)
+	alice := ts.join(t, "alice")
+
+	ts.register(t, "bob")
+	bob := dialChat(t, server, tokenOf(ts.login(t, "bob")))
+	bob.send(t, &gs.ChatMessage{Sender: "bob"})
+	bob.expect(t, func(m *gs.ChatMessage) bool { return m.GetSender() == "Server" })
+
+	// the browser and the native client are in the same room
+	bob.send(t, &gs.ChatMessage{Sender: "bob", Message: "hello from the browser"})
+	alice.expect(t, from("bob", "hello from the browser"))
+
+	alice.say(t, "hello from the app")
+	bob.expect(t, from("alice", "hello from the app"))
+}
+
+func TestWebSocketNeedsLogin(t *testing.T) {
+	ts := newTestServer(t, nil)
+	server := newTestGateway(t, ts)
+	ts.register(t, "bob")
+
+	bob := dialChat(t, server, "not-a-token")
+	bob.send(t, &gs.ChatMessage{Sender: "bob"})
+
+	_, _, err := bob.conn.Read(bob.ctx)
+	if websocket.CloseStatus(err) != websocket.StatusPolicyViolation {
+		t.Errorf("chat without a session: %v, want a policy violation", err)
+	}
+}
+
+func TestGRPCWeb(t *testing.T) {
+	ts := newTestServer(t, nil)
+	server := newTestGateway(t, ts)
+	ts.register(t, "alice")
+
+	// a gRPC-Web call is a POST of length-prefixed protobuf messages
+	request, _ := proto.Marshal(&gs.UserLoginCredentials{Username: "alice", Password: testPassword})
+	body := append([]byte{0}, binary.BigEndian.AppendUint32(nil, uint32(len(request)))...)
+	body = append(body, request...)
+
+	response, err := server.Client().Post(server.URL+"/grpcService.ChatRoom/Login", "application/grpc-web+proto", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer response.Body.Close()
+
+	data, _ := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK || len(data) < 5 || data[0] != 0 {
+		t.Fatalf("response: HTTP %d %q", response.StatusCode, data)
+	}
+
+	result := &gs.AuthenticationResult{}
+	if err := proto.Unmarshal(data[5:5+binary.BigEndian.Uint32(data[1:5])], result); err != nil {
+		t.Fatalf("result: %v", err)
+	}
+	if result.GetToken() == "" {
+		t.Errorf("login over gRPC-Web returned no session token: %v", result)
+	}
+}
+
+func TestGatewayRoutes(t *testing.T) {
+	ts := newTestServer(t, nil)
+	server := newTestGateway(t, ts)
+
+	for _, test := range []struct {
+		method      string
+		path        string
+		contentType string
+		want        int
+	}{
+		{http.MethodGet, openAPIPath, "", http.StatusOK},
+		// the admin service is not reachable from browsers
+		{http.MethodPost, "/grpcService.ChatAdmin/ListUsers", "application/grpc-web+proto", http.StatusNotFound},
+		{http.MethodPost, "/grpcService.ChatRoom/Login", "application/json", http.StatusUnsupportedMediaType},
+		{http.MethodGet, "/hooks/some-token", "", http.StatusMethodNotAllowed},
+	} {
+		request, _ := http.NewRequest(test.method, server.URL+test.path, nil)
+		if test.contentType != "" {
+			request.Header.Set("Content-Type", test.contentType)
+		}
+
+		response, err := server.Client().Do(request)
+		if err != nil {
+			t.Fatalf("%s %s: %v", test.method, test.path, err)
+		}
+		response.Body.Close()
+
+		if response.StatusCode != test.want {
+			t.Errorf("%s %s: HTTP %d, want %d", test.method, test.path, response.StatusCode, test.want)
+		}
+	}
+}
@@ -0,0 +1,391 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+)
+
+// results of a search when the request does not set a limit, and the most it can ask for
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+// a room or private message kept for searching, written as a JSON line.
+// a line with Deleted set removes the message with the same id
+type historyEntry struct {
+	ID     string `json:"id"`
+	Sender string `json:"sender,omitempty"`
+	// empty for a room message
+	Recipient string `json:"recipient,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+	Deleted   bool   `json:"deleted,omitempty"`
+}
+
+func (e *historyEntry) chatMessage() *gs.ChatMessage {
+	msg := &gs.ChatMessage{
+		Sender:    e.Sender,
+		Message:   e.Message,
+		Id:        &e.ID,
+		Timestamp: &e.Timestamp,
+	}
+	if e.Recipient != "" {
+		var private int32 = 1
+		msg.Private = &private
+		msg.Recipent = &e.Recipient
+	}
+
+	return msg
+}
+
+// the messages sent in the chat, with an inverted index of their words.
+// appended to a JSON lines file, kept in memory only when there is no file
+type messageHistory struct {
+	entries []*historyEntry
+	byID    map[string]int
+	// positions in entries of the messages containing a word, in increasing order
+	index map[string][]int
+	file  *os.File
+	mu    sync.Mutex
+}
+
+func newMessageHistory() *messageHistory {
+	return &messageHistory{
+		byID:  make(map[string]int),
+		index: make(map[string][]int),
+	}
+}
+
+// the lowercase words of a text, letters and digits separated by anything else
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// add an entry to the memory and the index. h.mu must be held by the caller
+func (h *messageHistory) insert(e *historyEntry) {
+	if e.Deleted {
+		if position, ok := h.byID[e.ID]; ok {
+			h.entries[position].Deleted = true
+		}
+		return
+	}
+
+	position := len(h.entries)
+	h.entries = append(h.entries, e)
+	h.byID[e.ID] = position
+
+	seen := make(map[string]bool)
+	for _, word := range searchWords(e.Message) {
+		if !seen[word] {
+			seen[word] = true
+			h.index[word] = append(h.index[word], position)
+		}
+	}
+}
+
+// write an entry to the file. h.mu must be held by the caller
+func (h *messageHistory) write(e *historyEntry) {
+	if h.file == nil {
+		return
+	}
+
+	line, _ := json.Marshal(e)
+	if _, err := h.file.Write(append(line, '\n')); err != nil {
+		slog.Error("Failed to write the message history", "error", err)
+	}
+}
+
+// read the entries of a JSON lines file and keep appending to it, a missing file is created.
+// returns the highest numeric message id found
+func (h *messageHistory) open(path string) (uint64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var lastID uint64
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return 0, err
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		// a line cut by a crash of the server is skipped
+		e := &historyEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), e); err != nil {
+			slog.Warn("Skipping an invalid line of the message history", "path", path, "line", line, "error", err)
+			continue
+		}
+
+		h.insert(e)
+		if n, err := strconv.ParseUint(e.ID, 10, 64); err == nil && n > lastID {
+			lastID = n
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return 0, err
+	}
+
+	h.file = file
+	return lastID, nil
+}
+
+func (h *messageHistory) add(e *historyEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.insert(e)
+	h.write(e)
+}
+
+func (h *messageHistory) remove(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.byID[id]; !ok {
+		return
+	}
+
+	e := &historyEntry{ID: id, Deleted: true}
+	h.insert(e)
+	h.write(e)
+}
+
+// remove the private messages a user sent or received, so a new account with the same
+// name cannot find them. their room messages were public and are kept
+func (h *messageHistory) removePrivate(username string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, entry := range h.entries {
+		if entry.Deleted || entry.Recipient == "" || (entry.Sender != username && entry.Recipient != username) {
+			continue
+		}
+
+		e := &historyEntry{ID: entry.ID, Deleted: true}
+		h.insert(e)
+		h.write(e)
+	}
+}
+
+// the words and the "quoted phrases" of a search query
+func parseQuery(query string) (words []string, phrases [][]string) {
+	for i, part := range strings.Split(query, `"`) {
+		// the parts between quotes are at odd positions
+		if i%2 == 1 {
+			if phrase := searchWords(part); len(phrase) > 0 {
+				phrases = append(phrases, phrase)
+			}
+			continue
+		}
+
+		words = append(words, searchWords(part)...)
+	}
+
+	return words, phrases
+}
+
+// check if the words contain the phrase, in order and next to each other
+func containsPhrase(words []string, phrase []string) bool {
+	for start := 0; start+len(phrase) <= len(words); start++ {
+		match := true
+		for i, word := range phrase {
+			if words[start+i] != word {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			return true
+		}
+	}
+
+	return false
+}
+
+// the positions of the entries containing every word, in increasing order
+func (h *messageHistory) candidates(words []string) []int {
+	var result []int
+	for i, word := range words {
+		positions := h.index[word]
+		if i == 0 {
+			result = positions
+			continue
+		}
+
+		// both lists are sorted
+		var both []int
+		for a, b := 0, 0; a < len(result) && b < len(positions); {
+			switch {
+			case result[a] < positions[b]:
+				a++
+			case result[a] > positions[b]:
+				b++
+			default:
+				both = append(both, result[a])
+				a++
+				b++
+			}
+		}
+		result = both
+	}
+
+	return result
+}
+
+// the messages matching a query and the filters, newest first
+func (h *messageHistory) search(query string, limit int, keep func(e *historyEntry) bool) []*historyEntry {
+	words, phrases := parseQuery(query)
+	for _, phrase := range phrases {
+		words = append(words, phrase...)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	positions := h.candidates(words)
+
+	var result []*historyEntry
+	for i := len(positions) - 1; i >= 0 && len(result) < limit; i-- {
+		e := h.entries[positions[i]]
+		if e.Deleted || !keep(e) {
+			continue
+		}
+
+		matches := true
+		if len(phrases) > 0 {
+			messageWords := searchWords(e.Message)
+			for _, phrase := range phrases {
+				if !containsPhrase(messageWords, phrase) {
+					matches = false
+					break
+				}
+			}
+		}
+
+		if matches {
+			result = append(result, e)
+		}
+	}
+
+	return result
+}
+
+// load the message history from a JSON lines file and append the new messages to it,
+// the history is only kept in memory until this is called
+func (cs *ChatServer) LoadHistory(path string) error {
+	history := newMessageHistory()
+	lastID, err := history.open(path)
+	if err != nil {
+		return err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.history = history
+	// message ids go on from the ones in the history
+	if lastID > cs.messageSeq {
+		cs.messageSeq = lastID
+	}
+	return nil
+}
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// check if a user can see a message of the history: every room message, and the private
+// messages they sent or received. cs.mu must be held by the caller
+func (cs *ChatServer) canSeeInHistory(username string, e *historyEntry) bool {
+	if e.Recipient == "" {
+		return !cs.hasBlocked(username, e.Sender)
+	}
+
+	return e.Sender == username || e.Recipient == username
+}
+
+// ---------------------------------------------------------//
+// ------------------------- RPC ---------------------------//
+
+// find the messages visible to the calling user matching a query, newest first
+func (cs *ChatServer) SearchMessages(ctx context.Context, request *gs.SearchRequest) (*gs.SearchResults, error) {
+	sender := request.GetSender()
+	slog.Info("Search request", "user", sender)
+
+	if _, err := cs.authenticate(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	var v fieldViolations
+	if len(searchWords(request.GetQuery())) == 0 {
+		v.add("query", "must contain a word")
+	}
+	if request.GetSince() > 0 && request.GetUntil() > 0 && request.GetSince() > request.GetUntil() {
+		v.add("until", "must be after since")
+	}
+	if request.GetLimit() < 0 {
+		v.add("limit", "cannot be negative")
+	}
+	if request.GetRoomOnly() && request.GetPeer() != "" {
+		v.add("peer", "cannot be set with room_only")
+	}
+	if err := v.err("Invalid search!"); err != nil {
+		return nil, err
+	}
+
+	limit := int(request.GetLimit())
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	limit = min(limit, maxSearchLimit)
+
+	keep := func(e *historyEntry) bool {
+		if !cs.canSeeInHistory(sender, e) {
+			return false
+		}
+		if request.From != nil && e.Sender != request.GetFrom() {
+			return false
+		}
+		if request.GetRoomOnly() && e.Recipient != "" {
+			return false
+		}
+		if peer := request.GetPeer(); peer != "" {
+			// the private conversation of the sender with peer
+			if e.Recipient == "" || !(e.Sender == peer || e.Recipient == peer) {
+				return false
+			}
+		}
+		if request.GetSince() > 0 && e.Timestamp < request.GetSince() {
+			return false
+		}
+		if request.GetUntil() > 0 && e.Timestamp > request.GetUntil() {
+			return false
+		}
+		return true
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	result := &gs.SearchResults{}
+	for _, e := range cs.history.search(request.GetQuery(), limit, keep) {
+		result.Messages = append(result.Messages, e.chatMessage())
+	}
+
+	slog.Debug("Search done", "user", sender, "results", len(result.Messages))
+	return result, nil
+}
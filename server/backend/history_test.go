@@ -0,0 +1,179 @@
+package backend
+
+import (
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+)
+
+// the texts of the messages chat finds with request, newest first
+func search(t *testing.T, ts *testServer, chat *testChat, request *gs.SearchRequest) []string {
+	t.Helper()
+
+	request.Sender = chat.username
+	results, err := ts.client.SearchMessages(chat.ctx, request)
+	if err != nil {
+		t.Fatalf("%s searches %q: %v", chat.username, request.GetQuery(), err)
+	}
+
+	var texts []string
+	for _, msg := range results.GetMessages() {
+		texts = append(texts, msg.GetMessage())
+	}
+	return texts
+}
+
+func TestParseQuery(t *testing.T) {
+	words, phrases := parseQuery(`Deploy "release v2" tonight "" "Go-Live`)
+
+	if !slices.Equal(words, []string{"deploy", "tonight"}) {
+		t.Errorf("words: %q", words)
+	}
+	if len(phrases) != 2 || !slices.Equal(phrases[0], []string{"release", "v2"}) || !slices.Equal(phrases[1], []string{"go", "live"}) {
+		t.Errorf("phrases: %q", phrases)
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	h := newMessageHistory()
+	for i, message := range []string{"the release is ready", "ready for the release?", "release notes", "Ready, set, go!"} {
+		h.add(&historyEntry{ID: string(rune('a' + i)), Sender: "alice", Message: message})
+	}
+	h.remove("b")
+
+	everything := func(e *historyEntry) bool { return true }
+	texts := func(entries []*historyEntry) []string {
+		var result []string
+		for _, e := range entries {
+			result = append(result, e.Message)
+		}
+		return result
+	}
+
+	for _, test := range []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		// every word must match, in any order and case, and deleted messages are gone
+		{"READY release", 10, []string{"the release is ready"}},
+		{"ready", 10, []string{"Ready, set, go!", "the release is ready"}},
+		{"ready", 1, []string{"Ready, set, go!"}},
+		{`"release notes"`, 10, []string{"release notes"}},
+		{`"ready release"`, 10, nil},
+		{"missing", 10, nil},
+	} {
+		if got := texts(h.search(test.query, test.limit, everything)); !slices.Equal(got, test.want) {
+			t.Errorf("search %q: %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestSearchPrivacy(t *testing.T) {
+	ts := newTestServer(t, nil)
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+	carol := ts.join(t, "carol")
+
+	if err := whisper(ts, alice, "bob", "the secret plan"); err != nil {
+		t.Fatalf("whisper: %v", err)
+	}
+	bob.say(t, "the public plan")
+	carol.expect(t, from("bob", "the public plan"))
+
+	// private messages are only found by the people who sent and received them
+	if got := search(t, ts, bob, &gs.SearchRequest{Query: "plan"}); !slices.Equal(got, []string{"the public plan", "the secret plan"}) {
+		t.Errorf("bob finds %q", got)
+	}
+	if got := search(t, ts, alice, &gs.SearchRequest{Query: "plan"}); !slices.Equal(got, []string{"the public plan", "the secret plan"}) {
+		t.Errorf("alice finds %q", got)
+	}
+	if got := search(t, ts, carol, &gs.SearchRequest{Query: "plan"}); !slices.Equal(got, []string{"the public plan"}) {
+		t.Errorf("carol finds %q", got)
+	}
+
+	// room messages of blocked users are hidden like in the room
+	if _, err := ts.client.BlockUser(carol.ctx, toward("carol", "bob")); err != nil {
+		t.Fatalf("block: %v", err)
+	}
+	if got := search(t, ts, carol, &gs.SearchRequest{Query: "plan"}); len(got) != 0 {
+		t.Errorf("carol finds %q after blocking bob", got)
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	ts := newTestServer(t, nil)
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+	ts.join(t, "carol")
+
+	alice.say(t, "lunch at noon")
+	bob.expect(t, from("alice", "lunch at noon"))
+	for _, recipient := range []string{"bob", "carol"} {
+		if err := whisper(ts, alice, recipient, "lunch with "+recipient); err != nil {
+			t.Fatalf("whisper: %v", err)
+		}
+	}
+
+	sender, peer := "alice", "bob"
+	for _, test := range []struct {
+		request *gs.SearchRequest
+		want    []string
+	}{
+		{&gs.SearchRequest{Query: "lunch", From: &sender}, []string{"lunch with carol", "lunch with bob", "lunch at noon"}},
+		{&gs.SearchRequest{Query: "lunch", RoomOnly: true}, []string{"lunch at noon"}},
+		{&gs.SearchRequest{Query: "lunch", Peer: &peer}, []string{"lunch with bob"}},
+		{&gs.SearchRequest{Query: "lunch", Limit: 2}, []string{"lunch with carol", "lunch with bob"}},
+	} {
+		if got := search(t, ts, alice, test.request); !slices.Equal(got, test.want) {
+			t.Errorf("search %v: %q, want %q", test.request, got, test.want)
+		}
+	}
+
+	_, err := ts.client.SearchMessages(alice.ctx, &gs.SearchRequest{Sender: "alice", Query: "?!", Since: 20, Until: 10, Limit: -1, RoomOnly: true, Peer: &peer})
+	if fields := badRequestFields(err); !slices.Equal(fields, []string{"query", "until", "limit", "peer"}) {
+		t.Errorf("invalid search: %v, fields %v", err, fields)
+	}
+}
+
+func TestHistoryIsSaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "History.jsonl")
+	load := func(cs *ChatServer) {
+		if err := cs.LoadHistory(path); err != nil {
+			t.Fatalf("load history: %v", err)
+		}
+	}
+
+	ts := newTestServer(t, load)
+	mod := ts.joinAs(t, "mod", gs.Role_MODERATOR)
+	alice := ts.join(t, "alice")
+
+	alice.say(t, "first words")
+	alice.say(t, "oops wrong words")
+	mod.expect(t, from("alice", "first words"))
+	oops := mod.expect(t, from("alice", "oops wrong words"))
+	if _, err := ts.client.DeleteMessage(mod.ctx, toward("mod", oops.GetId())); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	ts.cs.Shutdown()
+	ts.grpc.Stop()
+
+	// a restarted server finds the old messages, not the deleted one, and does not reuse their ids
+	restarted := newTestServer(t, load)
+	alice = restarted.join(t, "alice")
+	if got := search(t, restarted, alice, &gs.SearchRequest{Query: "words"}); !slices.Equal(got, []string{"first words"}) {
+		t.Errorf("after a restart: %q", got)
+	}
+
+	last, _ := strconv.ParseUint(oops.GetId(), 10, 64)
+	restarted.cs.mu.Lock()
+	next := restarted.cs.messageSeq
+	restarted.cs.mu.Unlock()
+	if next < last {
+		t.Errorf("message ids start again at %d, the history has %d", next, last)
+	}
+}
@@ -0,0 +1,238 @@
+package backend
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+)
+
+// an incoming webhook token as it is stored, only a hash of its secret is kept
+type incomingToken struct {
+	ID         string `json:"id"`
+	BotName    string `json:"botName"`
+	SecretHash string `json:"secretHash"`
+	CreatedBy  string `json:"createdBy"`
+	CreatedAt  int64  `json:"createdAt"`
+}
+
+func (t *incomingToken) info() *gs.WebhookToken {
+	return &gs.WebhookToken{
+		Id:        t.ID,
+		BotName:   t.BotName,
+		CreatedBy: t.CreatedBy,
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+// incoming webhook tokens, persisted to a json file (kept in memory when the path is empty)
+type webhookTokenStore struct {
+	tokens []*incomingToken
+	path   string
+	mu     sync.Mutex
+}
+
+func newWebhookTokenStore(path string) *webhookTokenStore {
+	return &webhookTokenStore{path: path}
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// load the tokens from the json file, a missing file is an empty store
+func (ts *webhookTokenStore) load() error {
+	data, err := os.ReadFile(ts.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return json.Unmarshal(data, &ts.tokens)
+}
+
+// write the tokens to the json file. ts.mu must be held by the caller
+func (ts *webhookTokenStore) save() error {
+	if ts.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(ts.tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(ts.path, data, 0600)
+}
+
+// create a token for botName and persist the store, returns the token to give to its owner
+func (ts *webhookTokenStore) add(botName string, createdBy string) (*incomingToken, string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	secret := GenerateSecureToken(24)
+	token := &incomingToken{
+		ID:         GenerateSecureToken(6),
+		BotName:    botName,
+		SecretHash: hashSecret(secret),
+		CreatedBy:  createdBy,
+		CreatedAt:  time.Now().Unix(),
+	}
+
+	ts.tokens = append(ts.tokens, token)
+	if err := ts.save(); err != nil {
+		ts.tokens = ts.tokens[:len(ts.tokens)-1]
+		return nil, "", err
+	}
+
+	return token, token.ID + "." + secret, nil
+}
+
+// revoke a token by its id and persist the store, nil if there is no such token
+func (ts *webhookTokenStore) remove(id string) (*incomingToken, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	for index, token := range ts.tokens {
+		if token.ID != id {
+			continue
+		}
+
+		previous := ts.tokens
+		ts.tokens = append(append([]*incomingToken{}, ts.tokens[:index]...), ts.tokens[index+1:]...)
+		if err := ts.save(); err != nil {
+			ts.tokens = previous
+			return nil, err
+		}
+		return token, nil
+	}
+
+	return nil, nil
+}
+
+// the stored token matching a token given by a client, nil if it is unknown or revoked
+func (ts *webhookTokenStore) find(given string) *incomingToken {
+	id, secret, ok := strings.Cut(given, ".")
+	if !ok {
+		return nil
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	for _, token := range ts.tokens {
+		if token.ID == id && subtle.ConstantTimeCompare([]byte(token.SecretHash), []byte(hashSecret(secret))) == 1 {
+			return token
+		}
+	}
+
+	return nil
+}
+
+func (ts *webhookTokenStore) list() []*gs.WebhookToken {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	result := make([]*gs.WebhookToken, 0, len(ts.tokens))
+	for _, token := range ts.tokens {
+		result = append(result, token.info())
+	}
+
+	return result
+}
+
+// check if a name is used by the bot of a token
+func (ts *webhookTokenStore) hasBot(name string) bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	for _, token := range ts.tokens {
+		if strings.EqualFold(token.BotName, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// load the incoming webhook tokens from a json file, they are kept in memory until this is called
+func (cs *ChatServer) LoadWebhookTokens(path string) error {
+	store := newWebhookTokenStore(path)
+	if err := store.load(); err != nil {
+		return err
+	}
+
+	cs.webhookTokens = store
+	return nil
+}
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// broadcast a message posted with an incoming webhook token, under the name of its bot
+func (cs *ChatServer) postBotMessage(ctx context.Context, given string, message string) (*gs.SentMessageStatus, error) {
+	token := cs.webhookTokens.find(given)
+	if token == nil {
+		slog.Warn("Incoming webhook refused, unknown token", "peer", peerHost(ctx))
+		return nil, rpcError(codes.Unauthenticated, gs.ReasonWrongCredentials, "Unknown or revoked webhook token!")
+	}
+
+	if strings.TrimSpace(message) == "" {
+		return nil, rpcError(codes.InvalidArgument, gs.ReasonInvalidArgument, "Blank message is not allowed!")
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	limits := cs.FloodLimits
+	if limits.MaxMessageLength > 0 && len([]rune(message)) > limits.MaxMessageLength {
+		return nil, rpcError(codes.InvalidArgument, gs.ReasonMessageTooLong, "Messages are limited to %d characters!", limits.MaxMessageLength)
+	}
+
+	// each token has its own limit, so one noisy integration does not silence the others
+	if limits.BotRate > 0 {
+		bucket, ok := cs.botBuckets[token.ID]
+		if !ok {
+			bucket = &tokenBucket{}
+			cs.botBuckets[token.ID] = bucket
+		}
+
+		if ok, wait := bucket.take(limits.BotRate, limits.BotBurst, time.Now()); !ok {
+			slog.Warn("Incoming webhook rate limited", "user", token.BotName, "token", token.ID)
+			return nil, retryableError(codes.ResourceExhausted, gs.ReasonRateLimited, wait, "Too many messages, slow down!")
+		}
+	}
+
+	bot := true
+	id := cs.nextMessageID()
+	timestamp := time.Now().Unix()
+	slog.LogAttrs(ctx, slog.LevelInfo, "Bot message", slog.String("user", token.BotName), slog.String("token", token.ID), cs.messageAttr(message))
+
+	cs.broadcast(&gs.ChatMessage{
+		Sender:  token.BotName,
+		Message: message,
+		Id:      &id,
+		Bot:     &bot,
+	}, nil)
+
+	return &gs.SentMessageStatus{
+		Id:        id,
+		Timestamp: timestamp,
+		Status:    int32(codes.OK),
+	}, nil
+}
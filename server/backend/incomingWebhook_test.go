@@ -0,0 +1,127 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+)
+
+// create an incoming webhook token for botName as the admin chat
+func createWebhookToken(t *testing.T, ts *testServer, admin *testChat, botName string) *gs.WebhookToken {
+	t.Helper()
+
+	token, err := ts.admin.CreateWebhookToken(admin.ctx, &gs.WebhookTokenRequest{Sender: admin.username, BotName: botName})
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	return token
+}
+
+// post a JSON body to the incoming webhook of token, returns the response
+func postWebhook(t *testing.T, server *httptest.Server, token string, body string) *http.Response {
+	t.Helper()
+
+	response, err := server.Client().Post(server.URL+incomingWebhookPath+token, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	response.Body.Close()
+
+	return response
+}
+
+func TestIncomingWebhook(t *testing.T) {
+	ts := newTestServer(t, nil)
+	server := newTestGateway(t, ts)
+	admin := ts.joinAs(t, "carol", gs.Role_ADMIN)
+	alice := ts.join(t, "alice")
+	token := createWebhookToken(t, ts, admin, "deploybot")
+
+	if response := postWebhook(t, server, token.GetToken(), `{"message": "deploy done"}`); response.StatusCode != http.StatusOK {
+		t.Fatalf("post: HTTP %d", response.StatusCode)
+	}
+
+	msg := alice.expect(t, from("deploybot", "deploy done"))
+	if !msg.GetBot() || msg.GetId() == "" {
+		t.Errorf("webhook message: bot %v, id %q", msg.GetBot(), msg.GetId())
+	}
+
+	for _, test := range []struct {
+		name  string
+		token string
+		body  string
+		want  int
+	}{
+		{"unknown token", "nope.nope", `{"message": "hi"}`, http.StatusUnauthorized},
+		{"wrong secret", token.GetId() + ".wrong", `{"message": "hi"}`, http.StatusUnauthorized},
+		{"blank message", token.GetToken(), `{"message": "  "}`, http.StatusBadRequest},
+		{"not json", token.GetToken(), `deploy done`, http.StatusBadRequest},
+	} {
+		if response := postWebhook(t, server, test.token, test.body); response.StatusCode != test.want {
+			t.Errorf("%s: HTTP %d, want %d", test.name, response.StatusCode, test.want)
+		}
+	}
+
+	// a revoked token cannot post anymore
+	if _, err := ts.admin.RevokeWebhookToken(admin.ctx, toward("carol", token.GetId())); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if response := postWebhook(t, server, token.GetToken(), `{"message": "still here?"}`); response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("revoked token: HTTP %d, want %d", response.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestIncomingWebhookRateLimit(t *testing.T) {
+	ts := newTestServer(t, func(cs *ChatServer) {
+		cs.FloodLimits.BotRate = 0.001
+		cs.FloodLimits.BotBurst = 1
+	})
+	server := newTestGateway(t, ts)
+	admin := ts.joinAs(t, "carol", gs.Role_ADMIN)
+	noisy := createWebhookToken(t, ts, admin, "noisybot")
+	quiet := createWebhookToken(t, ts, admin, "quietbot")
+
+	postWebhook(t, server, noisy.GetToken(), `{"message": "one"}`)
+	response := postWebhook(t, server, noisy.GetToken(), `{"message": "two"}`)
+	if response.StatusCode != http.StatusTooManyRequests || response.Header.Get("Retry-After") == "" {
+		t.Errorf("over the limit: HTTP %d, Retry-After %q", response.StatusCode, response.Header.Get("Retry-After"))
+	}
+
+	// every token has its own limit
+	if response := postWebhook(t, server, quiet.GetToken(), `{"message": "one"}`); response.StatusCode != http.StatusOK {
+		t.Errorf("another token: HTTP %d", response.StatusCode)
+	}
+}
+
+func TestWebhookTokensAreSaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "WebhookTokens.json")
+	ts := newTestServer(t, func(cs *ChatServer) {
+		if err := cs.LoadWebhookTokens(path); err != nil {
+			t.Fatalf("load: %v", err)
+		}
+	})
+	admin := ts.joinAs(t, "carol", gs.Role_ADMIN)
+	token := createWebhookToken(t, ts, admin, "deploybot")
+
+	// only a hash of the secret is written
+	data, _ := os.ReadFile(path)
+	_, secret, _ := strings.Cut(token.GetToken(), ".")
+	if !strings.Contains(string(data), token.GetId()) || strings.Contains(string(data), secret) {
+		t.Errorf("saved tokens: %s", data)
+	}
+
+	restarted := newTestServer(t, func(cs *ChatServer) {
+		if err := cs.LoadWebhookTokens(path); err != nil {
+			t.Fatalf("reload: %v", err)
+		}
+	})
+	if found := restarted.cs.webhookTokens.find(token.GetToken()); found == nil || found.BotName != "deploybot" {
+		t.Errorf("token after a restart: %v", found)
+	}
+}
@@ -0,0 +1,94 @@
+package backend
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// the address to listen on, a socket path for unix listeners
+func (l ListenerConfig) Addr() string {
+	if l.Network == "unix" {
+		return l.Address
+	}
+
+	return net.JoinHostPort(l.Address, strconv.Itoa(l.Port))
+}
+
+// the permissions of a unix socket, 0660 when not set
+func (l ListenerConfig) socketMode() (os.FileMode, error) {
+	if l.SocketMode == "" {
+		return 0660, nil
+	}
+
+	mode, err := strconv.ParseUint(l.SocketMode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid socket mode %q, use octal permissions like 0660", l.SocketMode)
+	}
+
+	return os.FileMode(mode), nil
+}
+
+// remove a socket file left behind by a server that did not shut down cleanly,
+// refusing to touch it when another server still accepts connections on it
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another server", path)
+	}
+
+	return os.Remove(path)
+}
+
+// open a listener, TCP listeners use TLS when tlsConfig is not nil.
+// unix sockets are local and protected by their permissions, so they are never wrapped in TLS
+func Listen(l ListenerConfig, tlsConfig *tls.Config) (net.Listener, error) {
+	if l.Network != "unix" {
+		listener, err := net.Listen(l.Network, l.Addr())
+		if err != nil {
+			return nil, err
+		}
+
+		if tlsConfig != nil {
+			listener = tls.NewListener(listener, tlsConfig)
+		}
+		return listener, nil
+	}
+
+	mode, err := l.socketMode()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := removeStaleSocket(l.Address); err != nil {
+		return nil, err
+	}
+
+	listener, err := listenUnix(l.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(l.Address, mode); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
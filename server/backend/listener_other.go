@@ -0,0 +1,10 @@
+//go:build !unix
+
+package backend
+
+import "net"
+
+// create a unix socket, the file permissions of other systems do not guard it
+func listenUnix(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,68 @@
+package backend
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnixSocketMode(t *testing.T) {
+	for _, test := range []struct {
+		mode string
+		want os.FileMode
+	}{
+		{"", 0660},
+		{"0600", 0600},
+	} {
+		path := filepath.Join(t.TempDir(), "chat.sock")
+		listener, err := Listen(ListenerConfig{Network: "unix", Address: path, SocketMode: test.mode}, nil)
+		if err != nil {
+			t.Fatalf("Listen: %v", err)
+		}
+
+		info, err := os.Stat(path)
+		listener.Close()
+		if err != nil {
+			t.Fatalf("stat: %v", err)
+		}
+		if info.Mode().Perm() != test.want {
+			t.Errorf("socket mode %q: permissions %o, want %o", test.mode, info.Mode().Perm(), test.want)
+		}
+	}
+
+	if _, err := Listen(ListenerConfig{Network: "unix", Address: filepath.Join(t.TempDir(), "chat.sock"), SocketMode: "rw"}, nil); err == nil {
+		t.Errorf("a socket mode that is not octal was accepted")
+	}
+}
+
+func TestStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.sock")
+	config := ListenerConfig{Network: "unix", Address: path}
+
+	first, err := Listen(config, nil)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+
+	// another server still accepts connections on it
+	if _, err := Listen(config, nil); err == nil {
+		t.Fatalf("a socket in use was replaced")
+	}
+
+	// left behind by a server that did not shut down cleanly
+	first.(*net.UnixListener).SetUnlinkOnClose(false)
+	first.Close()
+	second, err := Listen(config, nil)
+	if err != nil {
+		t.Fatalf("Listen over a stale socket: %v", err)
+	}
+	second.Close()
+
+	if err := os.WriteFile(path, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(config, nil); err == nil {
+		t.Errorf("a regular file was replaced by the socket")
+	}
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package backend
+
+import (
+	"net"
+	"syscall"
+)
+
+// create a unix socket only its owner can connect to until Listen sets its permissions.
+// the umask is the same for the whole process, so it is only changed around the bind
+func listenUnix(path string) (net.Listener, error) {
+	old := syscall.Umask(0177)
+	defer syscall.Umask(old)
+
+	return net.Listen("unix", path)
+}
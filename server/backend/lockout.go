@@ -0,0 +1,114 @@
+package backend
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Limits on failed logins, counted per username and per client address
+type LoginLimits struct {
+	// failures allowed before the username or the address is locked out
+	MaxFailures        int
+	MaxFailuresPerPeer int
+	// first lockout, doubled for every further failure up to MaxLockout
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+	// failures older than this are forgotten
+	FailureWindow time.Duration
+}
+
+// the limits used when nothing else is configured
+func DefaultLoginLimits() LoginLimits {
+	return LoginLimits{
+		MaxFailures:        5,
+		MaxFailuresPerPeer: 20,
+		BaseLockout:        30 * time.Second,
+		MaxLockout:         time.Hour,
+		FailureWindow:      15 * time.Minute,
+	}
+}
+
+// failed logins of a username or a client address
+type loginFailures struct {
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+// keys of the failure counters
+func usernameKey(username string) string { return "user:" + username }
+func peerKey(host string) string         { return "peer:" + host }
+
+// how long to lock out after count failures, when allowed failures are allowed
+func (limits LoginLimits) lockout(count int, allowed int) time.Duration {
+	if allowed <= 0 || count < allowed {
+		return 0
+	}
+
+	lockout := limits.BaseLockout
+	for i := allowed; i < count && lockout < limits.MaxLockout; i++ {
+		lockout *= 2
+	}
+
+	if lockout > limits.MaxLockout {
+		lockout = limits.MaxLockout
+	}
+	return lockout
+}
+
+// remaining lockout of a username or client address, 0 if logins are allowed.
+// cs.mu must be held by the caller
+func (cs *ChatServer) loginLockout(username string, host string) time.Duration {
+	now := time.Now()
+
+	var remaining time.Duration
+	for _, key := range []string{usernameKey(username), peerKey(host)} {
+		if failures, ok := cs.loginFailures[key]; ok && failures.lockedUntil.After(now) {
+			remaining = max(remaining, failures.lockedUntil.Sub(now))
+		}
+	}
+
+	return remaining
+}
+
+// count a failed login of username from host, locking them out when there are too many.
+// cs.mu must be held by the caller
+func (cs *ChatServer) recordLoginFailure(username string, host string) {
+	now := time.Now()
+	cs.Metrics.login("failure")
+
+	// forget old failures
+	for key, failures := range cs.loginFailures {
+		if now.Sub(failures.last) > cs.LoginLimits.FailureWindow && now.After(failures.lockedUntil) {
+			delete(cs.loginFailures, key)
+		}
+	}
+
+	// attr names who is locked out in the audit log, "user" or "peer"
+	count := func(key string, allowed int, attr string, value string) {
+		failures, ok := cs.loginFailures[key]
+		if !ok {
+			failures = &loginFailures{}
+			cs.loginFailures[key] = failures
+		}
+
+		failures.count++
+		failures.last = now
+
+		if lockout := cs.LoginLimits.lockout(failures.count, allowed); lockout > 0 {
+			failures.lockedUntil = now.Add(lockout)
+			slog.Warn("Login lockout", "audit", true, attr, value, "lockout", lockout, "failures", failures.count)
+		}
+	}
+
+	count(usernameKey(username), cs.LoginLimits.MaxFailures, "user", username)
+	if host != "" {
+		count(peerKey(host), cs.LoginLimits.MaxFailuresPerPeer, "peer", host)
+	}
+}
+
+// forget the failed logins of a username after a successful login.
+// cs.mu must be held by the caller
+func (cs *ChatServer) resetLoginFailures(username string) {
+	delete(cs.loginFailures, usernameKey(username))
+}
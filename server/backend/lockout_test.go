@@ -0,0 +1,160 @@
+package backend
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// the retry delay sent with an RPC error, 0 if there is none
+func retryDelayOf(err error) time.Duration {
+	for _, detail := range status.Convert(err).Details() {
+		if retry, ok := detail.(*errdetails.RetryInfo); ok {
+			return retry.GetRetryDelay().AsDuration()
+		}
+	}
+
+	return 0
+}
+
+func TestLockoutBackoff(t *testing.T) {
+	limits := LoginLimits{BaseLockout: 30 * time.Second, MaxLockout: 3 * time.Minute}
+
+	for _, test := range []struct {
+		count int
+		want  time.Duration
+	}{
+		{4, 0},
+		{5, 30 * time.Second},
+		{6, time.Minute},
+		{7, 2 * time.Minute},
+		{8, 3 * time.Minute},
+		{100, 3 * time.Minute},
+	} {
+		if got := limits.lockout(test.count, 5); got != test.want {
+			t.Errorf("lockout after %d failures: %v, want %v", test.count, got, test.want)
+		}
+	}
+
+	if got := limits.lockout(100, 0); got != 0 {
+		t.Errorf("lockout without a limit: %v, want 0", got)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	ts := newTestServer(t, func(cs *ChatServer) {
+		cs.LoginLimits.MaxFailures = 3
+		cs.LoginLimits.BaseLockout = 200 * time.Millisecond
+	})
+	ts.register(t, "alice")
+	ts.register(t, "bob")
+
+	for i := 0; i < 3; i++ {
+		if err := loginError(ts, "alice", "wrong password"); reasonOf(err) != gs.ReasonWrongCredentials {
+			t.Fatalf("wrong password %d: %v", i, err)
+		}
+	}
+
+	// even the right password is refused, and the client is told when to retry
+	err := loginError(ts, "alice", testPassword)
+	if status.Code(err) != codes.ResourceExhausted || reasonOf(err) != gs.ReasonTooManyAttempts {
+		t.Fatalf("login while locked out: %v, want ResourceExhausted", err)
+	}
+	if delay := retryDelayOf(err); delay <= 0 || delay > 200*time.Millisecond {
+		t.Errorf("retry delay: %v", delay)
+	}
+
+	// the lockout is per username
+	if err := loginError(ts, "bob", testPassword); err != nil {
+		t.Errorf("login of another user: %v", err)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	if err := loginError(ts, "alice", testPassword); err != nil {
+		t.Fatalf("login after the lockout: %v", err)
+	}
+
+	// a successful login forgets the failures
+	ts.cs.mu.Lock()
+	_, counted := ts.cs.loginFailures[usernameKey("alice")]
+	ts.cs.mu.Unlock()
+	if counted {
+		t.Errorf("failures of alice are still counted after a successful login")
+	}
+}
+
+func TestPeerLockout(t *testing.T) {
+	ts := newTestServer(t, func(cs *ChatServer) {
+		cs.LoginLimits.MaxFailures = 100
+		cs.LoginLimits.MaxFailuresPerPeer = 3
+	})
+
+	ts.cs.mu.Lock()
+	defer ts.cs.mu.Unlock()
+
+	// one address guessing a different username every time
+	for _, username := range []string{"alice", "bob", "carol"} {
+		ts.cs.recordLoginFailure(username, "10.0.0.1")
+	}
+
+	if ts.cs.loginLockout("dave", "10.0.0.1") == 0 {
+		t.Errorf("address with too many failures is not locked out")
+	}
+	if lockout := ts.cs.loginLockout("dave", "10.0.0.2"); lockout != 0 {
+		t.Errorf("other address is locked out for %v", lockout)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	bucket := &tokenBucket{}
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := bucket.take(1, 2, now); !ok {
+			t.Fatalf("token %d of the burst was refused", i)
+		}
+	}
+
+	ok, wait := bucket.take(1, 2, now)
+	if ok || wait != time.Second {
+		t.Errorf("empty bucket: %v, wait %v, want a refusal for 1s", ok, wait)
+	}
+
+	if ok, _ := bucket.take(1, 2, now.Add(time.Second)); !ok {
+		t.Errorf("the bucket was not refilled")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(1, 2)
+	client := func(ip string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}})
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := limiter.allow(client("10.0.0.1"), "/test"); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+
+	err := limiter.allow(client("10.0.0.1"), "/test")
+	if status.Code(err) != codes.ResourceExhausted || reasonOf(err) != gs.ReasonRateLimited || retryDelayOf(err) <= 0 {
+		t.Errorf("request over the limit: %v", err)
+	}
+
+	// every address has its own bucket, and 0 disables the limit
+	if err := limiter.allow(client("10.0.0.2"), "/test"); err != nil {
+		t.Errorf("request of another client: %v", err)
+	}
+	limiter.SetLimits(0, 0)
+	if err := limiter.allow(client("10.0.0.1"), "/test"); err != nil {
+		t.Errorf("request without a limit: %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// log file names carry their creation time, without colons so they are valid on every OS
+const logFileTimeFormat = "2006-01-02_15-04-05.000"
+
+// RPCs the client polls all the time, only logged at debug level when they succeed
+var quietMethods = map[string]bool{
+	"/grpcService.ChatRoom/GetConnectedPeers": true,
+}
+
+// a structured logger writing JSON or text records of at least level
+func NewLogger(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	options := &slog.HandlerOptions{Level: level}
+
+	switch format {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q, use json or text", format)
+	}
+}
+
+// A log file that is rotated when it grows too big or too old,
+// keeping a limited number of rotated files
+type RotatingFile struct {
+	// directory of the log files and prefix of their names
+	Dir    string
+	Prefix string
+	// rotate when the file reaches MaxSize bytes or is older than MaxAge, 0 disables each
+	MaxSize int64
+	MaxAge  time.Duration
+	// log files kept including the current one, 0 keeps all of them
+	Retention int
+
+	file   *os.File
+	size   int64
+	opened time.Time
+	mu     sync.Mutex
+}
+
+// open a new log file in dir, named prefix_<time>.log
+func OpenRotatingFile(dir string, prefix string, maxSize int64, maxAge time.Duration, retention int) (*RotatingFile, error) {
+	rf := &RotatingFile{
+		Dir:       dir,
+		Prefix:    prefix,
+		MaxSize:   maxSize,
+		MaxAge:    maxAge,
+		Retention: retention,
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := rf.rotate(time.Now()); err != nil {
+		return nil, err
+	}
+
+	return rf, nil
+}
+
+// close the current file and start a new one. rf.mu must be held by the caller
+func (rf *RotatingFile) rotate(now time.Time) error {
+	if rf.file != nil {
+		rf.file.Close()
+		rf.file = nil
+	}
+
+	name := filepath.Join(rf.Dir, fmt.Sprintf("%s_%s.log", rf.Prefix, now.Format(logFileTimeFormat)))
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	rf.opened = now
+	rf.prune()
+
+	return nil
+}
+
+// remove the oldest log files beyond the retention. rf.mu must be held by the caller
+func (rf *RotatingFile) prune() {
+	if rf.Retention <= 0 {
+		return
+	}
+
+	// the time in the names sorts them from the oldest to the newest
+	names, err := filepath.Glob(filepath.Join(rf.Dir, rf.Prefix+"_*.log"))
+	if err != nil {
+		return
+	}
+	sort.Strings(names)
+
+	for len(names) > rf.Retention {
+		if names[0] != rf.file.Name() {
+			os.Remove(names[0])
+		}
+		names = names[1:]
+	}
+}
+
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	now := time.Now()
+	tooBig := rf.MaxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.MaxSize
+	tooOld := rf.MaxAge > 0 && now.Sub(rf.opened) >= rf.MaxAge
+	if rf.file == nil || tooBig || tooOld {
+		if err := rf.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return nil
+	}
+
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// the text of a message as a log attribute, only its length when messages are redacted
+func (cs *ChatServer) messageAttr(message string) slog.Attr {
+	if cs.RedactMessages {
+		return slog.Int("message_length", len([]rune(message)))
+	}
+
+	return slog.String("message", message)
+}
+
+// the user a request is made by, from its fields or from the session token
+func (cs *ChatServer) requestUser(ctx context.Context, req any) string {
+	switch r := req.(type) {
+	case interface{ GetSender() string }:
+		return r.GetSender()
+	case interface{ GetUsername() string }:
+		return r.GetUsername()
+	}
+
+	token := sessionTokenFromContext(ctx)
+	if token == "" {
+		return ""
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	for username, sessions := range cs.loggedInAccount {
+		if _, ok := sessions[token]; ok {
+			return username
+		}
+	}
+
+	return ""
+}
+
+// log a finished RPC, at a level depending on its status code
+func logRPC(ctx context.Context, method string, user string, start time.Time, err error) {
+	code := status.Code(err)
+
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK:
+		if quietMethods[method] {
+			level = slog.LevelDebug
+		}
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("user", user),
+		slog.String("peer", peerHost(ctx)),
+		slog.Duration("latency", time.Since(start)),
+		slog.String("code", code.String()),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+
+	slog.LogAttrs(ctx, level, "rpc", attrs...)
+}
+
+// log every unary RPC with its method, user, client address and latency
+func (cs *ChatServer) LoggingUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logRPC(ctx, info.FullMethod, cs.requestUser(ctx, req), start, err)
+	return resp, err
+}
+
+// log every stream when it ends, the user is looked up from the session token
+func (cs *ChatServer) LoggingStreamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	user := cs.requestUser(stream.Context(), nil)
+	err := handler(srv, stream)
+	logRPC(stream.Context(), info.FullMethod, user, start, err)
+	return err
+}
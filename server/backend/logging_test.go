@@ -0,0 +1,178 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// send the records of the default logger to a buffer until the test ends
+func captureLogs(t *testing.T, level slog.Level) *lockedBuffer {
+	t.Helper()
+
+	logs := &lockedBuffer{}
+	logger, err := NewLogger(logs, "json", level)
+	if err != nil {
+		t.Fatalf("logger: %v", err)
+	}
+
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	return logs
+}
+
+// the logged records with the message msg
+func (b *lockedBuffer) records(t *testing.T, msg string) []map[string]any {
+	t.Helper()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var result []map[string]any
+	scanner := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("log record %q: %v", scanner.Text(), err)
+		}
+		if record["msg"] == msg {
+			result = append(result, record)
+		}
+	}
+
+	return result
+}
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger, err := NewLogger(&buf, "text", slog.LevelWarn)
+	if err != nil {
+		t.Fatalf("text logger: %v", err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown", "user", "alice")
+	if got := buf.String(); strings.Contains(got, "hidden") || !strings.Contains(got, "msg=shown user=alice") {
+		t.Errorf("text log: %q", got)
+	}
+
+	if _, err := NewLogger(&buf, "xml", slog.LevelInfo); err == nil {
+		t.Errorf("unknown format was accepted")
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	rf, err := OpenRotatingFile(dir, "server", 20, 0, 2)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer rf.Close()
+
+	// every line fills a file, the names differ by their creation time
+	for _, line := range []string{"first line 1234\n", "second line 123\n", "third line 1234\n"} {
+		time.Sleep(2 * time.Millisecond)
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	names, _ := filepath.Glob(filepath.Join(dir, "server_*.log"))
+	if len(names) != 2 {
+		t.Fatalf("log files: %v, want the 2 newest", names)
+	}
+	for i, want := range []string{"second line 123\n", "third line 1234\n"} {
+		if content, _ := os.ReadFile(names[i]); string(content) != want {
+			t.Errorf("file %d: %q, want %q", i, content, want)
+		}
+	}
+}
+
+func TestRotatingFileMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	rf, err := OpenRotatingFile(dir, "server", 0, 10*time.Millisecond, 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer rf.Close()
+
+	rf.Write([]byte("old\n"))
+	time.Sleep(20 * time.Millisecond)
+	rf.Write([]byte("new\n"))
+
+	if names, _ := filepath.Glob(filepath.Join(dir, "server_*.log")); len(names) != 2 {
+		t.Errorf("log files: %v, want 2", names)
+	}
+}
+
+func TestRedactMessages(t *testing.T) {
+	for _, redact := range []bool{false, true} {
+		logs := captureLogs(t, slog.LevelInfo)
+		ts := newTestServer(t, func(cs *ChatServer) { cs.RedactMessages = redact })
+		alice := ts.join(t, "alice")
+		ts.join(t, "bob")
+
+		if err := whisper(ts, alice, "bob", "my secret"); err != nil {
+			t.Fatalf("whisper: %v", err)
+		}
+
+		records := logs.records(t, "Private message request")
+		if len(records) != 1 {
+			t.Fatalf("redact %v: %d private message records", redact, len(records))
+		}
+
+		_, logged := records[0]["message"]
+		if logged == redact || (redact && records[0]["message_length"] != float64(len("my secret"))) {
+			t.Errorf("redact %v: logged %v", redact, records[0])
+		}
+	}
+}
+
+func TestLogRPC(t *testing.T) {
+	logs := captureLogs(t, slog.LevelDebug)
+	cs := NewChatServer(filepath.Join(t.TempDir(), "UserCredentials.json"))
+
+	for _, call := range []struct {
+		method string
+		err    error
+	}{
+		{"/grpcService.ChatRoom/GetConnectedPeers", nil},
+		{"/grpcService.ChatRoom/Login", nil},
+		{"/grpcService.ChatRoom/Login", status.Error(codes.Unauthenticated, "wrong password")},
+		{"/grpcService.ChatRoom/Register", status.Error(codes.Unavailable, "disk full")},
+	} {
+		handler := func(ctx context.Context, req any) (any, error) { return nil, call.err }
+		cs.LoggingUnaryInterceptor(context.Background(), &struct{}{}, &grpc.UnaryServerInfo{FullMethod: call.method}, handler)
+	}
+
+	records := logs.records(t, "rpc")
+	if len(records) != 4 {
+		t.Fatalf("%d rpc records, want 4", len(records))
+	}
+	for i, want := range []struct{ level, code string }{
+		{"DEBUG", "OK"},
+		{"INFO", "OK"},
+		{"WARN", "Unauthenticated"},
+		{"ERROR", "Unavailable"},
+	} {
+		if records[i]["level"] != want.level || records[i]["code"] != want.code {
+			t.Errorf("record %d: %v, want %s %s", i, records[i], want.level, want.code)
+		}
+	}
+	if records[2]["error"] != "wrong password" {
+		t.Errorf("error of a failed rpc: %v", records[2]["error"])
+	}
+}
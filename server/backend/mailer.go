@@ -0,0 +1,82 @@
+package backend
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sends emails to users, used for verification and password reset codes
+type Mailer interface {
+	Send(to string, subject string, body string) error
+}
+
+// a Mailer delivering through an SMTP server
+type SMTPMailer struct {
+	// host:port of the SMTP server
+	Addr string
+	From string
+	// credentials for PLAIN auth, no auth if Username is empty
+	Username string
+	Password string
+}
+
+func (m *SMTPMailer) Send(to string, subject string, body string) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	return smtp.SendMail(m.Addr, auth, m.From, []string{to}, []byte(formatMail(m.From, to, subject, body)))
+}
+
+// a Mailer appending emails to a file instead of sending them, for local testing
+type FileMailer struct {
+	out io.Writer
+	mu  sync.Mutex
+}
+
+// a FileMailer writing to the file at path, or to stdout if path is "-"
+func NewFileMailer(path string) (*FileMailer, error) {
+	if path == "-" {
+		return &FileMailer{out: os.Stdout}, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileMailer{out: file}, nil
+}
+
+func (m *FileMailer) Send(to string, subject string, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.out, "%s\n", formatMail("gRPC-ChatRoom", to, subject, body))
+	return err
+}
+
+// an RFC 5322 message with the headers needed by the mail senders
+func formatMail(from string, to string, subject string, body string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", from)
+	fmt.Fprintf(&sb, "To: %s\r\n", to)
+	fmt.Fprintf(&sb, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&sb, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	sb.WriteString(body)
+	sb.WriteString("\r\n")
+
+	return sb.String()
+}
@@ -0,0 +1,358 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+)
+
+// unread mentions kept for a user, the oldest are dropped first
+const maxMentionsPerUser = 100
+
+// @username, @here for the online users and @all for every registered user
+var mentionPattern = regexp.MustCompile(`(^|[^A-Za-z0-9_.-])@([A-Za-z0-9_.-]+)`)
+
+// an unread room message mentioning a user, as it is stored
+type mention struct {
+	MessageID string `json:"messageId"`
+	Sender    string `json:"sender"`
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func (m *mention) chatMessage() *gs.ChatMessage {
+	return &gs.ChatMessage{
+		Sender:    m.Sender,
+		Message:   m.Message,
+		Id:        &m.MessageID,
+		Timestamp: &m.Timestamp,
+		Kind:      gs.MessageKind_MESSAGE_MENTION,
+	}
+}
+
+// unread mentions of every user, persisted to a json file (kept in memory when the path is empty).
+// the file is written in the background, so posting to the room does not wait for the disk
+type mentionStore struct {
+	inbox map[string][]*mention
+	path  string
+	// the inbox changed since it was last written, and a writer is running
+	pending bool
+	saving  bool
+	writes  sync.WaitGroup
+	mu      sync.Mutex
+}
+
+func newMentionStore(path string) *mentionStore {
+	return &mentionStore{inbox: make(map[string][]*mention), path: path}
+}
+
+// load the mentions from the json file, a missing file is an empty store
+func (ms *mentionStore) load() error {
+	data, err := os.ReadFile(ms.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return json.Unmarshal(data, &ms.inbox)
+}
+
+// write the mentions to the json file in the background, the changes made until the
+// writer takes them are written at once. ms.mu must be held by the caller
+func (ms *mentionStore) save() {
+	if ms.path == "" {
+		return
+	}
+
+	ms.pending = true
+	if ms.saving {
+		return
+	}
+
+	ms.saving = true
+	ms.writes.Add(1)
+	go ms.write()
+}
+
+// write the json file until no change is pending
+func (ms *mentionStore) write() {
+	defer ms.writes.Done()
+
+	for {
+		ms.mu.Lock()
+		if !ms.pending {
+			ms.saving = false
+			ms.mu.Unlock()
+			return
+		}
+		ms.pending = false
+		data, err := json.MarshalIndent(ms.inbox, "", "  ")
+		ms.mu.Unlock()
+
+		if err == nil {
+			err = os.WriteFile(ms.path, data, 0600)
+		}
+		if err != nil {
+			slog.Error("Failed to save the mentions", "path", ms.path, "error", err)
+		}
+	}
+}
+
+// wait until the changes are written to the json file
+func (ms *mentionStore) flush() {
+	ms.writes.Wait()
+}
+
+// add a mention to the inbox of every user and persist the store
+func (ms *mentionStore) add(usernames []string, m *mention) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, username := range usernames {
+		inbox := append(ms.inbox[username], m)
+		if len(inbox) > maxMentionsPerUser {
+			inbox = inbox[len(inbox)-maxMentionsPerUser:]
+		}
+		ms.inbox[username] = inbox
+	}
+
+	ms.save()
+}
+
+// the unread mentions of a user, newest first
+func (ms *mentionStore) list(username string) []*mention {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	// the inbox is in the order of the messages
+	result := append([]*mention(nil), ms.inbox[username]...)
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+
+	return result
+}
+
+// remove the mentions of a user remove returns true for and persist the store, returns how many were removed
+func (ms *mentionStore) removeIf(username string, remove func(m *mention) bool) int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var kept []*mention
+	for _, m := range ms.inbox[username] {
+		if !remove(m) {
+			kept = append(kept, m)
+		}
+	}
+
+	removed := len(ms.inbox[username]) - len(kept)
+	if removed == 0 {
+		return 0
+	}
+
+	if len(kept) == 0 {
+		delete(ms.inbox, username)
+	} else {
+		ms.inbox[username] = kept
+	}
+	ms.save()
+
+	return removed
+}
+
+// remove a deleted message from every inbox
+func (ms *mentionStore) removeMessage(id string) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	changed := false
+	for username, inbox := range ms.inbox {
+		var kept []*mention
+		for _, m := range inbox {
+			if m.MessageID != id {
+				kept = append(kept, m)
+			}
+		}
+
+		if len(kept) == len(inbox) {
+			continue
+		}
+		changed = true
+		if len(kept) == 0 {
+			delete(ms.inbox, username)
+		} else {
+			ms.inbox[username] = kept
+		}
+	}
+
+	if changed {
+		ms.save()
+	}
+}
+
+// load the unread mentions from a json file, they are kept in memory until this is called
+func (cs *ChatServer) LoadMentions(path string) error {
+	store := newMentionStore(path)
+	if err := store.load(); err != nil {
+		return err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.mentions = store
+	return nil
+}
+
+// wait until the unread mentions are written to their json file, used when the server shuts down
+func (cs *ChatServer) FlushMentions() {
+	cs.mu.Lock()
+	mentions := cs.mentions
+	cs.mu.Unlock()
+
+	mentions.flush()
+}
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// the names after the @ of a message
+func parseMentions(message string) []string {
+	var names []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(message, -1) {
+		names = append(names, match[2])
+	}
+
+	return names
+}
+
+// the users mentioned by a room message, except the sender and the users who blocked them.
+// cs.mu must be held by the caller
+func (cs *ChatServer) mentionedUsers(sender string, message string) []string {
+	// @here and @all fill the inbox of many users at once
+	everyone := cs.can(sender, PermMentionEveryone)
+
+	found := make(map[string]bool)
+	for _, name := range parseMentions(message) {
+		switch {
+		case strings.EqualFold(name, "here"):
+			if !everyone {
+				continue
+			}
+			for username := range cs.clientStream {
+				found[username] = true
+			}
+		case strings.EqualFold(name, "all"):
+			if !everyone {
+				continue
+			}
+			for _, user := range cs.users.list() {
+				found[user.GetUsername()] = true
+			}
+		case cs.users.find(name) != nil:
+			found[name] = true
+		default:
+			// "@bob." ends a sentence, "bob." may be a username too
+			if trimmed := strings.TrimRight(name, ".-"); trimmed != name && cs.users.find(trimmed) != nil {
+				found[trimmed] = true
+			}
+		}
+	}
+
+	var result []string
+	for username := range found {
+		if username == sender || cs.hasBlocked(username, sender) {
+			continue
+		}
+		result = append(result, username)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// send a mention event to every stream of the users a room message mentions and keep it
+// in their inbox until they read it. cs.mu must be held by the caller
+func (cs *ChatServer) notifyMentions(roomMsg *gs.ChatMessage) {
+	mentioned := cs.mentionedUsers(roomMsg.GetSender(), roomMsg.GetMessage())
+	if len(mentioned) == 0 {
+		return
+	}
+
+	m := &mention{
+		MessageID: roomMsg.GetId(),
+		Sender:    roomMsg.GetSender(),
+		Message:   roomMsg.GetMessage(),
+		Timestamp: roomMsg.GetTimestamp(),
+	}
+	event := m.chatMessage()
+	event.Bot = roomMsg.Bot
+
+	for _, username := range mentioned {
+		cs.sendToUser(username, event, nil)
+	}
+
+	cs.mentions.add(mentioned, m)
+	slog.Debug("Mentions sent", "user", roomMsg.GetSender(), "id", roomMsg.GetId(), "mentioned", len(mentioned))
+}
+
+// the unread mentions of a user as a MentionList
+func (cs *ChatServer) mentionList(username string) *gs.MentionList {
+	result := &gs.MentionList{}
+	for _, m := range cs.mentions.list(username) {
+		result.Messages = append(result.Messages, m.chatMessage())
+	}
+
+	return result
+}
+
+// ---------------------------------------------------------//
+// ------------------------- RPC ---------------------------//
+
+// list the unread room messages mentioning the calling user, newest first
+func (cs *ChatServer) ListMentions(ctx context.Context, request *gs.UserRequest) (*gs.MentionList, error) {
+	sender := request.GetSender()
+	if _, err := cs.authenticate(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	return cs.mentionList(sender), nil
+}
+
+// mark the mention of the message target as read, or every mention when target is not set.
+// returns the mentions still unread
+func (cs *ChatServer) MarkMentionsRead(ctx context.Context, request *gs.UserRequest) (*gs.MentionList, error) {
+	sender := request.GetSender()
+	if _, err := cs.authenticate(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	removed := cs.mentions.removeIf(sender, func(m *mention) bool {
+		return request.Target == nil || m.MessageID == request.GetTarget()
+	})
+
+	if removed == 0 && request.Target != nil {
+		return nil, rpcError(codes.NotFound, gs.ReasonMentionNotFound, "Mention %s not found!", request.GetTarget())
+	}
+
+	slog.Info("Mentions marked as read", "user", sender, "count", removed)
+	return cs.mentionList(sender), nil
+}
@@ -0,0 +1,138 @@
+package backend
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+)
+
+// a mention event of a room message
+func mentionOf(sender string, message string) func(m *gs.ChatMessage) bool {
+	return func(m *gs.ChatMessage) bool {
+		return m.GetKind() == gs.MessageKind_MESSAGE_MENTION && m.GetSender() == sender && m.GetMessage() == message
+	}
+}
+
+// the texts of the unread mentions of a user, newest first
+func unreadMentions(t *testing.T, ts *testServer, chat *testChat) []string {
+	t.Helper()
+
+	mentions, err := ts.client.ListMentions(chat.ctx, &gs.UserRequest{Sender: chat.username})
+	if err != nil {
+		t.Fatalf("list mentions of %s: %v", chat.username, err)
+	}
+
+	var texts []string
+	for _, m := range mentions.GetMessages() {
+		texts = append(texts, m.GetMessage())
+	}
+	return texts
+}
+
+func TestParseMentions(t *testing.T) {
+	for message, want := range map[string][]string{
+		"hi @bob and @carol.":  {"bob", "carol."},
+		"@here standup":        {"here"},
+		"mail bob@example.com": nil,
+		"(@bob)":               {"bob"},
+	} {
+		if got := parseMentions(message); !reflect.DeepEqual(got, want) {
+			t.Errorf("mentions of %q: %v, want %v", message, got, want)
+		}
+	}
+}
+
+func TestMentions(t *testing.T) {
+	ts := newTestServer(t, nil)
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+	carol := ts.join(t, "carol")
+
+	alice.say(t, "thanks @bob.")
+	bob.expect(t, mentionOf("alice", "thanks @bob."))
+	carol.expect(t, from("alice", "thanks @bob."))
+	carol.expectNone(t, 100*time.Millisecond, mentionOf("alice", "thanks @bob."))
+
+	// users who blocked the sender are not notified
+	blocked := "alice"
+	if _, err := ts.client.BlockUser(carol.ctx, &gs.UserRequest{Sender: "carol", Target: &blocked}); err != nil {
+		t.Fatalf("block: %v", err)
+	}
+	alice.say(t, "@carol @bob lunch?")
+	bob.expect(t, mentionOf("alice", "@carol @bob lunch?"))
+
+	if got := unreadMentions(t, ts, bob); !reflect.DeepEqual(got, []string{"@carol @bob lunch?", "thanks @bob."}) {
+		t.Errorf("unread mentions of bob: %v", got)
+	}
+	if got := unreadMentions(t, ts, carol); len(got) != 0 {
+		t.Errorf("unread mentions of carol: %v, want none", got)
+	}
+
+	if _, err := ts.client.MarkMentionsRead(bob.ctx, &gs.UserRequest{Sender: "bob"}); err != nil {
+		t.Fatalf("mark read: %v", err)
+	}
+	if got := unreadMentions(t, ts, bob); len(got) != 0 {
+		t.Errorf("unread mentions of bob after reading them: %v", got)
+	}
+}
+
+func TestMentionEveryone(t *testing.T) {
+	ts := newTestServer(t, nil)
+	alice := ts.join(t, "alice")
+	mod := ts.joinAs(t, "mod", gs.Role_MODERATOR)
+	ts.register(t, "dave")
+
+	// members cannot notify everyone
+	alice.say(t, "@all look")
+	mod.expect(t, from("alice", "@all look"))
+	mod.expectNone(t, 100*time.Millisecond, mentionOf("alice", "@all look"))
+
+	// @here is for the online users, @all also for the offline ones
+	mod.say(t, "@here standup")
+	alice.expect(t, mentionOf("mod", "@here standup"))
+	mod.say(t, "@all release")
+	alice.expect(t, mentionOf("mod", "@all release"))
+
+	ts.cs.mu.Lock()
+	defer ts.cs.mu.Unlock()
+	var offline []string
+	for _, m := range ts.cs.mentions.list("dave") {
+		offline = append(offline, m.Message)
+	}
+	if !reflect.DeepEqual(offline, []string{"@all release"}) {
+		t.Errorf("unread mentions of the offline user: %v", offline)
+	}
+}
+
+func TestMentionsAreSaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mentions.json")
+	ts := newTestServer(t, func(cs *ChatServer) {
+		if err := cs.LoadMentions(path); err != nil {
+			t.Fatalf("LoadMentions: %v", err)
+		}
+	})
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	for _, message := range []string{"@bob one", "@bob two", "@bob three"} {
+		alice.say(t, message)
+		bob.expect(t, mentionOf("alice", message))
+	}
+	ts.cs.FlushMentions()
+
+	// the file written in the background has every mention
+	saved := newMentionStore(path)
+	if err := saved.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	var texts []string
+	for _, m := range saved.list("bob") {
+		texts = append(texts, m.Message)
+	}
+	if !reflect.DeepEqual(texts, []string{"@bob three", "@bob two", "@bob one"}) {
+		t.Errorf("saved mentions of bob: %v", texts)
+	}
+}
@@ -0,0 +1,154 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Prometheus metrics of a chat server, a nil *Metrics records nothing
+type Metrics struct {
+	registry        *prometheus.Registry
+	logins          *prometheus.CounterVec
+	broadcasts      prometheus.Counter
+	privateMessages prometheus.Counter
+	likes           prometheus.Counter
+	sendErrors      prometheus.Counter
+	fanOut          prometheus.Histogram
+	rpcLatency      *prometheus.HistogramVec
+}
+
+// create the metrics of cs in their own registry, with the Go runtime and process metrics
+func NewMetrics(cs *ChatServer) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chat_logins_total",
+			Help: "Login attempts by result: success, failure or locked_out.",
+		}, []string{"result"}),
+		broadcasts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "chat_messages_broadcast_total",
+			Help: "Messages broadcast to the room, including server notices.",
+		}),
+		privateMessages: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "chat_private_messages_total",
+			Help: "Private messages delivered.",
+		}),
+		likes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "chat_likes_total",
+			Help: "Messages liked.",
+		}),
+		sendErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "chat_stream_send_errors_total",
+			Help: "Messages that could not be sent on a chat stream.",
+		}),
+		fanOut: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "chat_broadcast_duration_seconds",
+			Help:    "Time to send a broadcast message to every connected stream.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 8),
+		}),
+		rpcLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "Latency of the RPCs handled by the server, streams until they end.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+	}
+
+	connected := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "chat_connected_streams",
+		Help: "Open chat streams, one per connected client session.",
+	}, func() float64 {
+		cs.mu.Lock()
+		defer cs.mu.Unlock()
+
+		streams := 0
+		for _, userStreams := range cs.clientStream {
+			streams += len(userStreams)
+		}
+		return float64(streams)
+	})
+
+	loggedIn := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "chat_logged_in_users",
+		Help: "Users with at least one logged in session.",
+	}, func() float64 {
+		cs.mu.Lock()
+		defer cs.mu.Unlock()
+
+		return float64(len(cs.loggedInAccount))
+	})
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		connected, loggedIn,
+		m.logins, m.broadcasts, m.privateMessages, m.likes, m.sendErrors, m.fanOut, m.rpcLatency,
+	)
+
+	return m
+}
+
+// the HTTP handler serving the metrics in the Prometheus format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+func (m *Metrics) login(result string) {
+	if m != nil {
+		m.logins.WithLabelValues(result).Inc()
+	}
+}
+
+func (m *Metrics) broadcast(start time.Time) {
+	if m != nil {
+		m.broadcasts.Inc()
+		m.fanOut.Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *Metrics) privateMessage() {
+	if m != nil {
+		m.privateMessages.Inc()
+	}
+}
+
+func (m *Metrics) like() {
+	if m != nil {
+		m.likes.Inc()
+	}
+}
+
+func (m *Metrics) sendError() {
+	if m != nil {
+		m.sendErrors.Inc()
+	}
+}
+
+func (m *Metrics) observeRPC(method string, start time.Time, err error) {
+	m.rpcLatency.WithLabelValues(method, status.Code(err).String()).Observe(time.Since(start).Seconds())
+}
+
+// record the latency of every unary RPC by method and status code
+func (m *Metrics) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	m.observeRPC(info.FullMethod, start, err)
+	return resp, err
+}
+
+// record how long every stream was open by method and status code
+func (m *Metrics) StreamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, stream)
+	m.observeRPC(info.FullMethod, start, err)
+	return err
+}
@@ -0,0 +1,93 @@
+package backend
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the metrics served by the handler of m, one sample per line
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, _ := io.ReadAll(recorder.Body)
+	return string(body)
+}
+
+// check that the scraped metrics have every sample of want
+func expectSamples(t *testing.T, metrics string, want ...string) {
+	t.Helper()
+
+	lines := strings.Split(metrics, "\n")
+	for _, sample := range want {
+		found := false
+		for _, line := range lines {
+			found = found || line == sample
+		}
+		if !found {
+			t.Errorf("sample %q not found", sample)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	ts := newTestServer(t, func(cs *ChatServer) { cs.Metrics = NewMetrics(cs) })
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+	ts.connect(t, "bob")
+
+	loginError(ts, "alice", "wrong password")
+	if err := whisper(ts, alice, "bob", "hi"); err != nil {
+		t.Fatalf("whisper: %v", err)
+	}
+	alice.say(t, "hello")
+	bob.expect(t, from("alice", "hello"))
+	if err := like(ts, bob, "alice"); err != nil {
+		t.Fatalf("like: %v", err)
+	}
+
+	expectSamples(t, scrape(t, ts.cs.Metrics),
+		`chat_logins_total{result="success"} 3`,
+		`chat_logins_total{result="failure"} 1`,
+		`chat_private_messages_total 1`,
+		`chat_likes_total 1`,
+		`chat_connected_streams 3`,
+		`chat_logged_in_users 2`,
+		// the login notices of alice and bob, the message of alice and the like notice
+		`chat_messages_broadcast_total 4`,
+		`chat_broadcast_duration_seconds_count 4`,
+	)
+}
+
+func TestRPCLatencyMetrics(t *testing.T) {
+	m := NewMetrics(NewChatServer(filepath.Join(t.TempDir(), "UserCredentials.json")))
+
+	for _, err := range []error{nil, nil, status.Error(codes.NotFound, "no")} {
+		handler := func(ctx context.Context, req any) (any, error) { return nil, err }
+		m.UnaryInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpcService.ChatRoom/Login"}, handler)
+	}
+
+	expectSamples(t, scrape(t, m),
+		`grpc_server_handling_seconds_count{code="OK",method="/grpcService.ChatRoom/Login"} 2`,
+		`grpc_server_handling_seconds_count{code="NotFound",method="/grpcService.ChatRoom/Login"} 1`,
+	)
+}
+
+func TestNilMetrics(t *testing.T) {
+	// a server without metrics records nothing and does not panic
+	var m *Metrics
+	m.login("success")
+	m.privateMessage()
+	m.like()
+	m.sendError()
+}
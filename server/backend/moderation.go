@@ -0,0 +1,170 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+)
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// a new unique room message id. cs.mu must be held by the caller
+func (cs *ChatServer) nextMessageID() string {
+	cs.messageSeq++
+	return strconv.FormatUint(cs.messageSeq, 10)
+}
+
+// remember a room message so it can be deleted later. cs.mu must be held by the caller
+func (cs *ChatServer) rememberMessage(msg *gs.ChatMessage) {
+	cs.recentMessages[msg.GetId()] = msg
+	cs.recentOrder = append(cs.recentOrder, msg.GetId())
+
+	for len(cs.recentOrder) > cs.MaxRecentMessages {
+		delete(cs.recentMessages, cs.recentOrder[0])
+		cs.recentOrder = cs.recentOrder[1:]
+	}
+}
+
+// the reason a user cannot post in the room right now, empty if they can.
+// cs.mu must be held by the caller
+func (cs *ChatServer) postRejection(username string) string {
+	if !cs.can(username, PermPostInRoom) {
+		return "You are not permitted to send messages to room!!!"
+	}
+
+	if until, ok := cs.mutedUntil[username]; ok {
+		if time.Now().Before(until) {
+			return fmt.Sprintf("You are muted until %s!!!", until.Format(time.Kitchen))
+		}
+		delete(cs.mutedUntil, username)
+	}
+
+	if cs.roomLocked && !cs.can(username, PermModerate) {
+		return "The room is read-only right now!!!"
+	}
+
+	return ""
+}
+
+// a successful moderation result
+func moderationResult(sender string) *gs.SentMessageStatus {
+	timestamp := time.Now().Unix()
+	return &gs.SentMessageStatus{
+		Id:        fmt.Sprintf("%d-%s", timestamp, sender),
+		Timestamp: timestamp,
+		Status:    int32(codes.OK),
+	}
+}
+
+// ---------------------------------------------------------//
+// ------------------------- RPC ---------------------------//
+
+// mute a user in the chat room for a duration
+func (cs *ChatServer) MuteUser(ctx context.Context, request *gs.MuteRequest) (*gs.SentMessageStatus, error) {
+	sender := request.GetSender()
+	target := request.GetTarget()
+
+	if _, err := cs.authorize(ctx, sender, PermModerate); err != nil {
+		return nil, err
+	}
+
+	if cs.users.find(target) == nil {
+		return nil, userNotFoundError(target)
+	}
+
+	// moderators cannot silence each other or admins
+	if cs.can(target, PermModerate) {
+		return nil, rpcError(codes.PermissionDenied, gs.ReasonPermissionDenied, "User %s cannot be muted!", target)
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	var msg string
+	if request.GetDurationSeconds() <= 0 {
+		delete(cs.mutedUntil, target)
+		msg = fmt.Sprintf("%s was unmuted by %s", target, sender)
+	} else {
+		duration := time.Duration(request.GetDurationSeconds()) * time.Second
+		cs.mutedUntil[target] = time.Now().Add(duration)
+		msg = fmt.Sprintf("%s was muted for %s by %s", target, duration, sender)
+	}
+
+	slog.Info(msg)
+	cs.broadcast(&gs.ChatMessage{
+		Message: msg,
+		Sender:  "Server",
+	}, nil)
+
+	return moderationResult(sender), nil
+}
+
+// delete a room message, users may delete their own messages and moderators any message
+func (cs *ChatServer) DeleteMessage(ctx context.Context, request *gs.UserRequest) (*gs.SentMessageStatus, error) {
+	sender := request.GetSender()
+	id := request.GetTarget()
+
+	if _, err := cs.authenticate(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	msg, ok := cs.recentMessages[id]
+	if !ok {
+		return nil, rpcError(codes.NotFound, gs.ReasonMessageNotFound, "Message %s not found!", id)
+	}
+
+	if msg.GetSender() != sender && !cs.can(sender, PermModerate) {
+		slog.Warn("User is not permitted to delete the message", "user", sender, "id", id, "target", msg.GetSender())
+		return nil, rpcError(codes.PermissionDenied, gs.ReasonPermissionDenied, "User %s is not permitted to %s!", sender, PermModerate)
+	}
+
+	delete(cs.recentMessages, id)
+	cs.history.remove(id)
+	cs.mentions.removeMessage(id)
+	slog.Info("Message deleted", "user", sender, "id", id, "target", msg.GetSender())
+
+	cs.broadcast(&gs.ChatMessage{
+		Message: fmt.Sprintf("A message of %s was deleted by %s", msg.GetSender(), sender),
+		Sender:  "Server",
+		Id:      &id,
+		Kind:    gs.MessageKind_MESSAGE_DELETED,
+	}, nil)
+
+	return moderationResult(sender), nil
+}
+
+// lock or unlock the chat room to read-only for non moderators
+func (cs *ChatServer) LockRoom(ctx context.Context, request *gs.LockRoomRequest) (*gs.SentMessageStatus, error) {
+	sender := request.GetSender()
+
+	if _, err := cs.authorize(ctx, sender, PermModerate); err != nil {
+		return nil, err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.roomLocked = request.GetLocked()
+
+	msg := fmt.Sprintf("The room was unlocked by %s", sender)
+	if cs.roomLocked {
+		msg = fmt.Sprintf("The room was locked to read-only by %s", sender)
+	}
+
+	slog.Info(msg)
+	cs.broadcast(&gs.ChatMessage{
+		Message: msg,
+		Sender:  "Server",
+	}, nil)
+
+	return moderationResult(sender), nil
+}
@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// a message of the server starting with prefix
+func serverSays(prefix string) func(m *gs.ChatMessage) bool {
+	return func(m *gs.ChatMessage) bool {
+		return m.GetSender() == "Server" && strings.HasPrefix(m.GetMessage(), prefix)
+	}
+}
+
+func TestRolePermissions(t *testing.T) {
+	for _, test := range []struct {
+		role    gs.Role
+		granted []Permission
+	}{
+		{gs.Role_GUEST, nil},
+		{gs.Role_MEMBER, []Permission{PermPostInRoom, PermLike, PermPrivateMessage, PermViewProfile}},
+		{gs.Role_MODERATOR, []Permission{PermPostInRoom, PermLike, PermPrivateMessage, PermViewProfile, PermModerate, PermMentionEveryone}},
+		{gs.Role_ADMIN, []Permission{PermPostInRoom, PermLike, PermPrivateMessage, PermViewProfile, PermModerate, PermAdminister, PermMentionEveryone}},
+	} {
+		granted := make(map[Permission]bool)
+		for _, perm := range test.granted {
+			granted[perm] = true
+		}
+
+		for perm := range permissionNames {
+			if hasPermission(test.role, perm) != granted[perm] {
+				t.Errorf("%s %s: %v, want %v", test.role, perm, hasPermission(test.role, perm), granted[perm])
+			}
+		}
+	}
+}
+
+func TestGuestCannotPost(t *testing.T) {
+	ts := newTestServer(t, nil)
+	guest := ts.joinAs(t, "guest", gs.Role_GUEST)
+	alice := ts.join(t, "alice")
+
+	guest.say(t, "hello")
+	guest.expect(t, from("Server", "You are not permitted to send messages to room!!!"))
+	alice.expectNone(t, 100*time.Millisecond, from("guest", "hello"))
+
+	alice.say(t, "hi")
+	guest.expect(t, from("alice", "hi"))
+	if err := like(ts, guest, "alice"); status.Code(err) != codes.PermissionDenied || reasonOf(err) != gs.ReasonPermissionDenied {
+		t.Errorf("like as a guest: %v, want PermissionDenied", err)
+	}
+}
+
+func TestMuteUser(t *testing.T) {
+	ts := newTestServer(t, nil)
+	mod := ts.joinAs(t, "mod", gs.Role_MODERATOR)
+	alice := ts.join(t, "alice")
+
+	// members cannot mute, and moderators cannot mute each other
+	if _, err := ts.client.MuteUser(alice.ctx, &gs.MuteRequest{Sender: "alice", Target: "mod", DurationSeconds: 60}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("mute by a member: %v, want PermissionDenied", err)
+	}
+	ts.joinAs(t, "mod2", gs.Role_MODERATOR)
+	if _, err := ts.client.MuteUser(mod.ctx, &gs.MuteRequest{Sender: "mod", Target: "mod2", DurationSeconds: 60}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("mute of a moderator: %v, want PermissionDenied", err)
+	}
+
+	if _, err := ts.client.MuteUser(mod.ctx, &gs.MuteRequest{Sender: "mod", Target: "alice", DurationSeconds: 60}); err != nil {
+		t.Fatalf("mute: %v", err)
+	}
+	alice.expect(t, from("Server", "alice was muted for 1m0s by mod"))
+	alice.say(t, "let me talk")
+	alice.expect(t, serverSays("You are muted until"))
+	mod.expectNone(t, 100*time.Millisecond, from("alice", "let me talk"))
+
+	if _, err := ts.client.MuteUser(mod.ctx, &gs.MuteRequest{Sender: "mod", Target: "alice"}); err != nil {
+		t.Fatalf("unmute: %v", err)
+	}
+	alice.say(t, "thanks")
+	mod.expect(t, from("alice", "thanks"))
+}
+
+func TestDeleteMessage(t *testing.T) {
+	ts := newTestServer(t, nil)
+	mod := ts.joinAs(t, "mod", gs.Role_MODERATOR)
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	alice.say(t, "oops")
+	alice.say(t, "spam")
+	oops := bob.expect(t, from("alice", "oops")).GetId()
+	spam := bob.expect(t, from("alice", "spam")).GetId()
+
+	// only the author and the moderators can delete a message
+	if _, err := ts.client.DeleteMessage(bob.ctx, &gs.UserRequest{Sender: "bob", Target: &oops}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("delete by another member: %v, want PermissionDenied", err)
+	}
+	if _, err := ts.client.DeleteMessage(alice.ctx, &gs.UserRequest{Sender: "alice", Target: &oops}); err != nil {
+		t.Fatalf("delete by the author: %v", err)
+	}
+	if _, err := ts.client.DeleteMessage(mod.ctx, &gs.UserRequest{Sender: "mod", Target: &spam}); err != nil {
+		t.Fatalf("delete by a moderator: %v", err)
+	}
+
+	for _, id := range []string{oops, spam} {
+		bob.expect(t, func(m *gs.ChatMessage) bool {
+			return m.GetKind() == gs.MessageKind_MESSAGE_DELETED && m.GetId() == id
+		})
+	}
+	if _, err := ts.client.DeleteMessage(alice.ctx, &gs.UserRequest{Sender: "alice", Target: &oops}); status.Code(err) != codes.NotFound {
+		t.Errorf("delete of a deleted message: %v, want NotFound", err)
+	}
+}
+
+func TestLockRoom(t *testing.T) {
+	ts := newTestServer(t, nil)
+	mod := ts.joinAs(t, "mod", gs.Role_MODERATOR)
+	alice := ts.join(t, "alice")
+
+	if _, err := ts.client.LockRoom(alice.ctx, &gs.LockRoomRequest{Sender: "alice", Locked: true}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("lock by a member: %v, want PermissionDenied", err)
+	}
+	if _, err := ts.client.LockRoom(mod.ctx, &gs.LockRoomRequest{Sender: "mod", Locked: true}); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+
+	alice.say(t, "hello?")
+	alice.expect(t, from("Server", "The room is read-only right now!!!"))
+	mod.say(t, "maintenance")
+	alice.expect(t, from("mod", "maintenance"))
+
+	if _, err := ts.client.LockRoom(mod.ctx, &gs.LockRoomRequest{Sender: "mod"}); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	alice.say(t, "back")
+	mod.expect(t, from("alice", "back"))
+}
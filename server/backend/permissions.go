@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"context"
+	"log/slog"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+)
+
+// An action a user may be allowed to take
+type Permission int
+
+const (
+	PermPostInRoom Permission = iota
+	PermLike
+	PermPrivateMessage
+	PermViewProfile
+	PermModerate
+	PermAdminister
+	// notify every user with @here and @all
+	PermMentionEveryone
+)
+
+var permissionNames = map[Permission]string{
+	PermPostInRoom:      "post in room",
+	PermLike:            "like",
+	PermPrivateMessage:  "private message",
+	PermViewProfile:     "view profile",
+	PermModerate:        "moderate",
+	PermAdminister:      "administer",
+	PermMentionEveryone: "mention everyone",
+}
+
+func (p Permission) String() string {
+	return permissionNames[p]
+}
+
+// permissions granted to each role
+var rolePermissions = map[gs.Role]map[Permission]bool{
+	gs.Role_GUEST: {},
+	gs.Role_MEMBER: {
+		PermPostInRoom:     true,
+		PermLike:           true,
+		PermPrivateMessage: true,
+		PermViewProfile:    true,
+	},
+	gs.Role_MODERATOR: {
+		PermPostInRoom:      true,
+		PermLike:            true,
+		PermPrivateMessage:  true,
+		PermViewProfile:     true,
+		PermModerate:        true,
+		PermMentionEveryone: true,
+	},
+	gs.Role_ADMIN: {
+		PermPostInRoom:      true,
+		PermLike:            true,
+		PermPrivateMessage:  true,
+		PermViewProfile:     true,
+		PermModerate:        true,
+		PermAdminister:      true,
+		PermMentionEveryone: true,
+	},
+}
+
+// Check if a role is granted a permission
+func hasPermission(role gs.Role, perm Permission) bool {
+	return rolePermissions[role][perm]
+}
+
+// Check if a registered user is granted a permission
+func (cs *ChatServer) can(username string, perm Permission) bool {
+	user := cs.users.find(username)
+	return user != nil && !user.GetDisabled() && hasPermission(user.GetRole(), perm)
+}
+
+// find the session of the calling client and check the user is granted the permission
+func (cs *ChatServer) authorize(ctx context.Context, username string, perm Permission) (*session, error) {
+	s, err := cs.authenticate(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cs.can(username, perm) {
+		slog.Warn("User is not permitted", "user", username, "permission", perm)
+		return nil, rpcError(codes.PermissionDenied, gs.ReasonPermissionDenied, "User %s is not permitted to %s!", username, perm)
+	}
+
+	return s, nil
+}
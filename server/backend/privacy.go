@@ -0,0 +1,189 @@
+package backend
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+)
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// Check if owner has blocked the other user
+func isBlockedBy(owner *gs.User, username string) bool {
+	return slices.Contains(owner.GetBlocked(), username)
+}
+
+// Check if recipient has blocked sender, server messages are never blocked
+func (cs *ChatServer) hasBlocked(recipient string, sender string) bool {
+	if sender == "Server" {
+		return false
+	}
+
+	user := cs.users.find(recipient)
+	return user != nil && isBlockedBy(user, sender)
+}
+
+// Check if viewer is in the audience owner shared something with
+func (cs *ChatServer) inAudience(owner *gs.User, audience gs.Audience, viewer string) bool {
+	if viewer == owner.Username {
+		return true
+	}
+
+	if isBlockedBy(owner, viewer) {
+		return false
+	}
+
+	switch audience {
+	case gs.Audience_EVERYONE:
+		return true
+	case gs.Audience_STAFF:
+		return cs.can(viewer, PermModerate)
+	default:
+		return false
+	}
+}
+
+// user information without the password and the fields hidden from viewer
+func (cs *ChatServer) visibleUserInfo(user *gs.User, viewer string) *gs.PublicUserInfo {
+	info := publicUserInfo(user)
+	privacy := user.GetPrivacy()
+
+	if !cs.inAudience(user, privacy.GetEmail(), viewer) {
+		info.Email = nil
+	}
+	if !cs.inAudience(user, privacy.GetBirthdate(), viewer) {
+		info.Birthdate = nil
+	}
+	if !cs.inAudience(user, privacy.GetAddress(), viewer) {
+		info.Address = nil
+	}
+
+	return info
+}
+
+// the settings part of a user
+func userSettings(user *gs.User) *gs.UserSettings {
+	privacy := user.GetPrivacy()
+	if privacy == nil {
+		privacy = &gs.PrivacySettings{}
+	}
+
+	return &gs.UserSettings{
+		Blocked:     user.GetBlocked(),
+		Privacy:     privacy,
+		TotpEnabled: user.GetTotpEnabled(),
+	}
+}
+
+// modify the settings of a user and return the new settings
+func (cs *ChatServer) updateSettings(username string, modify func(user *gs.User) error) (*gs.UserSettings, error) {
+	var settings *gs.UserSettings
+
+	err := cs.users.update(username, func(user *gs.User) error {
+		if err := modify(user); err != nil {
+			return err
+		}
+
+		settings = userSettings(user)
+		return nil
+	})
+
+	if err != nil {
+		return nil, storeError(err, username)
+	}
+
+	return settings, nil
+}
+
+// ---------------------------------------------------------//
+// ------------------------- RPC ---------------------------//
+
+// get the block list and privacy settings of the calling user
+func (cs *ChatServer) GetSettings(ctx context.Context, request *gs.UserRequest) (*gs.UserSettings, error) {
+	username := request.GetSender()
+
+	if _, err := cs.authenticate(ctx, username); err != nil {
+		return nil, err
+	}
+
+	user := cs.users.find(username)
+	if user == nil {
+		return nil, userNotFoundError(username)
+	}
+
+	return userSettings(user), nil
+}
+
+// replace the block list and privacy settings of the calling user
+func (cs *ChatServer) UpdateSettings(ctx context.Context, request *gs.UserSettingsRequest) (*gs.UserSettings, error) {
+	username := request.GetSender()
+
+	if _, err := cs.authenticate(ctx, username); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Settings updated", "user", username)
+
+	return cs.updateSettings(username, func(user *gs.User) error {
+		blocked := []string{}
+		for _, target := range request.GetSettings().GetBlocked() {
+			if target != "" && target != username && !slices.Contains(blocked, target) {
+				blocked = append(blocked, target)
+			}
+		}
+
+		user.Blocked = blocked
+		user.Privacy = request.GetSettings().GetPrivacy()
+		return nil
+	})
+}
+
+// hide public messages of a user and reject their private messages
+func (cs *ChatServer) BlockUser(ctx context.Context, request *gs.UserRequest) (*gs.UserSettings, error) {
+	username := request.GetSender()
+	target := request.GetTarget()
+
+	if _, err := cs.authenticate(ctx, username); err != nil {
+		return nil, err
+	}
+
+	if target == username {
+		return nil, rpcError(codes.InvalidArgument, gs.ReasonSelfAction, "You cannot block yourself!")
+	}
+
+	if cs.users.find(target) == nil {
+		return nil, userNotFoundError(target)
+	}
+
+	slog.Info("User blocked", "user", username, "target", target)
+
+	return cs.updateSettings(username, func(user *gs.User) error {
+		if !isBlockedBy(user, target) {
+			user.Blocked = append(user.Blocked, target)
+		}
+		return nil
+	})
+}
+
+// remove a user from the block list
+func (cs *ChatServer) UnblockUser(ctx context.Context, request *gs.UserRequest) (*gs.UserSettings, error) {
+	username := request.GetSender()
+	target := request.GetTarget()
+
+	if _, err := cs.authenticate(ctx, username); err != nil {
+		return nil, err
+	}
+
+	slog.Info("User unblocked", "user", username, "target", target)
+
+	return cs.updateSettings(username, func(user *gs.User) error {
+		user.Blocked = slices.DeleteFunc(user.Blocked, func(blocked string) bool {
+			return blocked == target
+		})
+		return nil
+	})
+}
@@ -0,0 +1,152 @@
+package backend
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// send a private message from sender to recipient
+func whisper(ts *testServer, sender *testChat, recipient string, message string) error {
+	_, err := ts.client.SendPrivateMessage(sender.ctx, &gs.PrivateChatMessage{Sender: sender.username, Recipent: recipient, Message: message})
+	return err
+}
+
+// a request of sender about target
+func toward(sender string, target string) *gs.UserRequest {
+	return &gs.UserRequest{Sender: sender, Target: &target}
+}
+
+func TestBlockUser(t *testing.T) {
+	ts := newTestServer(t, nil)
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	if _, err := ts.client.BlockUser(alice.ctx, toward("alice", "alice")); reasonOf(err) != gs.ReasonSelfAction {
+		t.Errorf("block yourself: %v, want %s", err, gs.ReasonSelfAction)
+	}
+	if _, err := ts.client.BlockUser(alice.ctx, toward("alice", "nobody")); status.Code(err) != codes.NotFound {
+		t.Errorf("block an unknown user: %v, want NotFound", err)
+	}
+
+	settings, err := ts.client.BlockUser(alice.ctx, toward("alice", "bob"))
+	if err != nil {
+		t.Fatalf("block: %v", err)
+	}
+	if !slices.Equal(settings.GetBlocked(), []string{"bob"}) {
+		t.Errorf("blocked: %v, want [bob]", settings.GetBlocked())
+	}
+
+	// alice no longer sees bob in the room and bob cannot whisper to her
+	bob.say(t, "can you hear me")
+	alice.expectNone(t, 100*time.Millisecond, from("bob", "can you hear me"))
+	if err := whisper(ts, bob, "alice", "psst"); reasonOf(err) != gs.ReasonPrivateMessagesRefused {
+		t.Errorf("whisper to a blocker: %v, want %s", err, gs.ReasonPrivateMessagesRefused)
+	}
+
+	// blocking is one way, bob still hears alice
+	alice.say(t, "hello")
+	bob.expect(t, from("alice", "hello"))
+
+	if _, err := ts.client.UnblockUser(alice.ctx, toward("alice", "bob")); err != nil {
+		t.Fatalf("unblock: %v", err)
+	}
+	bob.say(t, "back again")
+	alice.expect(t, from("bob", "back again"))
+}
+
+func TestPrivateMessageAudience(t *testing.T) {
+	ts := newTestServer(t, nil)
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+	mod := ts.joinAs(t, "mod", gs.Role_MODERATOR)
+
+	setAudience := func(audience gs.Audience) {
+		t.Helper()
+
+		request := &gs.UserSettingsRequest{Sender: "alice", Settings: &gs.UserSettings{Privacy: &gs.PrivacySettings{PrivateMessages: audience}}}
+		if _, err := ts.client.UpdateSettings(alice.ctx, request); err != nil {
+			t.Fatalf("update settings: %v", err)
+		}
+	}
+
+	setAudience(gs.Audience_STAFF)
+	if err := whisper(ts, bob, "alice", "hi"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("member to staff only: %v, want PermissionDenied", err)
+	}
+	if err := whisper(ts, mod, "alice", "hi"); err != nil {
+		t.Errorf("moderator to staff only: %v", err)
+	}
+
+	setAudience(gs.Audience_NOBODY)
+	if err := whisper(ts, mod, "alice", "hi"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("moderator to nobody: %v, want PermissionDenied", err)
+	}
+
+	setAudience(gs.Audience_EVERYONE)
+	if err := whisper(ts, bob, "alice", "hi"); err != nil {
+		t.Errorf("member to everyone: %v", err)
+	}
+}
+
+func TestUpdateSettingsCleansBlockList(t *testing.T) {
+	ts := newTestServer(t, nil)
+	alice := ts.join(t, "alice")
+
+	request := &gs.UserSettingsRequest{Sender: "alice", Settings: &gs.UserSettings{Blocked: []string{"bob", "", "alice", "bob", "carol"}}}
+	if _, err := ts.client.UpdateSettings(alice.ctx, request); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+
+	settings, err := ts.client.GetSettings(alice.ctx, &gs.UserRequest{Sender: "alice"})
+	if err != nil {
+		t.Fatalf("get settings: %v", err)
+	}
+	if !slices.Equal(settings.GetBlocked(), []string{"bob", "carol"}) {
+		t.Errorf("blocked: %v, want [bob carol]", settings.GetBlocked())
+	}
+}
+
+func TestHiddenProfileFields(t *testing.T) {
+	ts := newTestServer(t, nil)
+	ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+	mod := ts.joinAs(t, "mod", gs.Role_MODERATOR)
+
+	email, birthdate := "alice@example.com", "2000-01-01"
+	err := ts.cs.users.update("alice", func(user *gs.User) error {
+		user.Email = &email
+		user.Birthdate = &birthdate
+		user.Privacy = &gs.PrivacySettings{Email: gs.Audience_STAFF, Birthdate: gs.Audience_NOBODY}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("update alice: %v", err)
+	}
+
+	for _, test := range []struct {
+		viewer    *testChat
+		email     bool
+		birthdate bool
+	}{
+		{bob, false, false},
+		{mod, true, false},
+	} {
+		info, err := ts.client.GetPeerInfomations(test.viewer.ctx, toward(test.viewer.username, "alice"))
+		if err != nil {
+			t.Fatalf("%s views alice: %v", test.viewer.username, err)
+		}
+		if (info.Email != nil) != test.email || (info.Birthdate != nil) != test.birthdate {
+			t.Errorf("%s sees email %v and birthdate %v, want %v and %v", test.viewer.username, info.Email != nil, info.Birthdate != nil, test.email, test.birthdate)
+		}
+	}
+
+	// a user always sees their own profile
+	if info := ts.cs.visibleUserInfo(ts.cs.users.find("alice"), "alice"); info.GetEmail() != email || info.GetBirthdate() != birthdate {
+		t.Errorf("own profile: %v", info)
+	}
+}
@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+)
+
+// how long an unused bucket is kept before it is dropped
+const bucketIdleTimeout = 10 * time.Minute
+
+// a token bucket refilled at rate tokens per second, holding at most burst tokens
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take a token if there is one, otherwise return how long until the next one
+func (b *tokenBucket) take(rate float64, burst int, now time.Time) (bool, time.Duration) {
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else {
+		b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if rate <= 0 {
+		return false, time.Minute
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// the IP address of the calling client, without the port
+func peerHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+// Limits the rate of RPCs (and chat streams opened) per client IP address
+type RateLimiter struct {
+	// requests per second allowed for each client, 0 disables the limit
+	Rate  float64
+	Burst int
+
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		Rate:    rate,
+		Burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// change the limits, used when the configuration is reloaded
+func (rl *RateLimiter) SetLimits(rate float64, burst int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.Rate = rate
+	rl.Burst = burst
+}
+
+// take a token from the bucket of the calling client
+func (rl *RateLimiter) allow(ctx context.Context, method string) error {
+	host := peerHost(ctx)
+	now := time.Now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if rl.Rate <= 0 {
+		return nil
+	}
+
+	// drop the buckets of clients that went away
+	if now.Sub(rl.lastSweep) > bucketIdleTimeout {
+		for key, bucket := range rl.buckets {
+			if now.Sub(bucket.last) > bucketIdleTimeout {
+				delete(rl.buckets, key)
+			}
+		}
+		rl.lastSweep = now
+	}
+
+	bucket, ok := rl.buckets[host]
+	if !ok {
+		bucket = &tokenBucket{}
+		rl.buckets[host] = bucket
+	}
+
+	if ok, wait := bucket.take(rl.Rate, rl.Burst, now); !ok {
+		slog.Warn("Rate limit exceeded", "peer", host, "method", method)
+		return retryableError(codes.ResourceExhausted, gs.ReasonRateLimited, wait, "Too many requests, slow down!")
+	}
+
+	return nil
+}
+
+func (rl *RateLimiter) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := rl.allow(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (rl *RateLimiter) StreamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := rl.allow(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+
+	return handler(srv, stream)
+}
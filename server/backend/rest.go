@@ -0,0 +1,143 @@
+package backend
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// address of the in-process connections of the REST gateway to the gRPC server
+type restGatewayAddr struct{}
+
+func (restGatewayAddr) Network() string { return "rest-gateway" }
+func (restGatewayAddr) String() string  { return "rest-gateway" }
+
+type restGatewayConn struct {
+	net.Conn
+}
+
+func (restGatewayConn) LocalAddr() net.Addr  { return restGatewayAddr{} }
+func (restGatewayConn) RemoteAddr() net.Addr { return restGatewayAddr{} }
+
+// a listener accepting the connections dialed in the same process,
+// so REST requests go through the interceptors of the gRPC server like any other RPC
+type memoryListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newMemoryListener() *memoryListener {
+	return &memoryListener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *memoryListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *memoryListener) Close() error {
+	l.once.Do(func() {
+		close(l.closed)
+	})
+	return nil
+}
+
+func (l *memoryListener) Addr() net.Addr {
+	return restGatewayAddr{}
+}
+
+func (l *memoryListener) dial(ctx context.Context, _ string) (net.Conn, error) {
+	server, client := net.Pipe()
+
+	select {
+	case l.conns <- restGatewayConn{server}:
+		return restGatewayConn{client}, nil
+	case <-l.closed:
+	case <-ctx.Done():
+	}
+
+	server.Close()
+	client.Close()
+	return nil, net.ErrClosed
+}
+
+// create the REST/JSON handler of the ChatRoom endpoints annotated in services.proto,
+// it calls grpcServer in process with the session token from the session-token header
+func newRESTHandler(grpcServer *grpc.Server) (http.Handler, error) {
+	listener := newMemoryListener()
+	go grpcServer.Serve(listener)
+
+	conn, err := grpc.Dial("rest-gateway",
+		grpc.WithContextDialer(listener.dial),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(restHeaderMatcher))
+	if err := gs.RegisterChatRoomHandler(context.Background(), mux, conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return mux, nil
+}
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// forward the session token header like the gRPC clients send it
+func restHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, gs.SessionTokenKey) {
+		return gs.SessionTokenKey, true
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// the address of the HTTP client of a REST request, the gateway appends it to x-forwarded-for
+func forwardedAddr(ctx context.Context) net.Addr {
+	md, _ := metadata.FromIncomingContext(ctx)
+	forwarded := md.Get("x-forwarded-for")
+	if len(forwarded) == 0 {
+		return restGatewayAddr{}
+	}
+
+	hops := strings.Split(forwarded[len(forwarded)-1], ",")
+	ip := net.ParseIP(strings.TrimSpace(hops[len(hops)-1]))
+	if ip == nil {
+		return restGatewayAddr{}
+	}
+
+	return &net.IPAddr{IP: ip}
+}
+
+// give the REST requests the address of their HTTP client as peer,
+// so logs, rate limits and login lockouts see the real client. it must be the first interceptor
+func RESTPeerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if p, ok := peer.FromContext(ctx); ok {
+		if _, fromGateway := p.Addr.(restGatewayAddr); fromGateway {
+			ctx = peer.NewContext(ctx, &peer.Peer{Addr: forwardedAddr(ctx)})
+		}
+	}
+
+	return handler(ctx, req)
+}
@@ -0,0 +1,80 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+)
+
+// serve the gateway of a test server over HTTP
+func newTestGateway(t *testing.T, ts *testServer) *httptest.Server {
+	t.Helper()
+
+	gateway, err := NewGateway(ts.grpc, ts.cs, nil)
+	if err != nil {
+		t.Fatalf("NewGateway: %v", err)
+	}
+
+	server := httptest.NewServer(gateway)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// send a REST request as the user of chat, or without a token when chat is nil. returns the HTTP status
+func restRequest(t *testing.T, server *httptest.Server, chat *testChat, method string, path string, body string) int {
+	t.Helper()
+
+	request, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	if chat != nil {
+		request.Header.Set(gs.SessionTokenKey, tokenOf(chat.ctx))
+	}
+
+	response, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	response.Body.Close()
+
+	return response.StatusCode
+}
+
+func TestRESTLikeOfUnknownUserOrBot(t *testing.T) {
+	ts := newBotServer(t, BotConfig{Kind: "echo", Name: "echobot"})
+	server := newTestGateway(t, ts)
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	// the bot posts to the room, its message still cannot be liked
+	bob.say(t, "!echo hi")
+	alice.expect(t, from("echobot", "hi"))
+
+	for _, target := range []string{"nobody", "echobot"} {
+		code := restRequest(t, server, alice, http.MethodPost, "/v1/likes", `{"sender": "alice", "target": "`+target+`"}`)
+		if code != http.StatusNotFound {
+			t.Errorf("like of %s: HTTP %d, want %d", target, code, http.StatusNotFound)
+		}
+	}
+
+	// the server did not crash and still handles likes
+	if code := restRequest(t, server, alice, http.MethodPost, "/v1/likes", `{"sender": "alice", "target": "bob"}`); code != http.StatusOK {
+		t.Errorf("like of bob: HTTP %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestRESTPeersNeedLogin(t *testing.T) {
+	ts := newTestServer(t, nil)
+	server := newTestGateway(t, ts)
+	alice := ts.join(t, "alice")
+
+	// the registered usernames are not listed to anyone
+	if code := restRequest(t, server, nil, http.MethodGet, "/v1/peers?sender=alice", ""); code != http.StatusUnauthorized {
+		t.Errorf("peers without a token: HTTP %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := restRequest(t, server, alice, http.MethodGet, "/v1/peers?sender=alice", ""); code != http.StatusOK {
+		t.Errorf("peers with a token: HTTP %d, want %d", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,416 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// how often the scheduled messages are checked for delivery
+const scheduleSweepInterval = time.Second
+
+// messages a user can have waiting for delivery
+const maxScheduledPerUser = 50
+
+// how far ahead a message can be scheduled
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+// a scheduled message as it is stored
+type scheduledMessage struct {
+	ID      string `json:"id"`
+	Sender  string `json:"sender"`
+	Message string `json:"message"`
+	// empty for a room message
+	Recipient string `json:"recipient,omitempty"`
+	DeliverAt int64  `json:"deliverAt"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+func (m *scheduledMessage) info() *gs.ScheduledMessage {
+	info := &gs.ScheduledMessage{
+		Id:        m.ID,
+		Sender:    m.Sender,
+		Message:   m.Message,
+		DeliverAt: m.DeliverAt,
+		CreatedAt: m.CreatedAt,
+	}
+	if m.Recipient != "" {
+		info.Recipent = &m.Recipient
+	}
+
+	return info
+}
+
+// messages waiting for delivery, persisted to a json file (kept in memory when the path is empty)
+type scheduleStore struct {
+	messages []*scheduledMessage
+	path     string
+	mu       sync.Mutex
+}
+
+func newScheduleStore(path string) *scheduleStore {
+	return &scheduleStore{path: path}
+}
+
+// load the messages from the json file, a missing file is an empty store
+func (ss *scheduleStore) load() error {
+	data, err := os.ReadFile(ss.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return json.Unmarshal(data, &ss.messages)
+}
+
+// write the messages to the json file. ss.mu must be held by the caller
+func (ss *scheduleStore) save() error {
+	if ss.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(ss.messages, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(ss.path, data, 0600)
+}
+
+// add a message and persist the store
+func (ss *scheduleStore) add(m *scheduledMessage) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.messages = append(ss.messages, m)
+	if err := ss.save(); err != nil {
+		ss.messages = ss.messages[:len(ss.messages)-1]
+		return err
+	}
+
+	return nil
+}
+
+// remove the messages remove returns true for and persist the store, returns the removed messages
+func (ss *scheduleStore) removeIf(remove func(m *scheduledMessage) bool) ([]*scheduledMessage, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var removed []*scheduledMessage
+	kept := make([]*scheduledMessage, 0, len(ss.messages))
+	for _, m := range ss.messages {
+		if remove(m) {
+			removed = append(removed, m)
+		} else {
+			kept = append(kept, m)
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	previous := ss.messages
+	ss.messages = kept
+	if err := ss.save(); err != nil {
+		ss.messages = previous
+		return nil, err
+	}
+
+	return removed, nil
+}
+
+// the messages of a sender, by delivery time
+func (ss *scheduleStore) list(sender string) []*scheduledMessage {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var result []*scheduledMessage
+	for _, m := range ss.messages {
+		if m.Sender == sender {
+			result = append(result, m)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].DeliverAt < result[j].DeliverAt })
+	return result
+}
+
+// the messages due at now, by delivery time
+func (ss *scheduleStore) due(now int64) []*scheduledMessage {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var result []*scheduledMessage
+	for _, m := range ss.messages {
+		if m.DeliverAt <= now {
+			result = append(result, m)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].DeliverAt < result[j].DeliverAt })
+	return result
+}
+
+// load the scheduled messages from a json file, they are kept in memory until this is called.
+// messages that became due while the server was down are delivered right away
+func (cs *ChatServer) LoadScheduledMessages(path string) error {
+	store := newScheduleStore(path)
+	if err := store.load(); err != nil {
+		return err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.scheduled = store
+	return nil
+}
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// periodically deliver the scheduled messages that are due
+func (cs *ChatServer) deliverScheduled() {
+	tick := time.NewTicker(scheduleSweepInterval)
+	defer tick.Stop()
+
+	for range tick.C {
+		cs.deliverDue()
+	}
+}
+
+// deliver every scheduled message due at cs.Clock(). private messages to an offline user
+// wait until they are online, messages that cannot be delivered anymore are dropped
+func (cs *ChatServer) deliverDue() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	done := make(map[*scheduledMessage]bool)
+	for _, m := range cs.scheduled.due(cs.Clock().Unix()) {
+		delivered, err := cs.deliverScheduledMessage(m)
+		if err != nil {
+			slog.Warn("Scheduled message dropped", "user", m.Sender, "target", m.Recipient, "id", m.ID, "error", err)
+			cs.sendToUser(m.Sender, &gs.ChatMessage{
+				Message: fmt.Sprintf("Your scheduled message could not be delivered: %v", err),
+				Sender:  "Server",
+				Kind:    gs.MessageKind_MESSAGE_WARNING,
+			}, nil)
+		}
+
+		if delivered || err != nil {
+			done[m] = true
+		}
+	}
+
+	if len(done) == 0 {
+		return
+	}
+
+	if _, err := cs.scheduled.removeIf(func(m *scheduledMessage) bool { return done[m] }); err != nil {
+		slog.Error("Failed to save the scheduled messages", "error", err)
+	}
+}
+
+// deliver a due message through the room or the private messages, like the sender would.
+// false without an error when it has to wait for the recipient. cs.mu must be held by the caller
+func (cs *ChatServer) deliverScheduledMessage(m *scheduledMessage) (bool, error) {
+	if cs.users.find(m.Sender) == nil {
+		return false, fmt.Errorf("the account of %s was deleted", m.Sender)
+	}
+
+	// a reminder only goes to the devices of the sender
+	if m.Recipient == m.Sender {
+		if !cs.isConnected(m.Sender) {
+			return false, nil
+		}
+
+		slog.Info("Reminder delivered", "user", m.Sender, "id", m.ID)
+		cs.sendToUser(m.Sender, &gs.ChatMessage{
+			Message: "Reminder: " + m.Message,
+			Sender:  "Server",
+		}, nil)
+		return true, nil
+	}
+
+	// the same checks as a message sent to the room right now, so queued messages cannot flood it
+	if m.Recipient == "" {
+		if rejection := cs.postRejection(m.Sender); rejection != "" {
+			return false, errors.New(rejection)
+		}
+		if err := cs.floodRejection(m.Sender, m.Message, false); err != nil {
+			return false, errors.New(status.Convert(err).Message())
+		}
+		if cs.messageLikes[m.Sender].nLike < cs.LikesToPost {
+			return false, errors.New("your previous room message needs more likes")
+		}
+
+		slog.Info("Scheduled message posted", "user", m.Sender, "id", m.ID)
+		cs.broadcast(&gs.ChatMessage{
+			Message: m.Message,
+			Sender:  m.Sender,
+		}, nil)
+		return true, nil
+	}
+
+	toBot := cs.Webhooks.isBotUser(m.Recipient) || cs.Bots.has(m.Recipient)
+	recipient := cs.users.find(m.Recipient)
+	if recipient == nil && !toBot {
+		return false, fmt.Errorf("user %s does not exist anymore", m.Recipient)
+	}
+
+	if recipient != nil && !cs.inAudience(recipient, recipient.GetPrivacy().GetPrivateMessages(), m.Sender) {
+		return false, fmt.Errorf("user %s does not accept private messages from you", m.Recipient)
+	}
+
+	if !cs.isConnected(m.Recipient) && !toBot {
+		return false, nil
+	}
+
+	if err := cs.floodRejection(m.Sender, m.Message, true); err != nil {
+		return false, errors.New(status.Convert(err).Message())
+	}
+
+	// the sender did not see it when it was scheduled, so it is echoed to all their devices
+	id, _, err := cs.deliverPrivateMessage(m.Sender, m.Recipient, m.Message, nil)
+	if err != nil {
+		// the recipient went offline, try again later
+		return false, nil
+	}
+
+	slog.Info("Scheduled private message sent", "user", m.Sender, "target", m.Recipient, "id", m.ID, "message_id", id)
+	return true, nil
+}
+
+// ---------------------------------------------------------//
+// ------------------------- RPC ---------------------------//
+
+// deliver a message to the room, or privately to recipent, at a later time
+func (cs *ChatServer) ScheduleMessage(ctx context.Context, request *gs.ScheduleRequest) (*gs.ScheduledMessage, error) {
+	sender := request.GetSender()
+	recipient := request.GetRecipent()
+	slog.LogAttrs(ctx, slog.LevelInfo, "Schedule message request", slog.String("user", sender), slog.String("target", recipient), cs.messageAttr(request.GetMessage()))
+
+	perm := PermPostInRoom
+	if recipient != "" {
+		perm = PermPrivateMessage
+	}
+	if _, err := cs.authorize(ctx, sender, perm); err != nil {
+		return nil, err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	now := cs.Clock()
+	deliverAt := time.Unix(request.GetDeliverAt(), 0)
+	if request.GetDeliverAt() == 0 {
+		deliverAt = now.Add(time.Duration(request.GetDelaySeconds()) * time.Second)
+	}
+
+	var v fieldViolations
+	if strings.TrimSpace(request.GetMessage()) == "" {
+		v.add("message", "cannot be blank")
+	} else if limit := cs.FloodLimits.MaxMessageLength; limit > 0 && len([]rune(request.GetMessage())) > limit {
+		v.add("message", fmt.Sprintf("must be at most %d characters", limit))
+	}
+	if !deliverAt.After(now) {
+		v.add("deliver_at", "must be in the future")
+	} else if deliverAt.Sub(now) > maxScheduleAhead {
+		v.add("deliver_at", "must be within a year")
+	}
+	if err := v.err("Invalid scheduled message!"); err != nil {
+		return nil, err
+	}
+
+	// the recipient is checked again when the message is delivered
+	if recipient != "" && recipient != sender {
+		user := cs.users.find(recipient)
+		toBot := cs.Webhooks.isBotUser(recipient) || cs.Bots.has(recipient)
+		if user == nil && !toBot {
+			return nil, userNotFoundError(recipient)
+		}
+
+		if user != nil && !cs.inAudience(user, user.GetPrivacy().GetPrivateMessages(), sender) {
+			return nil, rpcError(codes.PermissionDenied, gs.ReasonPrivateMessagesRefused, "User %s does not accept private messages from you!", recipient)
+		}
+	}
+
+	if len(cs.scheduled.list(sender)) >= maxScheduledPerUser {
+		return nil, rpcError(codes.ResourceExhausted, gs.ReasonTooManyScheduled, "You cannot have more than %d scheduled messages!", maxScheduledPerUser)
+	}
+
+	m := &scheduledMessage{
+		ID:        GenerateSecureToken(6),
+		Sender:    sender,
+		Message:   request.GetMessage(),
+		Recipient: recipient,
+		DeliverAt: deliverAt.Unix(),
+		CreatedAt: now.Unix(),
+	}
+	if err := cs.scheduled.add(m); err != nil {
+		slog.Error("Failed to save the scheduled messages", "user", sender, "error", err)
+		return nil, storeError(err, sender)
+	}
+
+	slog.Info("Message scheduled", "user", sender, "target", recipient, "id", m.ID, "deliver_at", deliverAt)
+	return m.info(), nil
+}
+
+// list the scheduled messages of the calling user waiting for delivery
+func (cs *ChatServer) ListScheduled(ctx context.Context, request *gs.UserRequest) (*gs.ScheduledMessageList, error) {
+	sender := request.GetSender()
+	if _, err := cs.authenticate(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	result := &gs.ScheduledMessageList{}
+	for _, m := range cs.scheduled.list(sender) {
+		result.Messages = append(result.Messages, m.info())
+	}
+
+	return result, nil
+}
+
+// cancel a scheduled message of the calling user, target is its id
+func (cs *ChatServer) CancelScheduled(ctx context.Context, request *gs.UserRequest) (*gs.ScheduledMessage, error) {
+	sender := request.GetSender()
+	id := request.GetTarget()
+	if _, err := cs.authenticate(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	// users can only cancel their own messages, the others are reported as missing
+	removed, err := cs.scheduled.removeIf(func(m *scheduledMessage) bool { return m.ID == id && m.Sender == sender })
+	if err != nil {
+		slog.Error("Failed to save the scheduled messages", "user", sender, "error", err)
+		return nil, storeError(err, sender)
+	}
+
+	if len(removed) == 0 {
+		return nil, rpcError(codes.NotFound, gs.ReasonScheduledNotFound, "Scheduled message %s not found!", id)
+	}
+
+	slog.Info("Scheduled message cancelled", "user", sender, "id", id)
+	return removed[0].info(), nil
+}
@@ -0,0 +1,213 @@
+package backend
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// a clock the tests move forward by hand
+type fakeClock struct {
+	now time.Time
+	mu  sync.Mutex
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// start a server on a fake clock whose scheduled messages are kept in path
+func newSchedulerServer(t *testing.T, path string) (*testServer, *fakeClock) {
+	t.Helper()
+
+	clock := &fakeClock{now: time.Now()}
+	ts := newTestServer(t, func(cs *ChatServer) {
+		cs.Clock = clock.Now
+		if err := cs.LoadScheduledMessages(path); err != nil {
+			t.Fatalf("LoadScheduledMessages: %v", err)
+		}
+	})
+
+	return ts, clock
+}
+
+// schedule a room message of chat, or a private one to recipient, delay from now
+func schedule(ts *testServer, chat *testChat, recipient string, message string, delay time.Duration) (*gs.ScheduledMessage, error) {
+	request := &gs.ScheduleRequest{Sender: chat.username, Message: message, DelaySeconds: int64(delay / time.Second)}
+	if recipient != "" {
+		request.Recipent = &recipient
+	}
+
+	return ts.client.ScheduleMessage(chat.ctx, request)
+}
+
+// a warning that a scheduled message was dropped
+func undelivered(m *gs.ChatMessage) bool {
+	return m.GetKind() == gs.MessageKind_MESSAGE_WARNING && strings.HasPrefix(m.GetMessage(), "Your scheduled message could not be delivered")
+}
+
+func TestScheduledDelivery(t *testing.T) {
+	ts, clock := newSchedulerServer(t, filepath.Join(t.TempDir(), "scheduled.json"))
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	if _, err := schedule(ts, alice, "", "good morning", time.Minute); err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+	if _, err := schedule(ts, alice, "bob", "psst", time.Hour); err != nil {
+		t.Fatalf("schedule private: %v", err)
+	}
+
+	// nothing is due yet
+	ts.cs.deliverDue()
+	bob.expectNone(t, 100*time.Millisecond, func(m *gs.ChatMessage) bool { return m.GetSender() == "alice" })
+
+	clock.advance(time.Minute)
+	ts.cs.deliverDue()
+	bob.expect(t, from("alice", "good morning"))
+	bob.expectNone(t, 100*time.Millisecond, privateFrom("alice", "psst"))
+
+	clock.advance(time.Hour)
+	ts.cs.deliverDue()
+	bob.expect(t, privateFrom("alice", "psst"))
+
+	list, err := ts.client.ListScheduled(alice.ctx, &gs.UserRequest{Sender: "alice"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list.GetMessages()) != 0 {
+		t.Errorf("%d delivered messages are still scheduled", len(list.GetMessages()))
+	}
+}
+
+func TestScheduledMessagesReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scheduled.json")
+	ts, clock := newSchedulerServer(t, path)
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	scheduled, err := schedule(ts, alice, "", "after the restart", time.Minute)
+	if err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+
+	// the messages are read back from the json file
+	if err := ts.cs.LoadScheduledMessages(path); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	list, err := ts.client.ListScheduled(alice.ctx, &gs.UserRequest{Sender: "alice"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list.GetMessages()) != 1 || list.GetMessages()[0].GetId() != scheduled.GetId() || list.GetMessages()[0].GetDeliverAt() != scheduled.GetDeliverAt() {
+		t.Fatalf("reloaded %v, want %v", list.GetMessages(), scheduled)
+	}
+
+	// overdue messages are delivered right away
+	clock.advance(time.Hour)
+	ts.cs.deliverDue()
+	bob.expect(t, from("alice", "after the restart"))
+}
+
+func TestCancelScheduled(t *testing.T) {
+	ts, clock := newSchedulerServer(t, "")
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	scheduled, err := schedule(ts, alice, "", "never mind", time.Minute)
+	if err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+
+	// only the sender can cancel it
+	id := scheduled.GetId()
+	_, err = ts.client.CancelScheduled(bob.ctx, &gs.UserRequest{Sender: "bob", Target: &id})
+	if status.Code(err) != codes.NotFound || reasonOf(err) != gs.ReasonScheduledNotFound {
+		t.Fatalf("cancel by another user: %v, want NotFound", err)
+	}
+
+	if _, err := ts.client.CancelScheduled(alice.ctx, &gs.UserRequest{Sender: "alice", Target: &id}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if _, err := ts.client.CancelScheduled(alice.ctx, &gs.UserRequest{Sender: "alice", Target: &id}); status.Code(err) != codes.NotFound {
+		t.Fatalf("second cancel: %v, want NotFound", err)
+	}
+
+	clock.advance(time.Hour)
+	ts.cs.deliverDue()
+	bob.expectNone(t, 100*time.Millisecond, from("alice", "never mind"))
+}
+
+func TestScheduledLimit(t *testing.T) {
+	ts, _ := newSchedulerServer(t, "")
+	alice := ts.join(t, "alice")
+
+	for i := 0; i < maxScheduledPerUser; i++ {
+		if _, err := schedule(ts, alice, "", "later", time.Minute); err != nil {
+			t.Fatalf("schedule %d: %v", i, err)
+		}
+	}
+
+	_, err := schedule(ts, alice, "", "one too many", time.Minute)
+	if status.Code(err) != codes.ResourceExhausted || reasonOf(err) != gs.ReasonTooManyScheduled {
+		t.Fatalf("schedule over the limit: %v, want ResourceExhausted", err)
+	}
+}
+
+func TestScheduledMessagesAreFloodChecked(t *testing.T) {
+	ts, clock := newSchedulerServer(t, "")
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	// the third copy in a row is spam
+	for i := 0; i < ts.cs.FloodLimits.MaxDuplicates; i++ {
+		if _, err := schedule(ts, alice, "", "buy now", time.Minute); err != nil {
+			t.Fatalf("schedule: %v", err)
+		}
+	}
+
+	clock.advance(time.Minute)
+	ts.cs.deliverDue()
+	for i := 1; i < ts.cs.FloodLimits.MaxDuplicates; i++ {
+		bob.expect(t, from("alice", "buy now"))
+	}
+	bob.expectNone(t, 100*time.Millisecond, from("alice", "buy now"))
+	alice.expect(t, undelivered)
+}
+
+func TestScheduledMessagesNeedLikes(t *testing.T) {
+	ts, clock := newSchedulerServer(t, "")
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	alice.say(t, "first")
+	bob.expect(t, from("alice", "first"))
+
+	if _, err := schedule(ts, alice, "", "second", time.Minute); err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+
+	// the first message got no like when the second is due
+	ts.cs.mu.Lock()
+	ts.cs.LikesToPost = 1
+	ts.cs.mu.Unlock()
+
+	clock.advance(time.Minute)
+	ts.cs.deliverDue()
+	alice.expect(t, undelivered)
+	bob.expectNone(t, 100*time.Millisecond, from("alice", "second"))
+}
@@ -0,0 +1,264 @@
+package backend
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// password of the users registered by the tests
+const testPassword = "secret12"
+
+// how long a test waits for a message before failing
+const testTimeout = 5 * time.Second
+
+func TestMain(m *testing.M) {
+	// the server logs every request, only the test results are interesting
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// a ChatServer served over an in-memory connection, with clients of both services
+type testServer struct {
+	cs     *ChatServer
+	grpc   *grpc.Server
+	client gs.ChatRoomClient
+	admin  gs.ChatAdminClient
+}
+
+// start a server, configure is called before it serves. posting needs no likes
+func newTestServer(t *testing.T, configure func(cs *ChatServer)) *testServer {
+	t.Helper()
+
+	cs := NewChatServer(filepath.Join(t.TempDir(), "UserCredentials.json"))
+	cs.LikesToPost = 0
+	if configure != nil {
+		configure(cs)
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	gs.RegisterChatRoomServer(server, cs)
+	gs.RegisterChatAdminServer(server, NewAdminServer(cs))
+	go server.Serve(listener)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	t.Cleanup(func() {
+		cs.Shutdown()
+		conn.Close()
+		server.Stop()
+		cs.Bots.Close()
+	})
+
+	return &testServer{
+		cs:     cs,
+		grpc:   server,
+		client: gs.NewChatRoomClient(conn),
+		admin:  gs.NewChatAdminClient(conn),
+	}
+}
+
+func (ts *testServer) register(t *testing.T, username string) {
+	t.Helper()
+
+	_, err := ts.client.Register(context.Background(), &gs.User{Username: username, Password: testPassword, FullName: username})
+	if err != nil {
+		t.Fatalf("register %s: %v", username, err)
+	}
+}
+
+// log a registered user in, the context carries their session token
+func (ts *testServer) login(t *testing.T, username string) context.Context {
+	t.Helper()
+
+	result, err := ts.client.Login(context.Background(), &gs.UserLoginCredentials{Username: username, Password: testPassword})
+	if err != nil {
+		t.Fatalf("login %s: %v", username, err)
+	}
+
+	return metadata.AppendToOutgoingContext(context.Background(), gs.SessionTokenKey, result.GetToken())
+}
+
+// the session token carried by a context of login
+func tokenOf(ctx context.Context) string {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return md.Get(gs.SessionTokenKey)[0]
+}
+
+// a chat stream of a logged in user and the messages it received
+type testChat struct {
+	username string
+	ctx      context.Context
+	stream   gs.ChatRoom_ChatClient
+	messages chan *gs.ChatMessage
+}
+
+// register a user, log them in and open their chat stream
+func (ts *testServer) join(t *testing.T, username string) *testChat {
+	t.Helper()
+
+	ts.register(t, username)
+	return ts.connect(t, username)
+}
+
+// log a registered user in from a new device and open its chat stream
+func (ts *testServer) connect(t *testing.T, username string) *testChat {
+	t.Helper()
+
+	ctx := ts.login(t, username)
+
+	stream, err := ts.client.Chat(ctx)
+	if err != nil {
+		t.Fatalf("chat %s: %v", username, err)
+	}
+	if err := stream.Send(&gs.ChatMessage{Sender: username}); err != nil {
+		t.Fatalf("join %s: %v", username, err)
+	}
+
+	chat := &testChat{username: username, ctx: ctx, stream: stream, messages: make(chan *gs.ChatMessage, 1024)}
+	go func() {
+		defer close(chat.messages)
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			chat.messages <- msg
+		}
+	}()
+
+	// the stream is added to the room right after the welcome message
+	chat.expect(t, func(m *gs.ChatMessage) bool { return m.GetSender() == "Server" })
+	token := tokenOf(ctx)
+	deadline := time.Now().Add(testTimeout)
+	for {
+		ts.cs.mu.Lock()
+		_, connected := ts.cs.clientStream[username][token]
+		ts.cs.mu.Unlock()
+
+		if connected {
+			return chat
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s did not join the room", username)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// join the room as a user with role
+func (ts *testServer) joinAs(t *testing.T, username string, role gs.Role) *testChat {
+	t.Helper()
+
+	chat := ts.join(t, username)
+	if err := ts.cs.GrantRole(username, role); err != nil {
+		t.Fatalf("grant %s to %s: %v", role, username, err)
+	}
+
+	return chat
+}
+
+func (c *testChat) say(t *testing.T, message string) {
+	t.Helper()
+
+	if err := c.stream.Send(&gs.ChatMessage{Sender: c.username, Message: message}); err != nil {
+		t.Fatalf("%s say %q: %v", c.username, message, err)
+	}
+}
+
+// wait for a message matching match, the other messages are skipped
+func (c *testChat) expect(t *testing.T, match func(m *gs.ChatMessage) bool) *gs.ChatMessage {
+	t.Helper()
+
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			if !ok {
+				t.Fatalf("chat stream of %s closed", c.username)
+			}
+			if match(msg) {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("%s did not get the expected message", c.username)
+		}
+	}
+}
+
+// check that no message matching match arrives within wait
+func (c *testChat) expectNone(t *testing.T, wait time.Duration, match func(m *gs.ChatMessage) bool) {
+	t.Helper()
+
+	timeout := time.After(wait)
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			if !ok {
+				return
+			}
+			if match(msg) {
+				t.Fatalf("%s got an unexpected message from %s: %q", c.username, msg.GetSender(), msg.GetMessage())
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
+
+// wait until the session of the chat stream is ended by the server
+func (c *testChat) expectEnd(t *testing.T) {
+	t.Helper()
+
+	c.expect(t, from("Server", "Your session has ended!"))
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case _, ok := <-c.messages:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("chat stream of %s was not closed", c.username)
+		}
+	}
+}
+
+// a message from sender with the text message
+func from(sender string, message string) func(m *gs.ChatMessage) bool {
+	return func(m *gs.ChatMessage) bool {
+		return m.GetSender() == sender && m.GetMessage() == message
+	}
+}
+
+// the reason in the ErrorInfo details of an RPC error
+func reasonOf(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(interface{ GetReason() string }); ok {
+			return info.GetReason()
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,226 @@
+package backend
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// how often the idle session sweeper runs
+const sessionSweepInterval = 30 * time.Second
+
+// a logged in session (device) of a user
+type session struct {
+	id       string
+	username string
+	token    string
+	peer     string
+	loginAt  time.Time
+	lastSeen time.Time
+	done     chan struct{}
+	once     sync.Once
+}
+
+func newSession(ctx context.Context, username string) *session {
+	now := time.Now()
+	s := &session{
+		id:       GenerateSecureToken(4),
+		username: username,
+		token:    GenerateSecureToken(16),
+		loginAt:  now,
+		lastSeen: now,
+		done:     make(chan struct{}),
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		s.peer = p.Addr.String()
+	}
+
+	return s
+}
+
+// close the session, stopping the chat stream bound to it (if any)
+func (s *session) close() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+// get the session token from the incoming request metadata
+func sessionTokenFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get(gs.SessionTokenKey)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// find the session of the calling client, it must belong to username
+func (cs *ChatServer) authenticate(ctx context.Context, username string) (*session, error) {
+	token := sessionTokenFromContext(ctx)
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	s, ok := cs.loggedInAccount[username][token]
+	if !ok || token == "" {
+		return nil, rpcError(codes.Unauthenticated, gs.ReasonNotLoggedIn, "User %s is not logged in from this client!", username)
+	}
+
+	s.lastSeen = time.Now()
+	return s, nil
+}
+
+// register a new session of a user. cs.mu must be held by the caller
+func (cs *ChatServer) addSession(s *session) {
+	if _, ok := cs.loggedInAccount[s.username]; !ok {
+		cs.loggedInAccount[s.username] = make(map[string]*session)
+	}
+
+	cs.loggedInAccount[s.username][s.token] = s
+}
+
+// remove a session from the logged in accounts and stop its chat stream.
+// cs.mu must be held by the caller
+func (cs *ChatServer) endSession(s *session, reason string) {
+	sessions := cs.loggedInAccount[s.username]
+	delete(sessions, s.token)
+	if len(sessions) == 0 {
+		delete(cs.loggedInAccount, s.username)
+	}
+
+	s.close()
+	slog.Info("Session ended", "user", s.username, "session", s.id, "reason", reason)
+}
+
+// end the sessions of a deleted account and forget its state, so a new account with the
+// same name starts clean. cs.mu must be held by the caller
+func (cs *ChatServer) forgetUser(username string, reason string) {
+	cs.endAllSessions(username, reason)
+	delete(cs.messageLikes, username)
+	delete(cs.mutedUntil, username)
+	delete(cs.flood, username)
+	cs.history.removePrivate(username)
+	cs.mentions.removeIf(username, func(m *mention) bool { return true })
+}
+
+// end every session of a user. cs.mu must be held by the caller
+func (cs *ChatServer) endAllSessions(username string, reason string) {
+	for _, s := range cs.loggedInAccount[username] {
+		cs.endSession(s, reason)
+	}
+}
+
+// end every session so the chat streams return, used when the server shuts down
+func (cs *ChatServer) Shutdown() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	for username := range cs.loggedInAccount {
+		cs.endAllSessions(username, "server shutdown")
+	}
+}
+
+// periodically expire logged in sessions that never opened a chat stream
+func (cs *ChatServer) expireIdleSessions() {
+	tick := time.NewTicker(sessionSweepInterval)
+	defer tick.Stop()
+
+	for range tick.C {
+		cs.expireIdle()
+	}
+}
+
+// end the sessions without a chat stream that were not used for SessionIdleTimeout
+func (cs *ChatServer) expireIdle() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.SessionIdleTimeout <= 0 {
+		return
+	}
+
+	for username, sessions := range cs.loggedInAccount {
+		for token, s := range sessions {
+			if _, streaming := cs.clientStream[username][token]; streaming {
+				continue
+			}
+
+			if time.Since(s.lastSeen) > cs.SessionIdleTimeout {
+				cs.endSession(s, "idle without chat stream")
+			}
+		}
+	}
+}
+
+// list every logged in session of the calling user
+func (cs *ChatServer) ListSessions(ctx context.Context, request *gs.UserRequest) (*gs.SessionList, error) {
+	username := request.GetSender()
+
+	current, err := cs.authenticate(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	result := &gs.SessionList{}
+	for token, s := range cs.loggedInAccount[username] {
+		_, streaming := cs.clientStream[username][token]
+		result.Session = append(result.Session, &gs.SessionInfo{
+			Id:        s.id,
+			Peer:      s.peer,
+			LoginAt:   s.loginAt.Unix(),
+			LastSeen:  s.lastSeen.Unix(),
+			Streaming: streaming,
+			Current:   s == current,
+		})
+	}
+
+	return result, nil
+}
+
+// end one of the sessions of the calling user by its id
+func (cs *ChatServer) RevokeSession(ctx context.Context, request *gs.UserRequest) (*gs.AuthenticationResult, error) {
+	username := request.GetSender()
+	id := request.GetTarget()
+
+	slog.Info("Session revoke request", "user", username, "session", id)
+
+	if _, err := cs.authenticate(ctx, username); err != nil {
+		return nil, err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	for _, s := range cs.loggedInAccount[username] {
+		if s.id != id {
+			continue
+		}
+
+		cs.endSession(s, "revoked by "+username)
+
+		msg := "Session " + id + " has been revoked!"
+		return &gs.AuthenticationResult{
+			Username: username,
+			Status:   int32(codes.OK),
+			Message:  &msg,
+		}, nil
+	}
+
+	return nil, rpcError(codes.NotFound, gs.ReasonSessionNotFound, "Session %s not found!", id)
+}
@@ -0,0 +1,134 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// check that the session of chat cannot be used anymore
+func expectLoggedOut(t *testing.T, ts *testServer, chat *testChat) {
+	t.Helper()
+
+	_, err := ts.client.ListSessions(chat.ctx, &gs.UserRequest{Sender: chat.username})
+	if status.Code(err) != codes.Unauthenticated || reasonOf(err) != gs.ReasonNotLoggedIn {
+		t.Errorf("session of %s still works: %v", chat.username, err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	ts := newTestServer(t, nil)
+	alice := ts.join(t, "alice")
+	bob := ts.join(t, "bob")
+
+	if _, err := ts.client.Logout(alice.ctx, &gs.UserRequest{Sender: "alice"}); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+
+	alice.expectEnd(t)
+	expectLoggedOut(t, ts, alice)
+	bob.expect(t, from("Server", "User alice has logged out!"))
+
+	if _, err := ts.client.Logout(alice.ctx, &gs.UserRequest{Sender: "alice"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("second logout: %v, want Unauthenticated", err)
+	}
+}
+
+func TestIdleSessionExpiry(t *testing.T) {
+	ts := newTestServer(t, nil)
+	ts.register(t, "alice")
+	streaming := ts.connect(t, "alice")
+	idle := &testChat{username: "alice", ctx: ts.login(t, "alice")}
+
+	// both sessions were last used long ago, only the one with a chat stream is kept
+	ts.cs.mu.Lock()
+	for _, s := range ts.cs.loggedInAccount["alice"] {
+		s.lastSeen = time.Now().Add(-2 * ts.cs.SessionIdleTimeout)
+	}
+	ts.cs.mu.Unlock()
+	ts.cs.expireIdle()
+
+	expectLoggedOut(t, ts, idle)
+	if _, err := ts.client.ListSessions(streaming.ctx, &gs.UserRequest{Sender: "alice"}); err != nil {
+		t.Errorf("session with a chat stream expired: %v", err)
+	}
+}
+
+func TestKickOtherSession(t *testing.T) {
+	ts := newTestServer(t, nil)
+	first := ts.join(t, "alice")
+
+	kick := true
+	result, err := ts.client.Login(first.ctx, &gs.UserLoginCredentials{Username: "alice", Password: testPassword, KickOtherSession: &kick})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	first.expectEnd(t)
+	expectLoggedOut(t, ts, first)
+
+	ts.cs.mu.Lock()
+	_, ok := ts.cs.loggedInAccount["alice"][result.GetToken()]
+	ts.cs.mu.Unlock()
+	if !ok {
+		t.Errorf("the new session was ended too")
+	}
+}
+
+func TestMultipleDevices(t *testing.T) {
+	ts := newTestServer(t, nil)
+	laptop := ts.join(t, "alice")
+	phone := ts.connect(t, "alice")
+	bob := ts.join(t, "bob")
+
+	// room messages reach every device, and the other devices of the sender
+	bob.say(t, "hi alice")
+	laptop.expect(t, from("bob", "hi alice"))
+	phone.expect(t, from("bob", "hi alice"))
+
+	laptop.say(t, "hi bob")
+	bob.expect(t, from("alice", "hi bob"))
+	phone.expect(t, from("alice", "hi bob"))
+	laptop.expectNone(t, 100*time.Millisecond, from("alice", "hi bob"))
+
+	_, err := ts.client.SendPrivateMessage(bob.ctx, &gs.PrivateChatMessage{Sender: "bob", Recipent: "alice", Message: "psst"})
+	if err != nil {
+		t.Fatalf("private message: %v", err)
+	}
+	laptop.expect(t, privateFrom("bob", "psst"))
+	phone.expect(t, privateFrom("bob", "psst"))
+
+	sessions, err := ts.client.ListSessions(laptop.ctx, &gs.UserRequest{Sender: "alice"})
+	if err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+	if len(sessions.GetSession()) != 2 {
+		t.Fatalf("%d sessions, want 2", len(sessions.GetSession()))
+	}
+
+	// end the session of the phone from the laptop
+	var phoneID string
+	for _, s := range sessions.GetSession() {
+		if !s.GetStreaming() {
+			t.Errorf("session %s has no chat stream", s.GetId())
+		}
+		if !s.GetCurrent() {
+			phoneID = s.GetId()
+		}
+	}
+	// another user cannot end it
+	if _, err := ts.client.RevokeSession(bob.ctx, &gs.UserRequest{Sender: "bob", Target: &phoneID}); status.Code(err) != codes.NotFound {
+		t.Errorf("revoke of a session of another user: %v, want NotFound", err)
+	}
+	if _, err := ts.client.RevokeSession(laptop.ctx, &gs.UserRequest{Sender: "alice", Target: &phoneID}); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	phone.expectEnd(t)
+	expectLoggedOut(t, ts, phone)
+	bob.say(t, "still there?")
+	laptop.expect(t, from("bob", "still there?"))
+}
@@ -0,0 +1,274 @@
+package backend
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+)
+
+// RFC 6238 parameters, the defaults every authenticator app supports
+const (
+	totpIssuer = "gRPC-ChatRoom"
+	totpPeriod = 30
+	totpDigits = 6
+	// accepted clock drift between the server and the authenticator, in periods
+	totpSkew = 1
+)
+
+// how many recovery codes are given on enrollment
+const recoveryCodeCount = 10
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// the RFC 4226 HOTP value of secret for counter
+func hotp(secret []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+// the time step of a TOTP code that matches, checking the steps around now.
+// steps up to lastStep were used already and are refused
+func verifyTOTP(secret string, code string, now time.Time, lastStep int64) (int64, bool) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+
+		if hmac.Equal([]byte(hotp(key, uint64(step))), []byte(code)) {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// the otpauth:// URI authenticator apps scan to add an account
+func provisioningURI(username string, secret string) string {
+	label := url.PathEscape(totpIssuer + ":" + username)
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", totpIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+// a random base32 string of n bytes
+func randomBase32(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base32NoPadding.EncodeToString(b), nil
+}
+
+// recovery codes are stored hashed, ignoring case and dashes
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// check a TOTP or recovery code of a user with 2FA, using up the code.
+// must be called from a userStore update callback
+func (cs *ChatServer) useSecondFactor(user *gs.User, code string) error {
+	invalid := rpcError(codes.Unauthenticated, gs.ReasonInvalidCode, "The code is wrong or has expired!")
+
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return invalid
+	}
+
+	if step, ok := verifyTOTP(user.GetTotpSecret(), code, cs.Clock(), user.GetTotpLastStep()); ok {
+		user.TotpLastStep = &step
+		return nil
+	}
+
+	hash := hashRecoveryCode(code)
+	for i, stored := range user.GetRecoveryCodes() {
+		if hmac.Equal([]byte(stored), []byte(hash)) {
+			user.RecoveryCodes = append(user.RecoveryCodes[:i], user.RecoveryCodes[i+1:]...)
+			slog.Info("Recovery code used", "user", user.GetUsername(), "left", len(user.RecoveryCodes))
+			return nil
+		}
+	}
+
+	return invalid
+}
+
+// check the second factor of a login. cs.mu must not be held by the caller
+func (cs *ChatServer) checkSecondFactor(username string, code string) error {
+	if code == "" {
+		return rpcError(codes.FailedPrecondition, gs.ReasonTOTPRequired, "Failed to login as %s: A two-factor code is required!", username)
+	}
+
+	err := cs.users.update(username, func(user *gs.User) error {
+		return cs.useSecondFactor(user, code)
+	})
+
+	return storeError(err, username)
+}
+
+func totpResult(username string, msg string) *gs.AuthenticationResult {
+	slog.Info(msg)
+	return &gs.AuthenticationResult{
+		Username: username,
+		Status:   int32(codes.OK),
+		Message:  &msg,
+	}
+}
+
+// ---------------------------------------------------------//
+// ------------------------- RPC ---------------------------//
+
+// start two-factor authentication setup, replacing a previous unconfirmed enrollment
+func (cs *ChatServer) EnrollTOTP(ctx context.Context, request *gs.UserRequest) (*gs.TOTPEnrollment, error) {
+	username := request.GetSender()
+	slog.Info("TOTP enrollment request", "user", username)
+
+	if _, err := cs.authenticate(ctx, username); err != nil {
+		return nil, err
+	}
+
+	secret, err := randomBase32(20)
+	if err != nil {
+		return nil, rpcError(codes.Internal, gs.ReasonStoreFailure, "Failed to generate a secret: %v", err)
+	}
+
+	enrollment := &gs.TOTPEnrollment{
+		Secret:          secret,
+		ProvisioningUri: provisioningURI(username, secret),
+	}
+
+	var hashes []string
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := randomBase32(5)
+		if err != nil {
+			return nil, rpcError(codes.Internal, gs.ReasonStoreFailure, "Failed to generate a recovery code: %v", err)
+		}
+
+		code = strings.ToLower(code[:4] + "-" + code[4:])
+		enrollment.RecoveryCodes = append(enrollment.RecoveryCodes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	err = cs.users.update(username, func(user *gs.User) error {
+		if user.GetTotpEnabled() {
+			return rpcError(codes.FailedPrecondition, gs.ReasonTOTPAlreadyEnabled, "Two-factor authentication is already enabled for %s!", username)
+		}
+
+		user.TotpSecret = &secret
+		user.RecoveryCodes = hashes
+		user.TotpLastStep = nil
+		return nil
+	})
+	if err != nil {
+		return nil, storeError(err, username)
+	}
+
+	return enrollment, nil
+}
+
+// enable two-factor authentication with a code of the enrolled secret
+func (cs *ChatServer) ConfirmTOTP(ctx context.Context, request *gs.TOTPCode) (*gs.AuthenticationResult, error) {
+	username := request.GetSender()
+	slog.Info("TOTP confirmation request", "user", username)
+
+	if _, err := cs.authenticate(ctx, username); err != nil {
+		return nil, err
+	}
+
+	err := cs.users.update(username, func(user *gs.User) error {
+		if user.GetTotpEnabled() {
+			return rpcError(codes.FailedPrecondition, gs.ReasonTOTPAlreadyEnabled, "Two-factor authentication is already enabled for %s!", username)
+		}
+		if user.TotpSecret == nil {
+			return rpcError(codes.FailedPrecondition, gs.ReasonTOTPNotEnabled, "Call EnrollTOTP before confirming a code!")
+		}
+
+		// only a code of the authenticator proves the secret was saved
+		step, ok := verifyTOTP(user.GetTotpSecret(), strings.TrimSpace(request.GetCode()), cs.Clock(), user.GetTotpLastStep())
+		if !ok {
+			return rpcError(codes.InvalidArgument, gs.ReasonInvalidCode, "The code is wrong or has expired!")
+		}
+
+		enabled := true
+		user.TotpEnabled = &enabled
+		user.TotpLastStep = &step
+		return nil
+	})
+	if err != nil {
+		return nil, storeError(err, username)
+	}
+
+	slog.Warn("Two-factor authentication enabled", "audit", true, "user", username)
+	return totpResult(username, fmt.Sprintf("Two-factor authentication is enabled for %s!", username)), nil
+}
+
+// disable two-factor authentication, a TOTP or recovery code is required
+func (cs *ChatServer) DisableTOTP(ctx context.Context, request *gs.TOTPCode) (*gs.AuthenticationResult, error) {
+	username := request.GetSender()
+	slog.Info("TOTP disable request", "user", username)
+
+	if _, err := cs.authenticate(ctx, username); err != nil {
+		return nil, err
+	}
+
+	err := cs.users.update(username, func(user *gs.User) error {
+		if !user.GetTotpEnabled() {
+			return rpcError(codes.FailedPrecondition, gs.ReasonTOTPNotEnabled, "Two-factor authentication is not enabled for %s!", username)
+		}
+
+		if err := cs.useSecondFactor(user, request.GetCode()); err != nil {
+			return err
+		}
+
+		user.TotpEnabled = nil
+		user.TotpSecret = nil
+		user.TotpLastStep = nil
+		user.RecoveryCodes = nil
+		return nil
+	})
+	if err != nil {
+		return nil, storeError(err, username)
+	}
+
+	slog.Warn("Two-factor authentication disabled", "audit", true, "user", username)
+	return totpResult(username, fmt.Sprintf("Two-factor authentication is disabled for %s!", username)), nil
+}
@@ -0,0 +1,136 @@
+package backend
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the SHA1 secret of the RFC 6238 test vectors
+var rfc6238Secret = base32NoPadding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPVectors(t *testing.T) {
+	// RFC 6238 appendix B, the last 6 of the 8 digits
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, v := range vectors {
+		step, ok := verifyTOTP(rfc6238Secret, v.code, time.Unix(v.unix, 0), 0)
+		if !ok || step != v.unix/totpPeriod {
+			t.Errorf("code %s at %d: step %d, %v, want step %d", v.code, v.unix, step, ok, v.unix/totpPeriod)
+		}
+	}
+
+	// authenticator apps show the secret in lower case too
+	if _, ok := verifyTOTP(strings.ToLower(rfc6238Secret), "287082", time.Unix(59, 0), 0); !ok {
+		t.Errorf("a lower case secret was refused")
+	}
+}
+
+func TestTOTPSkew(t *testing.T) {
+	// 1234567890 is in the middle of its step
+	at := time.Unix(1234567890, 0)
+	for _, drift := range []time.Duration{-totpPeriod * time.Second, 0, totpPeriod * time.Second} {
+		if _, ok := verifyTOTP(rfc6238Secret, "005924", at.Add(drift), 0); !ok {
+			t.Errorf("code refused with a drift of %s", drift)
+		}
+	}
+	for _, drift := range []time.Duration{-2 * totpPeriod * time.Second, 2 * totpPeriod * time.Second} {
+		if _, ok := verifyTOTP(rfc6238Secret, "005924", at.Add(drift), 0); ok {
+			t.Errorf("code accepted with a drift of %s", drift)
+		}
+	}
+}
+
+func TestTOTPReplay(t *testing.T) {
+	at := time.Unix(1234567890, 0)
+	step, ok := verifyTOTP(rfc6238Secret, "005924", at, 0)
+	if !ok {
+		t.Fatalf("code refused")
+	}
+
+	// the step was used, and so were the steps before it
+	if _, ok := verifyTOTP(rfc6238Secret, "005924", at, step); ok {
+		t.Errorf("a used code was accepted again")
+	}
+	if _, ok := verifyTOTP(rfc6238Secret, "050471", time.Unix(1111111111, 0), step); ok {
+		t.Errorf("a code older than the last used one was accepted")
+	}
+}
+
+// the current code of secret at the time of clock
+func currentCode(t *testing.T, secret string, clock *fakeClock) string {
+	t.Helper()
+
+	key, err := base32NoPadding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q: %v", secret, err)
+	}
+
+	return hotp(key, uint64(clock.Now().Unix()/totpPeriod))
+}
+
+func loginWithCode(ts *testServer, username string, code string) error {
+	_, err := ts.client.Login(context.Background(), &gs.UserLoginCredentials{Username: username, Password: testPassword, TotpCode: &code})
+	return err
+}
+
+func TestTOTPLogin(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1234567890, 0)}
+	ts := newTestServer(t, func(cs *ChatServer) { cs.Clock = clock.Now })
+	ts.register(t, "alice")
+	ctx := ts.login(t, "alice")
+
+	enrollment, err := ts.client.EnrollTOTP(ctx, &gs.UserRequest{Sender: "alice"})
+	if err != nil {
+		t.Fatalf("enroll: %v", err)
+	}
+	if len(enrollment.GetRecoveryCodes()) != recoveryCodeCount {
+		t.Fatalf("%d recovery codes, want %d", len(enrollment.GetRecoveryCodes()), recoveryCodeCount)
+	}
+
+	code := currentCode(t, enrollment.GetSecret(), clock)
+	if _, err := ts.client.ConfirmTOTP(ctx, &gs.TOTPCode{Sender: "alice", Code: code}); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+
+	// the code that confirmed the enrollment cannot log in
+	if err := loginWithCode(ts, "alice", code); status.Code(err) != codes.Unauthenticated || reasonOf(err) != gs.ReasonInvalidCode {
+		t.Fatalf("login with a used code: %v, want Unauthenticated", err)
+	}
+
+	clock.advance(totpPeriod * time.Second)
+	code = currentCode(t, enrollment.GetSecret(), clock)
+	if err := loginWithCode(ts, "alice", code); err != nil {
+		t.Fatalf("login with the next code: %v", err)
+	}
+	if err := loginWithCode(ts, "alice", code); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("second login with the same code: %v, want Unauthenticated", err)
+	}
+
+	// a recovery code works once, in any case and without its dash
+	recovery := enrollment.GetRecoveryCodes()[0]
+	if err := loginWithCode(ts, "alice", strings.ToUpper(strings.ReplaceAll(recovery, "-", ""))); err != nil {
+		t.Fatalf("login with a recovery code: %v", err)
+	}
+	if err := loginWithCode(ts, "alice", recovery); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("second login with a recovery code: %v, want Unauthenticated", err)
+	}
+	if left := len(ts.cs.users.find("alice").GetRecoveryCodes()); left != recoveryCodeCount-1 {
+		t.Errorf("%d recovery codes left, want %d", left, recoveryCodeCount-1)
+	}
+}
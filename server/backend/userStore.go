@@ -0,0 +1,152 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	errUserNotFound = errors.New("user not found")
+	errUserExists   = errors.New("username is already taken")
+)
+
+// registered accounts, persisted to a json file
+type userStore struct {
+	accounts gs.UserList
+	path     string
+	mu       sync.Mutex
+}
+
+func newUserStore(path string) *userStore {
+	return &userStore{path: path}
+}
+
+// Load credentials from the json file
+func (us *userStore) load() error {
+	// Read the JSON file
+	jsonData, err := os.ReadFile(us.path)
+	if err != nil {
+		return err
+	}
+
+	// Unmarshal the JSON data into UserList
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	return json.Unmarshal(jsonData, &us.accounts)
+}
+
+// write the user information to the json file. us.mu must be held by the caller
+func (us *userStore) save() error {
+	jsonData, err := json.MarshalIndent(&us.accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(us.path, jsonData, 0644)
+}
+
+// index of a user in the account list, -1 if not registered. us.mu must be held by the caller
+func (us *userStore) indexOf(username string) int {
+	for index, user := range us.accounts.User {
+		if user.Username == username {
+			return index
+		}
+	}
+
+	return -1
+}
+
+// get a copy of a registered user, nil if not found
+func (us *userStore) find(username string) *gs.User {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	index := us.indexOf(username)
+	if index < 0 {
+		return nil
+	}
+
+	return proto.Clone(us.accounts.User[index]).(*gs.User)
+}
+
+// get a copy of every registered user
+func (us *userStore) list() []*gs.User {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	users := make([]*gs.User, 0, len(us.accounts.User))
+	for _, user := range us.accounts.User {
+		users = append(users, proto.Clone(user).(*gs.User))
+	}
+
+	return users
+}
+
+// register a new user and persist the store
+func (us *userStore) add(user *gs.User) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	if us.indexOf(user.Username) >= 0 {
+		return errUserExists
+	}
+
+	us.accounts.User = append(us.accounts.User, user)
+	if err := us.save(); err != nil {
+		us.accounts.User = us.accounts.User[:len(us.accounts.User)-1]
+		return err
+	}
+
+	return nil
+}
+
+// modify a registered user in place and persist the store
+func (us *userStore) update(username string, modify func(user *gs.User) error) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	index := us.indexOf(username)
+	if index < 0 {
+		return errUserNotFound
+	}
+
+	// modify a copy so a failed update leaves the store untouched
+	user := proto.Clone(us.accounts.User[index]).(*gs.User)
+	if err := modify(user); err != nil {
+		return err
+	}
+
+	previous := us.accounts.User[index]
+	us.accounts.User[index] = user
+	if err := us.save(); err != nil {
+		us.accounts.User[index] = previous
+		return err
+	}
+
+	return nil
+}
+
+// delete a registered user and persist the store
+func (us *userStore) remove(username string) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	index := us.indexOf(username)
+	if index < 0 {
+		return errUserNotFound
+	}
+
+	previous := us.accounts.User
+	us.accounts.User = append(append([]*gs.User{}, previous[:index]...), previous[index+1:]...)
+	if err := us.save(); err != nil {
+		us.accounts.User = previous
+		return err
+	}
+
+	return nil
+}
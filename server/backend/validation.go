@@ -0,0 +1,207 @@
+package backend
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Rules applied to registration, profile and password data
+type ValidationRules struct {
+	UsernameMinLength int
+	UsernameMaxLength int
+	// allowed characters of a username
+	UsernamePattern *regexp.Regexp
+	// usernames nobody can register, compared case-insensitively
+	ReservedUsernames []string
+
+	PasswordMinLength    int
+	PasswordRequireUpper bool
+	PasswordRequireLower bool
+	PasswordRequireDigit bool
+	PasswordRequireOther bool
+
+	FullNameMaxLength int
+}
+
+// the rules used when nothing else is configured
+func DefaultValidationRules() ValidationRules {
+	return ValidationRules{
+		UsernameMinLength: 3,
+		UsernameMaxLength: 32,
+		UsernamePattern:   regexp.MustCompile(`^[A-Za-z0-9_.-]+$`),
+		// the client shows these names for its own and server messages
+		ReservedUsernames: []string{"Server", "You", "admin", "root", "system"},
+
+		PasswordMinLength:    8,
+		PasswordRequireLower: true,
+		PasswordRequireDigit: true,
+
+		FullNameMaxLength: 64,
+	}
+}
+
+// per-field errors, sent to the client as errdetails.BadRequest
+type fieldViolations []*errdetails.BadRequest_FieldViolation
+
+func (v *fieldViolations) add(field string, description string) {
+	*v = append(*v, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: description,
+	})
+}
+
+// an InvalidArgument error carrying the violations, nil if there are none
+func (v fieldViolations) err(msg string) error {
+	if len(v) == 0 {
+		return nil
+	}
+
+	st, detailErr := status.New(codes.InvalidArgument, msg).WithDetails(errorInfo(gs.ReasonInvalidArgument), &errdetails.BadRequest{
+		FieldViolations: v,
+	})
+	if detailErr != nil {
+		return status.Error(codes.InvalidArgument, msg)
+	}
+
+	return st.Err()
+}
+
+// ---------------------------------------------------------//
+// ------------------ RULES --------------------------------//
+
+func (rules ValidationRules) checkUsername(v *fieldViolations, username string) {
+	length := len([]rune(username))
+
+	switch {
+	case username == "":
+		v.add("username", "Username is required")
+	case length < rules.UsernameMinLength || length > rules.UsernameMaxLength:
+		v.add("username", fmt.Sprintf("Username must be %d to %d characters long", rules.UsernameMinLength, rules.UsernameMaxLength))
+	case rules.UsernamePattern != nil && !rules.UsernamePattern.MatchString(username):
+		v.add("username", "Username contains characters that are not allowed")
+	}
+
+	for _, reserved := range rules.ReservedUsernames {
+		if strings.EqualFold(username, reserved) {
+			v.add("username", fmt.Sprintf("Username %s is reserved", username))
+			break
+		}
+	}
+}
+
+func (rules ValidationRules) checkPassword(v *fieldViolations, field string, password string) {
+	if password == "" {
+		v.add(field, "Password is required")
+		return
+	}
+
+	if len([]rune(password)) < rules.PasswordMinLength {
+		v.add(field, fmt.Sprintf("Password must be at least %d characters long", rules.PasswordMinLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasOther bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasOther = true
+		}
+	}
+
+	if rules.PasswordRequireUpper && !hasUpper {
+		v.add(field, "Password must contain an uppercase letter")
+	}
+	if rules.PasswordRequireLower && !hasLower {
+		v.add(field, "Password must contain a lowercase letter")
+	}
+	if rules.PasswordRequireDigit && !hasDigit {
+		v.add(field, "Password must contain a digit")
+	}
+	if rules.PasswordRequireOther && !hasOther {
+		v.add(field, "Password must contain a symbol")
+	}
+}
+
+// check full name, email, birthdate and address, a birthdate cannot be after now
+func (rules ValidationRules) checkProfile(v *fieldViolations, user *gs.User, now time.Time) {
+	fullName := strings.TrimSpace(user.GetFullName())
+	if fullName == "" {
+		v.add("full_name", "Full name is required")
+	} else if len([]rune(fullName)) > rules.FullNameMaxLength {
+		v.add("full_name", fmt.Sprintf("Full name must be at most %d characters long", rules.FullNameMaxLength))
+	}
+
+	// RFC 5322 address, without a display name
+	if user.Email != nil {
+		address, err := mail.ParseAddress(user.GetEmail())
+		if err != nil || address.Address != user.GetEmail() {
+			v.add("email", "Email is not a valid address")
+		}
+	}
+
+	// ISO 8601 calendar date
+	if user.Birthdate != nil {
+		birthdate, err := time.Parse(time.DateOnly, user.GetBirthdate())
+		if err != nil {
+			v.add("birthdate", "Birthdate must be a date like 2000-12-31")
+		} else if birthdate.After(now) || birthdate.Year() < 1900 {
+			v.add("birthdate", "Birthdate is out of range")
+		}
+	}
+
+	// ISO 3166-1 alpha-2 country code
+	if user.Address != nil {
+		country := user.GetAddress().GetCountry()
+		if country == "" {
+			v.add("address.country", "Country is required in an address")
+		} else if !countryCodes[strings.ToUpper(country)] {
+			v.add("address.country", "Country must be a 2-letter ISO 3166 code like VN")
+		}
+	}
+}
+
+// ---------------------------------------------------------//
+
+// check a new account before it is registered
+func (cs *ChatServer) validateRegistration(user *gs.User) error {
+	var v fieldViolations
+	cs.Validation.checkUsername(&v, user.GetUsername())
+	cs.Validation.checkPassword(&v, "password", user.GetPassword())
+	cs.Validation.checkProfile(&v, user, cs.Clock())
+
+	if cs.RequireEmailVerification && user.Email == nil {
+		v.add("email", "Email is required to verify the account")
+	}
+
+	return v.err("Invalid registration data!")
+}
+
+// check profile data before it is updated
+func (cs *ChatServer) validateProfile(user *gs.User) error {
+	var v fieldViolations
+	cs.Validation.checkProfile(&v, user, cs.Clock())
+
+	return v.err("Invalid profile data!")
+}
+
+// check a new password, field is the name of the request field holding it
+func (cs *ChatServer) validatePassword(field string, password string) error {
+	var v fieldViolations
+	cs.Validation.checkPassword(&v, field, password)
+
+	return v.err("Invalid password!")
+}
@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the fields of the violations, in order
+func violatedFields(v fieldViolations) []string {
+	var fields []string
+	for _, violation := range v {
+		fields = append(fields, violation.GetField())
+	}
+
+	return fields
+}
+
+// the fields of the BadRequest details of an RPC error
+func badRequestFields(err error) []string {
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			return violatedFields(badRequest.GetFieldViolations())
+		}
+	}
+
+	return nil
+}
+
+func TestCheckUsername(t *testing.T) {
+	rules := DefaultValidationRules()
+	for username, valid := range map[string]bool{
+		"alice":  true,
+		"a.b-c_": true,
+		"":       false,
+		"al":     false,
+		"al ice": false,
+		"Server": false,
+		"ROOT":   false,
+	} {
+		var v fieldViolations
+		rules.checkUsername(&v, username)
+		if (len(v) == 0) != valid {
+			t.Errorf("username %q: violations %v, valid %v", username, v, valid)
+		}
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	rules := DefaultValidationRules()
+	for password, valid := range map[string]bool{
+		testPassword: true,
+		"":           false,
+		"short1":     false,
+		"password":   false,
+		"PASSWORD1":  false,
+	} {
+		var v fieldViolations
+		rules.checkPassword(&v, "password", password)
+		if (len(v) == 0) != valid {
+			t.Errorf("password %q: violations %v, valid %v", password, v, valid)
+		}
+	}
+}
+
+func TestCheckProfile(t *testing.T) {
+	rules := DefaultValidationRules()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	profile := func(birthdate string, email string, country string) *gs.User {
+		return &gs.User{FullName: "Alice", Birthdate: &birthdate, Email: &email, Address: &gs.Address{Country: country}}
+	}
+
+	tests := []struct {
+		user   *gs.User
+		fields []string
+	}{
+		{profile("2024-06-01", "alice@example.com", "vn"), nil},
+		{profile("2024-06-02", "alice@example.com", "VN"), []string{"birthdate"}},
+		{profile("1899-12-31", "alice@example.com", "VN"), []string{"birthdate"}},
+		{profile("01/06/2000", "Alice <alice@example.com>", "XX"), []string{"email", "birthdate", "address.country"}},
+		{&gs.User{FullName: " ", Address: &gs.Address{}}, []string{"full_name", "address.country"}},
+	}
+
+	for _, test := range tests {
+		var v fieldViolations
+		rules.checkProfile(&v, test.user, now)
+		if fields := violatedFields(v); !reflect.DeepEqual(fields, test.fields) {
+			t.Errorf("profile %v: violated %v, want %v", test.user, fields, test.fields)
+		}
+	}
+}
+
+func TestRegisterFieldViolations(t *testing.T) {
+	ts := newTestServer(t, nil)
+
+	_, err := ts.client.Register(context.Background(), &gs.User{Username: "al", Password: "short", FullName: "Al"})
+	if status.Code(err) != codes.InvalidArgument || reasonOf(err) != gs.ReasonInvalidArgument {
+		t.Fatalf("register: %v, want InvalidArgument", err)
+	}
+
+	// every broken rule is reported at once
+	want := []string{"username", "password", "password"}
+	if fields := badRequestFields(err); !reflect.DeepEqual(fields, want) {
+		t.Errorf("violated %v, want %v", fields, want)
+	}
+}
+
+func TestProfileBirthdateUsesClock(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)}
+	ts := newTestServer(t, func(cs *ChatServer) { cs.Clock = clock.Now })
+	ts.register(t, "alice")
+	ctx := ts.login(t, "alice")
+
+	birthdate := "2010-01-01"
+	_, err := ts.client.UpdateProfile(ctx, &gs.User{Username: "alice", FullName: "Alice", Birthdate: &birthdate})
+	if fields := badRequestFields(err); !reflect.DeepEqual(fields, []string{"birthdate"}) {
+		t.Errorf("birthdate after the clock: %v, want a birthdate violation", err)
+	}
+}
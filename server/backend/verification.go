@@ -0,0 +1,311 @@
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+)
+
+// what a one-time code can be used for
+type codePurpose int
+
+const (
+	codeVerifyEmail codePurpose = iota
+	codeResetPassword
+)
+
+// wrong guesses allowed before a code is thrown away
+const maxCodeAttempts = 5
+
+// codes of each purpose a user can be sent within codeRequestWindow
+const (
+	maxCodeRequests   = 5
+	codeRequestWindow = time.Hour
+)
+
+type codeKey struct {
+	username string
+	purpose  codePurpose
+}
+
+// a one-time code sent by email
+type oneTimeCode struct {
+	code string
+	// the address the code was sent to
+	email    string
+	expires  time.Time
+	attempts int
+}
+
+// same answer whether or not the account exists, so usernames cannot be probed
+const codeSentMessage = "If the account has an email address, a code was sent to it!"
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// a random 6 digit code
+func generateCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// check that the server can send emails
+func (cs *ChatServer) checkMailer() error {
+	if cs.Mailer == nil {
+		return rpcError(codes.FailedPrecondition, gs.ReasonMailFailure, "Email is not configured on this server!")
+	}
+
+	return nil
+}
+
+// create a code for username, replacing the previous one, and email it.
+// only maxCodeRequests codes are sent within codeRequestWindow, so codes cannot be guessed one after another
+func (cs *ChatServer) sendCode(username string, email string, purpose codePurpose) error {
+	if err := cs.checkMailer(); err != nil {
+		return err
+	}
+
+	code, err := generateCode()
+	if err != nil {
+		return rpcError(codes.Internal, gs.ReasonMailFailure, "Failed to generate a code: %v", err)
+	}
+
+	key := codeKey{username, purpose}
+	now := time.Now()
+
+	cs.mu.Lock()
+	var sent []time.Time
+	for _, at := range cs.codesSent[key] {
+		if now.Sub(at) < codeRequestWindow {
+			sent = append(sent, at)
+		}
+	}
+
+	if len(sent) >= maxCodeRequests {
+		cs.codesSent[key] = sent
+		cs.mu.Unlock()
+		return retryableError(codes.ResourceExhausted, gs.ReasonTooManyAttempts, sent[0].Add(codeRequestWindow).Sub(now), "Too many codes were requested, try again later!")
+	}
+
+	cs.codesSent[key] = append(sent, now)
+	cs.oneTimeCodes[key] = &oneTimeCode{
+		code:    code,
+		email:   email,
+		expires: now.Add(cs.CodeTTL),
+	}
+	cs.mu.Unlock()
+
+	var subject, body string
+	switch purpose {
+	case codeVerifyEmail:
+		subject = "Verify your gRPC Chat Room email"
+		body = fmt.Sprintf("Hello %s,\r\n\r\nYour email verification code is %s\r\nIt expires in %s.", username, code, cs.CodeTTL)
+	case codeResetPassword:
+		subject = "Reset your gRPC Chat Room password"
+		body = fmt.Sprintf("Hello %s,\r\n\r\nYour password reset code is %s\r\nIt expires in %s. Ignore this email if you did not ask for it.", username, code, cs.CodeTTL)
+	}
+
+	if err := cs.Mailer.Send(email, subject, body); err != nil {
+		slog.Error("Failed to email a code", "user", username, "error", err)
+		return retryableError(codes.Unavailable, gs.ReasonMailFailure, time.Minute, "Failed to send the email!")
+	}
+
+	slog.Info("Sent a code", "user", username)
+	return nil
+}
+
+// use the code of username, it is deleted when it matches, expired or guessed too often
+func (cs *ChatServer) useCode(username string, purpose codePurpose, code string) (*oneTimeCode, error) {
+	invalid := rpcError(codes.InvalidArgument, gs.ReasonInvalidCode, "The code is wrong or has expired!")
+	key := codeKey{username, purpose}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	stored, ok := cs.oneTimeCodes[key]
+	if !ok {
+		return nil, invalid
+	}
+
+	if time.Now().After(stored.expires) {
+		delete(cs.oneTimeCodes, key)
+		return nil, invalid
+	}
+
+	if subtle.ConstantTimeCompare([]byte(stored.code), []byte(code)) != 1 {
+		stored.attempts++
+		if stored.attempts >= maxCodeAttempts {
+			slog.Warn("Too many wrong codes, the code was thrown away", "user", username)
+			delete(cs.oneTimeCodes, key)
+		}
+		return nil, invalid
+	}
+
+	delete(cs.oneTimeCodes, key)
+	return stored, nil
+}
+
+// email a verification code if the user has an unverified address
+func (cs *ChatServer) sendVerificationCode(user *gs.User) error {
+	if user.Email == nil || user.GetEmailVerified() {
+		return nil
+	}
+
+	return cs.sendCode(user.GetUsername(), user.GetEmail(), codeVerifyEmail)
+}
+
+// check if a user must verify their email before logging in
+func (cs *ChatServer) needsVerification(user *gs.User) bool {
+	return cs.RequireEmailVerification && user.Email != nil && !user.GetEmailVerified()
+}
+
+// ---------------------------------------------------------//
+// ------------------------- RPC ---------------------------//
+
+// confirm the email address of a user with the code sent to it
+func (cs *ChatServer) VerifyEmail(ctx context.Context, request *gs.VerificationCode) (*gs.AuthenticationResult, error) {
+	username := request.GetUsername()
+	slog.Info("Email verification request", "user", username)
+
+	sent, err := cs.useCode(username, codeVerifyEmail, request.GetCode())
+	if err != nil {
+		return nil, err
+	}
+
+	err = cs.users.update(username, func(user *gs.User) error {
+		// the address was changed after the code was sent
+		if user.GetEmail() != sent.email {
+			return rpcError(codes.InvalidArgument, gs.ReasonInvalidCode, "The code is wrong or has expired!")
+		}
+
+		verified := true
+		user.EmailVerified = &verified
+		return nil
+	})
+	if err != nil {
+		return nil, storeError(err, username)
+	}
+
+	msg := fmt.Sprintf("Email of %s was verified successfully!", username)
+	slog.Info(msg)
+	return &gs.AuthenticationResult{
+		Username: username,
+		Status:   int32(codes.OK),
+		Message:  &msg,
+	}, nil
+}
+
+// send a new email verification code, sender is the username
+func (cs *ChatServer) ResendVerificationCode(ctx context.Context, request *gs.UserRequest) (*gs.AuthenticationResult, error) {
+	username := request.GetSender()
+	slog.Info("Verification code request", "user", username)
+
+	if err := cs.checkMailer(); err != nil {
+		return nil, err
+	}
+
+	// the same answer when the code cannot be sent, so usernames cannot be probed
+	if user := cs.users.find(username); user != nil {
+		if err := cs.sendVerificationCode(user); err != nil {
+			slog.Warn("Verification code not sent", "user", username, "error", err)
+		}
+	}
+
+	msg := codeSentMessage
+	return &gs.AuthenticationResult{
+		Username: username,
+		Status:   int32(codes.OK),
+		Message:  &msg,
+	}, nil
+}
+
+// email a password reset code, sender is the username
+func (cs *ChatServer) RequestPasswordReset(ctx context.Context, request *gs.UserRequest) (*gs.AuthenticationResult, error) {
+	username := request.GetSender()
+	slog.Info("Password reset request", "user", username)
+
+	if err := cs.checkMailer(); err != nil {
+		return nil, err
+	}
+
+	// the same answer when the code cannot be sent, so usernames cannot be probed
+	if user := cs.users.find(username); user != nil && user.Email != nil && !user.GetDisabled() {
+		if err := cs.sendCode(username, user.GetEmail(), codeResetPassword); err != nil {
+			slog.Warn("Password reset code not sent", "user", username, "error", err)
+		}
+	}
+
+	msg := codeSentMessage
+	return &gs.AuthenticationResult{
+		Username: username,
+		Status:   int32(codes.OK),
+		Message:  &msg,
+	}, nil
+}
+
+// set a new password with the code sent by RequestPasswordReset, ending every session of the user.
+// wrong codes count as failed logins
+func (cs *ChatServer) ConfirmPasswordReset(ctx context.Context, request *gs.PasswordResetConfirmation) (*gs.AuthenticationResult, error) {
+	username := request.GetUsername()
+	host := peerHost(ctx)
+	slog.Info("Password reset confirmation", "user", username, "peer", host)
+
+	cs.mu.Lock()
+	lockout := cs.loginLockout(username, host)
+	cs.mu.Unlock()
+
+	if lockout > 0 {
+		slog.Warn("Password reset refused, locked out", "user", username, "peer", host, "lockout", lockout)
+		return nil, retryableError(codes.ResourceExhausted, gs.ReasonTooManyAttempts, lockout, "Too many failed attempts, try again later!")
+	}
+
+	if err := cs.validatePassword("new_password", request.GetNewPassword()); err != nil {
+		return nil, err
+	}
+
+	sent, err := cs.useCode(username, codeResetPassword, request.GetCode())
+	if err != nil {
+		cs.mu.Lock()
+		cs.recordLoginFailure(username, host)
+		cs.mu.Unlock()
+		return nil, err
+	}
+
+	err = cs.users.update(username, func(user *gs.User) error {
+		user.Password = request.GetNewPassword()
+
+		// receiving the code proves the address belongs to the user
+		if user.GetEmail() == sent.email {
+			verified := true
+			user.EmailVerified = &verified
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, storeError(err, username)
+	}
+
+	cs.mu.Lock()
+	cs.endAllSessions(username, "password reset")
+	cs.resetLoginFailures(username)
+	cs.mu.Unlock()
+
+	msg := fmt.Sprintf("Password of %s was reset successfully!", username)
+	slog.Info(msg)
+	return &gs.AuthenticationResult{
+		Username: username,
+		Status:   int32(codes.OK),
+		Message:  &msg,
+	}, nil
+}
@@ -0,0 +1,157 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+
+	gs "github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
+	codes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// keeps the codes it was asked to send, or fails every email when broken
+type testMailer struct {
+	codes  []string
+	broken bool
+	mu     sync.Mutex
+}
+
+var mailedCode = regexp.MustCompile(`code is (\d{6})`)
+
+func (m *testMailer) Send(to string, subject string, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.broken {
+		return errors.New("mail server down")
+	}
+	m.codes = append(m.codes, mailedCode.FindStringSubmatch(body)[1])
+	return nil
+}
+
+// the last code sent and how many were sent
+func (m *testMailer) last() (string, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.codes) == 0 {
+		return "", 0
+	}
+	return m.codes[len(m.codes)-1], len(m.codes)
+}
+
+// start a server sending emails with mailer and register alice with an email address
+func newMailServer(t *testing.T, mailer *testMailer, configure func(cs *ChatServer)) *testServer {
+	t.Helper()
+
+	ts := newTestServer(t, func(cs *ChatServer) {
+		cs.Mailer = mailer
+		if configure != nil {
+			configure(cs)
+		}
+	})
+
+	email := "alice@example.com"
+	_, err := ts.client.Register(context.Background(), &gs.User{Username: "alice", Password: testPassword, FullName: "alice", Email: &email})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	return ts
+}
+
+func requestReset(ts *testServer, username string) (*gs.AuthenticationResult, error) {
+	return ts.client.RequestPasswordReset(context.Background(), &gs.UserRequest{Sender: username})
+}
+
+func confirmReset(ts *testServer, code string) error {
+	_, err := ts.client.ConfirmPasswordReset(context.Background(), &gs.PasswordResetConfirmation{Username: "alice", Code: code, NewPassword: "another12"})
+	return err
+}
+
+func TestPasswordReset(t *testing.T) {
+	mailer := &testMailer{}
+	ts := newMailServer(t, mailer, nil)
+
+	if _, err := requestReset(ts, "alice"); err != nil {
+		t.Fatalf("request reset: %v", err)
+	}
+	code, _ := mailer.last()
+	if err := confirmReset(ts, code); err != nil {
+		t.Fatalf("confirm reset: %v", err)
+	}
+
+	_, err := ts.client.Login(context.Background(), &gs.UserLoginCredentials{Username: "alice", Password: "another12"})
+	if err != nil {
+		t.Fatalf("login with the new password: %v", err)
+	}
+	if err := confirmReset(ts, code); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("second reset with the same code: %v, want InvalidArgument", err)
+	}
+}
+
+func TestCodeRequestsDoNotRevealAccounts(t *testing.T) {
+	ts := newMailServer(t, &testMailer{broken: true}, nil)
+
+	// the email of alice cannot be sent, nobody has no account
+	for _, username := range []string{"alice", "nobody"} {
+		result, err := requestReset(ts, username)
+		if err != nil || result.GetMessage() != codeSentMessage {
+			t.Errorf("password reset of %s: %v, %v", username, result.GetMessage(), err)
+		}
+
+		result, err = ts.client.ResendVerificationCode(context.Background(), &gs.UserRequest{Sender: username})
+		if err != nil || result.GetMessage() != codeSentMessage {
+			t.Errorf("verification code of %s: %v, %v", username, result.GetMessage(), err)
+		}
+	}
+}
+
+func TestCodeRequestLimit(t *testing.T) {
+	mailer := &testMailer{}
+	ts := newMailServer(t, mailer, nil)
+	_, registered := mailer.last()
+
+	for i := 0; i < maxCodeRequests+2; i++ {
+		if _, err := requestReset(ts, "alice"); err != nil {
+			t.Fatalf("request reset %d: %v", i, err)
+		}
+	}
+
+	if _, sent := mailer.last(); sent-registered != maxCodeRequests {
+		t.Errorf("%d reset codes were sent, want %d", sent-registered, maxCodeRequests)
+	}
+}
+
+func TestPasswordResetLockout(t *testing.T) {
+	mailer := &testMailer{}
+	ts := newMailServer(t, mailer, func(cs *ChatServer) { cs.LoginLimits.MaxFailures = 3 })
+
+	if _, err := requestReset(ts, "alice"); err != nil {
+		t.Fatalf("request reset: %v", err)
+	}
+	code, _ := mailer.last()
+
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	for i := 0; i < ts.cs.LoginLimits.MaxFailures; i++ {
+		if err := confirmReset(ts, wrong); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("wrong code %d: %v, want InvalidArgument", i, err)
+		}
+	}
+
+	// locked out like after failed logins, even with the right code
+	err := confirmReset(ts, code)
+	if status.Code(err) != codes.ResourceExhausted || reasonOf(err) != gs.ReasonTooManyAttempts {
+		t.Fatalf("reset after wrong codes: %v, want ResourceExhausted", err)
+	}
+	_, err = ts.client.Login(context.Background(), &gs.UserLoginCredentials{Username: "alice", Password: testPassword})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("login after wrong codes: %v, want ResourceExhausted", err)
+	}
+}
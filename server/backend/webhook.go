@@ -0,0 +1,285 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// types of the chat events posted to webhooks
+const (
+	EventMessage        = "message"
+	EventPrivateMessage = "private_message"
+	EventLogin          = "login"
+	EventLogout         = "logout"
+	EventLike           = "like"
+)
+
+var webhookEventTypes = map[string]bool{
+	EventMessage:        true,
+	EventPrivateMessage: true,
+	EventLogin:          true,
+	EventLogout:         true,
+	EventLike:           true,
+}
+
+// headers of a webhook request, the signature is the hex HMAC-SHA256 of the body with the secret of the URL
+const (
+	webhookSignatureHeader = "X-Chat-Signature"
+	webhookEventHeader     = "X-Chat-Event"
+	webhookDeliveryHeader  = "X-Chat-Delivery"
+)
+
+// events waiting for each URL, newer events are dead-lettered when a receiver is that far behind
+const webhookQueueSize = 256
+
+// longest wait between two attempts of a delivery
+const maxWebhookBackoff = 5 * time.Minute
+
+// the JSON payload posted to webhooks
+type WebhookEvent struct {
+	ID   string    `json:"id"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// the user doing the action
+	User string `json:"user"`
+	// the recipient of a private message or the author of a liked message
+	Target    string `json:"target,omitempty"`
+	MessageID string `json:"messageId,omitempty"`
+	Message   string `json:"message,omitempty"`
+	// the message was posted by a bot through an incoming webhook, receivers may ignore it to avoid loops
+	Bot bool `json:"bot,omitempty"`
+}
+
+// an event that could not be delivered, written as a JSON line to the dead-letter log
+type deadLetter struct {
+	URL      string       `json:"url"`
+	Event    WebhookEvent `json:"event"`
+	Attempts int          `json:"attempts"`
+	Error    string       `json:"error"`
+	Time     time.Time    `json:"time"`
+}
+
+type webhookEndpoint struct {
+	WebhookConfig
+	events map[string]bool
+	queue  chan WebhookEvent
+}
+
+// Posts chat events to the configured URLs in the background, retrying failed deliveries
+// with exponential backoff. a nil *Webhooks posts nothing
+type Webhooks struct {
+	// private messages to these users are posted as events, so external bots can answer them
+	BotUsers map[string]bool
+	// attempts of each delivery before it is dead-lettered
+	MaxAttempts int
+	// wait before the first retry, doubled after every failed attempt
+	Backoff time.Duration
+	Client  *http.Client
+
+	endpoints    []*webhookEndpoint
+	deadLetter   io.Writer
+	deadLetterMu sync.Mutex
+	ctx          context.Context
+	cancel       context.CancelFunc
+	closed       bool
+	wg           sync.WaitGroup
+	mu           sync.Mutex
+}
+
+// start posting events to the endpoints, undeliverable events are written to deadLetter
+func NewWebhooks(endpoints []WebhookConfig, deadLetter io.Writer) *Webhooks {
+	w := &Webhooks{
+		BotUsers:    make(map[string]bool),
+		MaxAttempts: 5,
+		Backoff:     time.Second,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		deadLetter:  deadLetter,
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+
+	for _, config := range endpoints {
+		endpoint := &webhookEndpoint{
+			WebhookConfig: config,
+			events:        make(map[string]bool),
+			queue:         make(chan WebhookEvent, webhookQueueSize),
+		}
+		for _, event := range config.Events {
+			endpoint.events[event] = true
+		}
+
+		w.endpoints = append(w.endpoints, endpoint)
+		w.wg.Add(1)
+		go w.deliverAll(endpoint)
+	}
+
+	return w
+}
+
+// stop taking events and deliver the queued ones until ctx is done,
+// the events still not delivered then are dead-lettered
+func (w *Webhooks) Close(ctx context.Context) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	for _, endpoint := range w.endpoints {
+		close(endpoint.queue)
+	}
+	w.mu.Unlock()
+
+	delivered := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(delivered)
+	}()
+
+	select {
+	case <-delivered:
+	case <-ctx.Done():
+		w.cancel()
+		<-delivered
+	}
+	w.cancel()
+}
+
+// sign a payload with the secret of a webhook
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ---------------------------------------------------------//
+// ------------------ HELPER -------------------------------//
+
+// check if private messages to username go to the webhooks
+func (w *Webhooks) isBotUser(username string) bool {
+	return w != nil && w.BotUsers[username]
+}
+
+// queue an event for every endpoint subscribed to its type, without waiting for the deliveries
+func (w *Webhooks) post(event WebhookEvent) {
+	if w == nil {
+		return
+	}
+
+	event.ID = GenerateSecureToken(8)
+	event.Time = time.Now().UTC()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+
+	for _, endpoint := range w.endpoints {
+		if len(endpoint.events) > 0 && !endpoint.events[event.Type] {
+			continue
+		}
+
+		select {
+		case endpoint.queue <- event:
+		default:
+			w.writeDeadLetter(endpoint, event, 0, fmt.Errorf("too many events waiting for delivery"))
+		}
+	}
+}
+
+// deliver the events of an endpoint in order, until the webhooks are closed
+func (w *Webhooks) deliverAll(endpoint *webhookEndpoint) {
+	defer w.wg.Done()
+
+	for event := range endpoint.queue {
+		w.deliver(endpoint, event)
+	}
+}
+
+// post an event until it is accepted, dead-letter it after MaxAttempts
+func (w *Webhooks) deliver(endpoint *webhookEndpoint, event WebhookEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		w.writeDeadLetter(endpoint, event, 0, err)
+		return
+	}
+
+	backoff := w.Backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := w.send(endpoint, event, body)
+		if err == nil {
+			return
+		}
+
+		if !retry || attempt >= w.MaxAttempts || w.ctx.Err() != nil {
+			w.writeDeadLetter(endpoint, event, attempt, err)
+			return
+		}
+
+		slog.Warn("Webhook delivery failed, retrying", "url", endpoint.URL, "event", event.ID, "attempt", attempt, "retry_in", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-w.ctx.Done():
+		}
+		backoff = min(2*backoff, maxWebhookBackoff)
+	}
+}
+
+// post a signed payload once, reporting if a failure is worth retrying
+func (w *Webhooks) send(endpoint *webhookEndpoint, event WebhookEvent, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, event.Type)
+	req.Header.Set(webhookDeliveryHeader, event.ID)
+	req.Header.Set(webhookSignatureHeader, "sha256="+SignWebhook(endpoint.Secret, body))
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	// the receiver is overloaded or down, other errors will not go away by retrying
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+	return retry, fmt.Errorf("receiver answered %s", resp.Status)
+}
+
+// record an event that could not be delivered
+func (w *Webhooks) writeDeadLetter(endpoint *webhookEndpoint, event WebhookEvent, attempts int, err error) {
+	slog.Error("Webhook delivery failed", "url", endpoint.URL, "event", event.ID, "type", event.Type, "attempts", attempts, "error", err)
+	if w.deadLetter == nil {
+		return
+	}
+
+	line, _ := json.Marshal(deadLetter{
+		URL:      endpoint.URL,
+		Event:    event,
+		Attempts: attempts,
+		Error:    err.Error(),
+		Time:     time.Now().UTC(),
+	})
+	line = append(line, '\n')
+
+	// deliveries of several endpoints fail at the same time
+	w.deadLetterMu.Lock()
+	defer w.deadLetterMu.Unlock()
+	w.deadLetter.Write(line)
+}
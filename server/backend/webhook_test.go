@@ -0,0 +1,190 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// a request received by a webhook receiver
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+	at     time.Time
+}
+
+// an HTTP server answering webhook requests with the next status of statuses,
+// then with 200. the requests are sent to the returned channel
+func newWebhookReceiver(t *testing.T, statuses ...int) (*httptest.Server, chan webhookRequest) {
+	t.Helper()
+
+	requests := make(chan webhookRequest, 64)
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- webhookRequest{header: r.Header, body: body, at: time.Now()}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(statuses) > 0 {
+			w.WriteHeader(statuses[0])
+			statuses = statuses[1:]
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, requests
+}
+
+// a dead-letter log that can be written by the deliveries and read by the test
+type lockedBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) deadLetters(t *testing.T) []deadLetter {
+	t.Helper()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var result []deadLetter
+	scanner := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	for scanner.Scan() {
+		var letter deadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			t.Fatalf("dead letter %q: %v", scanner.Text(), err)
+		}
+		result = append(result, letter)
+	}
+
+	return result
+}
+
+// deliver the queued events and stop the webhooks
+func closeWebhooks(w *Webhooks) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	w.Close(ctx)
+}
+
+func nextRequest(t *testing.T, requests chan webhookRequest) webhookRequest {
+	t.Helper()
+
+	select {
+	case request := <-requests:
+		return request
+	case <-time.After(testTimeout):
+		t.Fatalf("the receiver got no request")
+		return webhookRequest{}
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	receiver, requests := newWebhookReceiver(t)
+	ts := newTestServer(t, func(cs *ChatServer) {
+		cs.Webhooks = NewWebhooks([]WebhookConfig{{URL: receiver.URL, Secret: "s3cret", Events: []string{EventMessage}}}, nil)
+	})
+	t.Cleanup(func() { closeWebhooks(ts.cs.Webhooks) })
+
+	// the login of alice is not a subscribed event
+	alice := ts.join(t, "alice")
+	alice.say(t, "hello hooks")
+
+	request := nextRequest(t, requests)
+	if got, want := request.header.Get(webhookSignatureHeader), "sha256="+SignWebhook("s3cret", request.body); got != want {
+		t.Errorf("signature %q, want %q", got, want)
+	}
+	if got := request.header.Get(webhookSignatureHeader); got == "sha256="+SignWebhook("other", request.body) {
+		t.Errorf("signature does not depend on the secret")
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(request.body, &event); err != nil {
+		t.Fatalf("payload %s: %v", request.body, err)
+	}
+	if event.Type != EventMessage || event.User != "alice" || event.Message != "hello hooks" {
+		t.Errorf("event %+v, want the message of alice", event)
+	}
+	if request.header.Get(webhookEventHeader) != EventMessage || request.header.Get(webhookDeliveryHeader) != event.ID {
+		t.Errorf("headers %v do not match the event %+v", request.header, event)
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	receiver, requests := newWebhookReceiver(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+	letters := &lockedBuffer{}
+	w := NewWebhooks([]WebhookConfig{{URL: receiver.URL, Secret: "s3cret"}}, letters)
+	w.Backoff = 50 * time.Millisecond
+
+	w.post(WebhookEvent{Type: EventLogin, User: "alice"})
+
+	var attempts []webhookRequest
+	for i := 0; i < 3; i++ {
+		attempts = append(attempts, nextRequest(t, requests))
+	}
+	closeWebhooks(w)
+
+	// the same delivery, each wait twice the previous one
+	for i, attempt := range attempts[1:] {
+		if attempt.header.Get(webhookDeliveryHeader) != attempts[0].header.Get(webhookDeliveryHeader) {
+			t.Errorf("attempt %d is another delivery", i+2)
+		}
+
+		wait := attempt.at.Sub(attempts[i].at)
+		if minimum := w.Backoff << i; wait < minimum {
+			t.Errorf("attempt %d came after %s, want at least %s", i+2, wait, minimum)
+		}
+	}
+
+	if len(requests) != 0 {
+		t.Errorf("the event was posted again after it was accepted")
+	}
+	if letters := letters.deadLetters(t); len(letters) != 0 {
+		t.Errorf("a delivered event was dead-lettered: %+v", letters)
+	}
+}
+
+func TestWebhookDeadLetter(t *testing.T) {
+	failing, failingRequests := newWebhookReceiver(t, 500, 500, 500, 500)
+	refusing, refusingRequests := newWebhookReceiver(t, http.StatusBadRequest)
+	letters := &lockedBuffer{}
+	w := NewWebhooks([]WebhookConfig{{URL: failing.URL}, {URL: refusing.URL}}, letters)
+	w.MaxAttempts = 3
+	w.Backoff = time.Millisecond
+
+	w.post(WebhookEvent{Type: EventLike, User: "alice", Target: "bob"})
+	closeWebhooks(w)
+
+	// a server error is retried up to MaxAttempts, a refused event is not retried
+	if len(failingRequests) != w.MaxAttempts {
+		t.Errorf("failing receiver got %d attempts, want %d", len(failingRequests), w.MaxAttempts)
+	}
+	if len(refusingRequests) != 1 {
+		t.Errorf("refusing receiver got %d attempts, want 1", len(refusingRequests))
+	}
+
+	attempts := make(map[string]int)
+	for _, letter := range letters.deadLetters(t) {
+		if letter.Event.Type != EventLike || letter.Event.User != "alice" || letter.Event.Target != "bob" || letter.Error == "" {
+			t.Errorf("dead letter %+v does not describe the event", letter)
+		}
+		attempts[letter.URL] = letter.Attempts
+	}
+	if len(attempts) != 2 || attempts[failing.URL] != w.MaxAttempts || attempts[refusing.URL] != 1 {
+		t.Errorf("dead letters of %v, want %d attempts to %s and 1 to %s", attempts, w.MaxAttempts, failing.URL, refusing.URL)
+	}
+}
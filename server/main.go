@@ -1,79 +1,483 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"syscall"
 	"time"
 
 	"github.com/phucthuan1st/gRPC-ChatRoom/grpcService"
 	be "github.com/phucthuan1st/gRPC-ChatRoom/server/backend"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
-var (
-	port           int    = 55555
-	connectionType string = "tcp"
-	serverAddress  string = "localhost"
-	logDir         string = "log"
-	credDB         string = "db/UserCredentials.json"
-)
+// how long running RPCs get to finish when the server shuts down
+const shutdownTimeout = 10 * time.Second
+
+// environment variable naming the config file when -config is not given
+const configEnv = "CHAT_CONFIG"
+
+// command line options that are not settings of the config
+type commandLine struct {
+	configPath string
+	// permissions of the unix sockets, applied after all the flags are parsed
+	socketMode string
+}
+
+// command line flags, bound to the settings of cfg so they override the config file
+func newFlagSet(cfg *be.Config, cmd *commandLine, errorHandling flag.ErrorHandling) *flag.FlagSet {
+	fs := flag.NewFlagSet(os.Args[0], errorHandling)
+	if errorHandling == flag.ContinueOnError {
+		fs.SetOutput(io.Discard)
+	}
+
+	fs.StringVar(&cmd.configPath, "config", cmd.configPath, "JSON config file, settings can be overridden by CHAT_* environment variables and flags")
 
-func setupLogging(logFile *os.File) {
+	fs.StringVar(&cfg.Listener.Address, "server", cfg.Listener.Address, "gRPC server address")
+	fs.IntVar(&cfg.Listener.Port, "port", cfg.Listener.Port, "server port")
+	fs.StringVar(&cfg.Listener.Network, "connectionType", cfg.Listener.Network, "connection type: tcp, tcp4, tcp6 or unix (-server is then the socket path)")
+	fs.Func("socket", "also serve on a unix socket at this path, can be repeated", func(path string) error {
+		cfg.Listeners = append(cfg.Listeners, be.ListenerConfig{Network: "unix", Address: path})
+		return nil
+	})
+	fs.StringVar(&cmd.socketMode, "socketMode", cmd.socketMode, "octal permissions of the unix sockets (default 0660)")
+	fs.StringVar(&cfg.TLS.CertFile, "tlsCert", cfg.TLS.CertFile, "PEM certificate of the server, enables TLS with -tlsKey")
+	fs.StringVar(&cfg.TLS.KeyFile, "tlsKey", cfg.TLS.KeyFile, "PEM private key of the server certificate")
+	fs.StringVar(&cfg.Store.Credentials, "credDB", cfg.Store.Credentials, "location of credentials database")
+	fs.StringVar(&cfg.Store.WebhookTokens, "webhookTokensDB", cfg.Store.WebhookTokens, "location of the incoming webhook tokens database")
+	fs.StringVar(&cfg.Store.ScheduledMessages, "scheduledDB", cfg.Store.ScheduledMessages, "location of the scheduled messages database")
+	fs.StringVar(&cfg.Store.History, "historyDB", cfg.Store.History, "location of the message history, searched by clients")
+	fs.StringVar(&cfg.Store.Mentions, "mentionsDB", cfg.Store.Mentions, "location of the unread mentions database")
+
+	fs.StringVar(&cfg.Logging.Dir, "logDir", cfg.Logging.Dir, "log directory")
+	fs.StringVar(&cfg.Logging.Format, "logFormat", cfg.Logging.Format, "log output format, text or json")
+	fs.TextVar(&cfg.Logging.Level, "logLevel", cfg.Logging.Level, "lowest level logged: debug, info, warn or error")
+	fs.Int64Var(&cfg.Logging.MaxSizeMB, "logMaxSize", cfg.Logging.MaxSizeMB, "rotate the log file when it reaches this size in MB (0 to disable)")
+	fs.TextVar(&cfg.Logging.MaxAge, "logMaxAge", cfg.Logging.MaxAge, "rotate the log file when it is older than this (0 to disable)")
+	fs.IntVar(&cfg.Logging.Retention, "logRetention", cfg.Logging.Retention, "log files kept in -logDir, older ones are deleted (0 to keep all)")
+	fs.BoolVar(&cfg.Logging.RedactMessages, "redactMessages", cfg.Logging.RedactMessages, "log the length of chat messages instead of their text")
+	fs.StringVar(&cfg.Ops.MetricsAddr, "metricsAddr", cfg.Ops.MetricsAddr, "host:port serving Prometheus metrics on /metrics (disabled if empty)")
+	fs.StringVar(&cfg.Ops.HealthAddr, "healthAddr", cfg.Ops.HealthAddr, "host:port serving HTTP liveness (/livez) and readiness (/readyz) checks (disabled if empty)")
+	fs.StringVar(&cfg.Gateway.Addr, "gatewayAddr", cfg.Gateway.Addr, "host:port serving gRPC-Web, the WebSocket chat bridge and the REST API over HTTP (disabled if empty)")
+	fs.Func("gatewayOrigins", "comma separated host patterns of the web pages allowed to call the gateway from another origin", func(origins string) error {
+		cfg.Gateway.AllowedOrigins = be.SplitList(origins)
+		return nil
+	})
+	fs.BoolVar(&cfg.Ops.Reflection, "reflection", cfg.Ops.Reflection, "enable gRPC server reflection, used by tools like grpcurl")
+
+	fs.Func("admin", "comma separated usernames given the admin role at startup", func(names string) error {
+		cfg.Accounts.Admins = be.SplitList(names)
+		return nil
+	})
+	fs.IntVar(&cfg.Accounts.PasswordMinLength, "passwordMinLength", cfg.Accounts.PasswordMinLength, "minimum password length")
+	fs.BoolVar(&cfg.Accounts.PasswordRequireUpper, "passwordRequireUpper", cfg.Accounts.PasswordRequireUpper, "passwords must contain an uppercase letter")
+	fs.BoolVar(&cfg.Accounts.PasswordRequireSymbol, "passwordRequireSymbol", cfg.Accounts.PasswordRequireSymbol, "passwords must contain a symbol")
+	fs.Func("reservedUsernames", "extra comma separated usernames nobody can register", func(names string) error {
+		cfg.Accounts.ReservedUsernames = be.SplitList(names)
+		return nil
+	})
+	fs.TextVar(&cfg.Accounts.SessionIdleTimeout, "sessionIdleTimeout", cfg.Accounts.SessionIdleTimeout, "expire logins that do not open a chat stream within this time (0 to disable)")
+	fs.BoolVar(&cfg.Accounts.RequireEmailVerification, "requireEmailVerification", cfg.Accounts.RequireEmailVerification, "users must verify their email with a code before logging in")
+	fs.TextVar(&cfg.Accounts.CodeTTL, "codeTTL", cfg.Accounts.CodeTTL, "how long email verification and password reset codes are valid")
+
+	fs.StringVar(&cfg.Mail.SMTPAddr, "smtpAddr", cfg.Mail.SMTPAddr, "host:port of the SMTP server sending emails, emails are written to -mailFile if empty")
+	fs.StringVar(&cfg.Mail.SMTPFrom, "smtpFrom", cfg.Mail.SMTPFrom, "sender address of the emails")
+	fs.StringVar(&cfg.Mail.SMTPUser, "smtpUser", cfg.Mail.SMTPUser, "SMTP username, the password is read from the SMTP_PASSWORD environment variable")
+	fs.StringVar(&cfg.Mail.File, "mailFile", cfg.Mail.File, "file the emails are appended to when no SMTP server is set, - for stdout. email is disabled when both are empty")
+
+	fs.Float64Var(&cfg.RateLimit.Requests, "rateLimit", cfg.RateLimit.Requests, "requests per second allowed from each client address (0 to disable)")
+	fs.IntVar(&cfg.RateLimit.Burst, "rateBurst", cfg.RateLimit.Burst, "requests a client address can make at once before -rateLimit applies")
+	fs.IntVar(&cfg.RateLimit.LoginMaxFailures, "loginMaxFailures", cfg.RateLimit.LoginMaxFailures, "failed logins of a username before it is locked out")
+	fs.IntVar(&cfg.RateLimit.LoginMaxFailuresPerPeer, "loginMaxFailuresPerPeer", cfg.RateLimit.LoginMaxFailuresPerPeer, "failed logins from a client address before it is locked out")
+	fs.TextVar(&cfg.RateLimit.LoginLockout, "loginLockout", cfg.RateLimit.LoginLockout, "first login lockout, doubled for every further failure")
+	fs.TextVar(&cfg.RateLimit.LoginMaxLockout, "loginMaxLockout", cfg.RateLimit.LoginMaxLockout, "longest login lockout")
+
+	fs.IntVar(&cfg.Posting.LikesToPost, "likesToPost", cfg.Posting.LikesToPost, "likes the previous room message of a user needs before they can post again")
+	fs.Float64Var(&cfg.Posting.MessageRate, "messageRate", cfg.Posting.MessageRate, "room messages per second allowed for each user (0 to disable)")
+	fs.IntVar(&cfg.Posting.MessageBurst, "messageBurst", cfg.Posting.MessageBurst, "room messages a user can send at once before -messageRate applies")
+	fs.Float64Var(&cfg.Posting.PrivateMessageRate, "privateMessageRate", cfg.Posting.PrivateMessageRate, "private messages per second allowed for each user (0 to disable)")
+	fs.IntVar(&cfg.Posting.PrivateMessageBurst, "privateMessageBurst", cfg.Posting.PrivateMessageBurst, "private messages a user can send at once before -privateMessageRate applies")
+	fs.Float64Var(&cfg.Posting.BotMessageRate, "botMessageRate", cfg.Posting.BotMessageRate, "messages per second allowed for each incoming webhook token (0 to disable)")
+	fs.IntVar(&cfg.Posting.BotMessageBurst, "botMessageBurst", cfg.Posting.BotMessageBurst, "messages an incoming webhook token can post at once before -botMessageRate applies")
+	fs.IntVar(&cfg.Posting.MaxMessageLength, "maxMessageLength", cfg.Posting.MaxMessageLength, "longest message in characters (0 for no limit)")
+	fs.IntVar(&cfg.Posting.MaxDuplicateMessages, "maxDuplicateMessages", cfg.Posting.MaxDuplicateMessages, "the same message sent this many times in a row is spam (0 to disable)")
+	fs.IntVar(&cfg.Posting.FloodMuteAfter, "floodMuteAfter", cfg.Posting.FloodMuteAfter, "broken message limits before a user is muted (0 to never mute)")
+	fs.TextVar(&cfg.Posting.FloodMuteDuration, "floodMuteDuration", cfg.Posting.FloodMuteDuration, "how long flooding users are muted")
+	fs.IntVar(&cfg.Posting.FloodDisconnectAfter, "floodDisconnectAfter", cfg.Posting.FloodDisconnectAfter, "broken message limits before a user is disconnected (0 to never disconnect)")
+	fs.IntVar(&cfg.Retention.RecentMessages, "recentMessages", cfg.Retention.RecentMessages, "room messages remembered so moderators can delete them")
+
+	return fs
+}
+
+// the settings from the defaults, the config file, the CHAT_* environment variables
+// and the command line, each one overriding the previous
+func loadConfig(args []string, errorHandling flag.ErrorHandling) (be.Config, error) {
+	cfg := be.DefaultConfig()
+	cmd := commandLine{configPath: os.Getenv(configEnv)}
+
+	// a first pass only to find the config file
+	if err := newFlagSet(&cfg, &cmd, errorHandling).Parse(args); err != nil {
+		return cfg, err
+	}
+
+	cfg = be.DefaultConfig()
+	if cmd.configPath != "" {
+		if err := cfg.LoadFile(cmd.configPath); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err := cfg.LoadEnv(); err != nil {
+		return cfg, err
+	}
+
+	if err := newFlagSet(&cfg, &cmd, errorHandling).Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if cmd.socketMode != "" {
+		if cfg.Listener.Network == "unix" {
+			cfg.Listener.SocketMode = cmd.socketMode
+		}
+		for i := range cfg.Listeners {
+			if cfg.Listeners[i].Network == "unix" {
+				cfg.Listeners[i].SocketMode = cmd.socketMode
+			}
+		}
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// log to both the terminal and a rotating file in the log directory
+func setupLogging(cfg be.LoggingConfig, level slog.Leveler) (*be.RotatingFile, error) {
+	logFile, err := be.OpenRotatingFile(cfg.Dir, "app", cfg.MaxSizeMB*1024*1024, time.Duration(cfg.MaxAge), cfg.Retention)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open log file: %v", err)
+	}
 
 	// Create a multi-writer to write log messages to both the file and the terminal.
 	multiWriter := io.MultiWriter(os.Stdout, logFile)
 
-	// Set the log output to the multi-writer.
-	log.SetOutput(multiWriter)
+	logger, err := be.NewLogger(multiWriter, cfg.Format, level)
+	if err != nil {
+		logFile.Close()
+		return nil, err
+	}
 
-	// Set log flags as desired (e.g., log date and time).
-	log.SetFlags(log.Ldate | log.Ltime)
+	slog.SetDefault(logger)
+	return logFile, nil
 }
 
-func main() {
+// the mailer sending verification and password reset codes, nil when email is not configured
+// so the codes are never printed by accident
+func newMailer(cfg be.MailConfig) (be.Mailer, error) {
+	if cfg.SMTPAddr != "" {
+		return &be.SMTPMailer{
+			Addr:     cfg.SMTPAddr,
+			From:     cfg.SMTPFrom,
+			Username: cfg.SMTPUser,
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}, nil
+	}
+
+	if cfg.File == "" {
+		return nil, nil
+	}
+
+	return be.NewFileMailer(cfg.File)
+}
+
+// the webhooks posting chat events, nil when none is configured
+func newWebhooks(cfg be.Config) (*be.Webhooks, error) {
+	if len(cfg.Webhooks.Endpoints) == 0 {
+		return nil, nil
+	}
+
+	path := cfg.Webhooks.DeadLetterFile
+	if path == "" {
+		if err := os.MkdirAll(cfg.Logging.Dir, 0755); err != nil {
+			return nil, err
+		}
+		path = filepath.Join(cfg.Logging.Dir, "webhook_dead_letters.jsonl")
+	}
+
+	deadLetter, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := be.NewWebhooks(cfg.Webhooks.Endpoints, deadLetter)
+	webhooks.MaxAttempts = cfg.Webhooks.MaxAttempts
+	webhooks.Backoff = time.Duration(cfg.Webhooks.Backoff)
+	webhooks.Client.Timeout = time.Duration(cfg.Webhooks.Timeout)
+	for _, bot := range cfg.Webhooks.BotUsers {
+		webhooks.BotUsers[bot] = true
+	}
+
+	return webhooks, nil
+}
+
+// reload the config with the command line args on every hangup signal,
+// applying the settings that can change at runtime
+func watchReload(hangup <-chan os.Signal, args []string, current be.Config, backendServer *be.ChatServer, limiter *be.RateLimiter, logLevel *slog.LevelVar) {
+	for range hangup {
+		next, err := loadConfig(args, flag.ContinueOnError)
+		if err != nil {
+			slog.Error("Cannot reload the config, the current settings are kept", "error", err)
+			continue
+		}
+
+		if !reflect.DeepEqual(current.WithReloadable(next), next) {
+			slog.Warn("Some changed settings only apply after a restart")
+		}
+
+		logLevel.Set(next.Logging.Level)
+		limiter.SetLimits(next.RateLimit.Requests, next.RateLimit.Burst)
+		backendServer.Reload(next)
+
+		current = current.WithReloadable(next)
+		slog.Info("Config reloaded")
+	}
+}
+
+// HTTP liveness and readiness endpoints for container deployments,
+// ready while the gRPC health service reports SERVING
+func healthHandler(healthServer *health.Server) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		resp, err := healthServer.Check(r.Context(), &healthpb.HealthCheckRequest{})
+		if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+
+	return mux
+}
+
+func serveHealth(addr string, healthServer *health.Server) {
+	slog.Info("Serving health checks", "address", addr)
+	if err := http.ListenAndServe(addr, healthHandler(healthServer)); err != nil {
+		fatal("Cannot serve health checks", "error", err)
+	}
+}
+
+// serve browsers and REST clients over HTTP/1.1, with TLS when the server has a certificate
+func serveGateway(addr string, gateway *be.Gateway, tlsConfig *tls.Config) {
+	server := &http.Server{Addr: addr, Handler: gateway}
 
-	flag.StringVar(&serverAddress, "server", serverAddress, "gRPC server address")
-	flag.IntVar(&port, "port", port, "server port")
-	flag.StringVar(&connectionType, "connectionType", connectionType, "connection type")
-	flag.StringVar(&logDir, "logDir", logDir, "log directory")
-	flag.StringVar(&credDB, "credDB", credDB, "location of credentials database")
+	var err error
+	slog.Info("Serving the HTTP gateway", "address", addr, "tls", tlsConfig != nil)
+	if tlsConfig != nil {
+		server.TLSConfig = tlsConfig.Clone()
+		server.TLSConfig.NextProtos = []string{"http/1.1"}
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
 
-	flag.Parse()
+	fatal("Cannot serve the HTTP gateway", "error", err)
+}
 
-	// Generate a log file name based on the current date and time.
-	currentTime := time.Now()
-	logFileName := fmt.Sprintf("%s/app_%s.log", logDir, currentTime.Format("2006-01-02_15:04:05"))
-	logFile, err := os.OpenFile(logFileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+// log an error and stop the server
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+func main() {
+	cfg, err := loadConfig(os.Args[1:], flag.ExitOnError)
 	if err != nil {
-		log.Fatalf("Error opening log file: %v", err)
+		fmt.Fprintln(os.Stderr, "Invalid config:", err)
+		os.Exit(2)
 	}
 
-	// Set up logging with the generated log file name.
-	setupLogging(logFile)
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(cfg.Logging.Level)
+
+	logFile, err := setupLogging(cfg.Logging, logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	defer logFile.Close()
 
+	var tlsConfig *tls.Config
+	if cfg.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			fatal("Cannot load the TLS certificate", "error", err)
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, NextProtos: []string{"h2"}}
+	}
+
 	// https://grpc.io/docs/languages/go/basics/#starting-the-server
-	listener, err := net.Listen(connectionType, fmt.Sprintf("%s:%d", serverAddress, port))
+	var listeners []net.Listener
+	for _, l := range cfg.AllListeners() {
+		listener, err := be.Listen(l, tlsConfig)
+		if err != nil {
+			fatal("Cannot start the server", "address", l.Addr(), "error", err)
+		}
+		listeners = append(listeners, listener)
+	}
+
+	// not serving until the credential store is loaded
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	if cfg.Ops.HealthAddr != "" {
+		go serveHealth(cfg.Ops.HealthAddr, healthServer)
+	}
+
+	backendServer := be.NewChatServer(cfg.Store.Credentials)
+	backendServer.Configure(cfg)
+	if err := backendServer.LoadWebhookTokens(cfg.Store.WebhookTokens); err != nil {
+		fatal("Cannot load the incoming webhook tokens", "path", cfg.Store.WebhookTokens, "error", err)
+	}
+	if err := backendServer.LoadHistory(cfg.Store.History); err != nil {
+		fatal("Cannot load the message history", "path", cfg.Store.History, "error", err)
+	}
+	if err := backendServer.LoadScheduledMessages(cfg.Store.ScheduledMessages); err != nil {
+		fatal("Cannot load the scheduled messages", "path", cfg.Store.ScheduledMessages, "error", err)
+	}
+	if err := backendServer.LoadMentions(cfg.Store.Mentions); err != nil {
+		fatal("Cannot load the unread mentions", "path", cfg.Store.Mentions, "error", err)
+	}
 
+	mailer, err := newMailer(cfg.Mail)
 	if err != nil {
-		log.Fatalf("Cannot start the server: %s", err.Error())
-		return
+		fatal("Cannot open mail file", "error", err)
 	}
+	backendServer.Mailer = mailer
+
+	webhooks, err := newWebhooks(cfg)
+	if err != nil {
+		fatal("Cannot open the webhook dead-letter log", "error", err)
+	}
+	backendServer.Webhooks = webhooks
+
+	bots, err := be.NewBots(backendServer, cfg.Bots)
+	if err != nil {
+		fatal("Cannot start the bots", "error", err)
+	}
+	backendServer.Bots = bots
+
+	limiter := be.NewRateLimiter(cfg.RateLimit.Requests, cfg.RateLimit.Burst)
+	unaryInterceptors := []grpc.UnaryServerInterceptor{be.RESTPeerInterceptor, backendServer.LoggingUnaryInterceptor}
+	streamInterceptors := []grpc.StreamServerInterceptor{backendServer.LoggingStreamInterceptor}
+
+	if cfg.Ops.MetricsAddr != "" {
+		backendServer.Metrics = be.NewMetrics(backendServer)
+		unaryInterceptors = append(unaryInterceptors, backendServer.Metrics.UnaryInterceptor)
+		streamInterceptors = append(streamInterceptors, backendServer.Metrics.StreamInterceptor)
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", backendServer.Metrics.Handler())
+		go func() {
+			slog.Info("Serving metrics", "address", cfg.Ops.MetricsAddr)
+			if err := http.ListenAndServe(cfg.Ops.MetricsAddr, mux); err != nil {
+				fatal("Cannot serve metrics", "error", err)
+			}
+		}()
+	}
+
+	unaryInterceptors = append(unaryInterceptors, limiter.UnaryInterceptor)
+	streamInterceptors = append(streamInterceptors, limiter.StreamInterceptor)
+
+	// TLS is done by the TCP listeners, so the same server can also serve plain unix sockets
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	)
 
-	grpcServer := grpc.NewServer()
-	backendServer := be.NewChatServer(credDB)
 	grpcService.RegisterChatRoomServer(grpcServer, backendServer)
+	grpcService.RegisterChatAdminServer(grpcServer, be.NewAdminServer(backendServer))
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	if cfg.Ops.Reflection {
+		reflection.Register(grpcServer)
+	}
 
-	defer grpcServer.GracefulStop()
+	for _, admin := range cfg.Accounts.Admins {
+		if err := backendServer.GrantRole(admin, grpcService.Role_ADMIN); err != nil {
+			slog.Error("Cannot grant admin role", "user", admin, "error", err)
+		}
+	}
+
+	backendServer.Start()
+
+	if cfg.Gateway.Addr != "" {
+		gateway, err := be.NewGateway(grpcServer, backendServer, cfg.Gateway.AllowedOrigins, streamInterceptors...)
+		if err != nil {
+			fatal("Cannot create the HTTP gateway", "error", err)
+		}
+		go serveGateway(cfg.Gateway.Addr, gateway, tlsConfig)
+	}
+
+	for _, service := range []string{"", grpcService.ChatRoom_ServiceDesc.ServiceName, grpcService.ChatAdmin_ServiceDesc.ServiceName} {
+		healthServer.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go watchReload(hangup, os.Args[1:], cfg, backendServer, limiter, logLevel)
+
+	// report not serving while shutting down, then let the running RPCs finish
+	stopped := make(chan struct{})
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-signals
+
+		slog.Info("Shutting down", "signal", sig.String())
+		healthServer.Shutdown()
+		backendServer.Shutdown()
+
+		// stop anyway when clients do not let go
+		time.AfterFunc(shutdownTimeout, grpcServer.Stop)
+		grpcServer.GracefulStop()
+		bots.Close()
+		backendServer.FlushMentions()
+
+		// deliver the last chat events, dead-letter what is left
+		if webhooks != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			webhooks.Close(ctx)
+			cancel()
+		}
+		close(stopped)
+	}()
+
+	// Start the gRPC server on every listener, they all stop with the server
+	served := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		slog.Info("Starting gRPC server", "network", listener.Addr().Network(), "address", listener.Addr().String(), "tls", tlsConfig != nil && listener.Addr().Network() != "unix")
+		go func(listener net.Listener) {
+			served <- grpcServer.Serve(listener)
+		}(listener)
+	}
 
-	// Start the gRPC server
-	log.Printf("Starting gRPC server on localhost:%d...", port)
-	if err := grpcServer.Serve(listener); err != nil {
-		log.Fatalf("Failed to serve: %v", err)
+	for range listeners {
+		if err := <-served; err != nil {
+			fatal("Failed to serve", "error", err)
+		}
 	}
 
+	<-stopped
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	be "github.com/phucthuan1st/gRPC-ChatRoom/server/backend"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthHandler(t *testing.T) {
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	handler := healthHandler(healthServer)
+
+	get := func(path string) int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+		return recorder.Code
+	}
+
+	// alive but not ready while the stores are loading
+	if code := get("/livez"); code != http.StatusOK {
+		t.Errorf("livez while starting: %d", code)
+	}
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("readyz while starting: %d", code)
+	}
+
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	if code := get("/readyz"); code != http.StatusOK {
+		t.Errorf("readyz while serving: %d", code)
+	}
+
+	// not ready anymore while shutting down, so no new clients are sent
+	healthServer.Shutdown()
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("readyz while shutting down: %d", code)
+	}
+	if code := get("/livez"); code != http.StatusOK {
+		t.Errorf("livez while shutting down: %d", code)
+	}
+}
+
+// write a config file in a temporary directory
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `{"listener": {"address": "0.0.0.0", "port": 6000}, "posting": {"likesToPost": 1}, "rateLimit": {"burst": 10}}`)
+	t.Setenv(configEnv, path)
+	t.Setenv("CHAT_LISTENER_PORT", "7000")
+	t.Setenv("CHAT_POSTING_LIKES_TO_POST", "3")
+
+	// the file is found through the environment, the environment overrides it and flags override both
+	cfg, err := loadConfig([]string{"-port", "8000"}, flag.ContinueOnError)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Listener.Address != "0.0.0.0" || cfg.RateLimit.Burst != 10 {
+		t.Errorf("settings of the file: %+v %+v", cfg.Listener, cfg.RateLimit)
+	}
+	if cfg.Posting.LikesToPost != 3 {
+		t.Errorf("likes to post: %d, want the 3 of the environment", cfg.Posting.LikesToPost)
+	}
+	if cfg.Listener.Port != 8000 {
+		t.Errorf("port: %d, want the 8000 of the flag", cfg.Listener.Port)
+	}
+
+	// the -config flag wins over the environment variable
+	other := writeConfig(t, `{"listener": {"address": "127.0.0.1"}}`)
+	if cfg, err := loadConfig([]string{"-config", other}, flag.ContinueOnError); err != nil || cfg.Listener.Address != "127.0.0.1" {
+		t.Errorf("config flag: %v %+v", err, cfg.Listener)
+	}
+
+	t.Setenv("CHAT_LOGGING_FORMAT", "xml")
+	if _, err := loadConfig(nil, flag.ContinueOnError); err == nil {
+		t.Errorf("invalid config was accepted")
+	}
+}
+
+func TestReloadOnHangup(t *testing.T) {
+	path := writeConfig(t, `{"logging": {"level": "info"}, "posting": {"likesToPost": 2}}`)
+	args := []string{"-config", path}
+	cfg, err := loadConfig(args, flag.ContinueOnError)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	backendServer := be.NewChatServer(filepath.Join(t.TempDir(), "UserCredentials.json"))
+	backendServer.Configure(cfg)
+	limiter := be.NewRateLimiter(cfg.RateLimit.Requests, cfg.RateLimit.Burst)
+	logLevel := new(slog.LevelVar)
+
+	reload := func(content string) {
+		t.Helper()
+
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+
+		hangup := make(chan os.Signal, 1)
+		hangup <- syscall.SIGHUP
+		close(hangup)
+		watchReload(hangup, args, cfg, backendServer, limiter, logLevel)
+	}
+
+	reload(`{"logging": {"level": "debug"}, "posting": {"likesToPost": 0}, "rateLimit": {"requests": 5, "burst": 5}}`)
+	if logLevel.Level() != slog.LevelDebug || backendServer.LikesToPost != 0 || limiter.Rate != 5 {
+		t.Errorf("after reload: level %v, likes %d, rate %v", logLevel.Level(), backendServer.LikesToPost, limiter.Rate)
+	}
+
+	// an invalid config keeps the current settings
+	reload(`{"logging": {"level": "info"}, "posting": {"likesToPost": -1}}`)
+	if logLevel.Level() != slog.LevelDebug || backendServer.LikesToPost != 0 {
+		t.Errorf("after an invalid reload: level %v, likes %d", logLevel.Level(), backendServer.LikesToPost)
+	}
+}